@@ -0,0 +1,31 @@
+package conf
+
+import "reflect"
+
+// AuditEvent describes a field which a provider supplied a value for. It
+// never holds the value itself, so events can be logged even for secrets.
+type AuditEvent struct {
+	// Field is the name of the struct field.
+	Field string
+	// Key is the key the value was found at and Source names where it was
+	// found, as reported by a ResultProvider. Both are empty for providers
+	// which only implement Provider, and for defaults.
+	Key    string
+	Source string
+	// Default reports whether the value is a default, such as from the
+	// `envDefault` tag.
+	Default bool
+	// Masked reports whether the field holds a secret, either read from a
+	// `secret` tag or tagged `mask:"true"`.
+	Masked bool
+}
+
+func newAuditEvent(sf reflect.StructField, result Result) AuditEvent {
+	return AuditEvent{
+		Field:   sf.Name,
+		Key:     result.Key,
+		Source:  result.Source,
+		Default: result.Default,
+		Masked:  isMasked(sf),
+	}
+}
@@ -0,0 +1,70 @@
+package conf_test
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditSink(t *testing.T) {
+	type config struct {
+		Host     string `env:"HOST" envAlias:"HOSTNAME"`
+		Port     int    `env:"PORT" envDefault:"8080"`
+		User     string `env:"USER"`
+		Password string `secret:"PASSWORD"`
+		Token    string `env:"TOKEN" mask:"true"`
+		Unset    string `env:"UNSET"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("HOSTNAME", "localhost")
+	os.Setenv("PASSWORD", "hunter2")
+	os.Setenv("TOKEN", "abc")
+	dotenv, err := conf.NewDotenvProvider(strings.NewReader("USER=admin"))
+	require.NoError(t, err)
+
+	var events []conf.AuditEvent
+	cfg := config{}
+	err = conf.ParseWithOptions(&cfg, []conf.Option{
+		conf.WithAuditSink(func(e conf.AuditEvent) {
+			events = append(events, e)
+		}),
+	}, conf.EnvProvider, conf.SecretEnvProvider, dotenv)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []conf.AuditEvent{
+		{Field: "Host", Key: "HOSTNAME", Source: "env"},
+		{Field: "Port", Default: true},
+		{Field: "Token", Key: "TOKEN", Source: "env", Masked: true},
+		{Field: "Port", Default: true},
+		{Field: "Password", Key: "PASSWORD", Source: "secret", Masked: true},
+		{Field: "Port", Default: true},
+		{Field: "User", Key: "USER", Source: "dotenv"},
+	}, events)
+	assert.Equal(t, "hunter2", cfg.Password)
+}
+
+func TestAuditSinkProvider(t *testing.T) {
+	type config struct {
+		Host string `env:"HOST"`
+	}
+	provider := providerFunc(func(field reflect.StructField) (string, error) {
+		return "localhost", nil
+	})
+
+	var events []conf.AuditEvent
+	cfg := config{}
+	err := conf.ParseWithOptions(&cfg, []conf.Option{
+		conf.WithAuditSink(func(e conf.AuditEvent) {
+			events = append(events, e)
+		}),
+	}, provider)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []conf.AuditEvent{{Field: "Host"}}, events)
+}
@@ -0,0 +1,104 @@
+package conf_test
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steinfletcher/conf"
+)
+
+func benchmarkSliceValue(n int) string {
+	parts := make([]string, n)
+	for i := range parts {
+		parts[i] = strconv.Itoa(i)
+	}
+	return strings.Join(parts, ",")
+}
+
+func BenchmarkParseLargeSlice(b *testing.B) {
+	type config struct {
+		Ints    []int     `env:"VALUES"`
+		Strings []string  `env:"VALUES"`
+		Ptrs    []*uint32 `env:"VALUES"`
+	}
+	defer os.Clearenv()
+	os.Setenv("VALUES", benchmarkSliceValue(50000))
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var cfg config
+		if err := conf.Parse(&cfg, conf.EnvProvider); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseLargeUniqueSlice(b *testing.B) {
+	type config struct {
+		Ints []int `env:"VALUES" envUnique:"true"`
+	}
+	defer os.Clearenv()
+	os.Setenv("VALUES", benchmarkSliceValue(50000))
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var cfg config
+		if err := conf.Parse(&cfg, conf.EnvProvider); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+type benchmarkDatabase struct {
+	Host     string        `env:"HOST" envDefault:"localhost"`
+	Port     int           `env:"PORT" envDefault:"5432"`
+	User     string        `env:"USER" envDefault:"app"`
+	Password string        `env:"PASSWORD"`
+	Name     string        `env:"NAME" envDefault:"app"`
+	SSL      bool          `env:"SSL"`
+	Timeout  time.Duration `env:"TIMEOUT" envDefault:"5s"`
+	Replicas []string      `env:"REPLICAS"`
+}
+
+type benchmarkConfig struct {
+	Name        string            `env:"NAME,required"`
+	Env         string            `env:"ENV" envDefault:"dev" envOneOf:"dev,staging,prod"`
+	Port        int               `env:"PORT" envDefault:"8080"`
+	Debug       bool              `env:"DEBUG"`
+	Hosts       []string          `env:"HOSTS"`
+	Weights     map[string]int    `env:"WEIGHTS"`
+	Timeout     time.Duration     `env:"TIMEOUT" envDefault:"30s"`
+	Ratio       float64           `env:"RATIO" envDefault:"0.5"`
+	Retries     uint              `env:"RETRIES" envDefault:"3"`
+	Region      string            `env:"REGION" envAlias:"AWS_REGION"`
+	Primary     benchmarkDatabase `envPrefix:"PRIMARY_"`
+	Replica     benchmarkDatabase `envPrefix:"REPLICA_"`
+	Analytics   benchmarkDatabase `envPrefix:"ANALYTICS_"`
+	Token       string            `secret:"TOKEN"`
+	Tags        map[string]string `env:"TAGS"`
+	Concurrency int               `env:"CONCURRENCY" envDefault:"4"`
+}
+
+func BenchmarkParseLargeStruct(b *testing.B) {
+	provider := conf.NewMapProvider(map[string]string{
+		"NAME":             "api",
+		"ENV":              "prod",
+		"HOSTS":            "a,b,c",
+		"WEIGHTS":          "a=1,b=2",
+		"PRIMARY_HOST":     "db.local",
+		"PRIMARY_PASSWORD": "secret",
+		"REPLICA_REPLICAS": "r1,r2",
+		"TAGS":             "team=payments",
+	})
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var cfg benchmarkConfig
+		if err := conf.Parse(&cfg, provider, conf.SecretEnvProvider); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package conf
+
+import "reflect"
+
+// NewChainProvider returns a provider which resolves each field from the first
+// of providers to find a value which is not empty. Within each provider the
+// keys of the field are tried in order, the key of its tag and then those of
+// `envAlias`, so provider order takes precedence over key order:
+//
+//	for each provider
+//		for each key
+//			return the value if it is not empty
+//
+// The `envDefault` and `envMissing` tags and the `required` and `notEmpty`
+// options apply to the chain as a whole rather than to each provider, so the
+// default is only used when no provider has a value.
+func NewChainProvider(providers ...Provider) Provider {
+	return chainProvider{providers: providers}
+}
+
+type chainProvider struct {
+	providers []Provider
+	opts      options
+}
+
+func (c chainProvider) withOptions(opts options) Provider {
+	providers := make([]Provider, len(c.providers))
+	for i, p := range c.providers {
+		if cp, ok := p.(configurableProvider); ok {
+			p = cp.withOptions(opts)
+		}
+		providers[i] = p
+	}
+	return chainProvider{providers: providers, opts: opts}
+}
+
+func (c chainProvider) Provide(field reflect.StructField) (string, error) {
+	result, err := c.ProvideResult(field)
+	return result.Value, err
+}
+
+func (c chainProvider) ProvideResult(field reflect.StructField) (Result, error) {
+	inner := withoutFallbacks(field)
+
+	var warnings []string
+	for _, p := range c.providers {
+		result, err := provideResult(c.opts.context(), p, inner)
+		warnings = append(warnings, result.Warnings...)
+		if err != nil {
+			return Result{Warnings: warnings}, err
+		}
+		if result.Value != "" {
+			result.Warnings = warnings
+			return c.fetch(result, field)
+		}
+	}
+
+	key, opts := chainKeyOptions(field)
+	if key != "" {
+		warning, err := checkMissing(field, key, false)
+		if err != nil {
+			return Result{Warnings: warnings}, err
+		}
+		if warning != "" {
+			warnings = append(warnings, warning)
+		}
+	}
+	result := Result{Warnings: warnings}
+	result.Value, result.Default = field.Tag.Lookup("envDefault")
+	var err error
+	result.Value, err = checkOptions(key, opts, result.Value, false, c.opts.strictRequired)
+	if err == nil && result.Value != "" && hasOption(opts, "file") {
+		result.Value, err = readValueFile(key, result.Value, field)
+	}
+	if err != nil || result.Value == "" {
+		return result, err
+	}
+	return c.fetch(result, field)
+}
+
+// fetch applies the `fetch` option, which withoutFallbacks hides from the
+// providers, to the value the chain resolved, so the URL is fetched once
+// whichever provider it came from.
+func (c chainProvider) fetch(result Result, field reflect.StructField) (Result, error) {
+	key, opts := chainKeyOptions(field)
+	if !hasOption(opts, "fetch") {
+		return result, nil
+	}
+	if result.Key != "" {
+		key = result.Key
+	}
+	var err error
+	result.Value, err = fetchValue(c.opts.context(), key, result.Value, field)
+	return result, err
+}
+
+// withoutFallbacks removes the `envDefault` tag and the tag options applied
+// by the chain from field, such as `fetch`, so each provider only reports
+// what it finds.
+func withoutFallbacks(field reflect.StructField) reflect.StructField {
+	var tags []structTag
+	for _, tag := range parseTag(field.Tag) {
+		if tag.name == "envDefault" || tag.name == "envMissing" {
+			continue
+		}
+		if contains(prefixedTags, tag.name) {
+			// the file option is kept as the providers read the files
+			key, opts := parseKeyForOption(tag.value)
+			tag.value = key
+			if hasOption(opts, "file") {
+				tag.value += ",file"
+			}
+		}
+		tags = append(tags, tag)
+	}
+	field.Tag = formatTag(tags)
+	return field
+}
+
+// chainKeyOptions returns the first key of the field and the options of all
+// of its key tags.
+func chainKeyOptions(field reflect.StructField) (string, []string) {
+	var key string
+	var opts []string
+	for _, name := range prefixedTags {
+		tag, ok := field.Tag.Lookup(name)
+		if !ok {
+			continue
+		}
+		k, o := parseKeyForOption(tag)
+		if key == "" {
+			key = k
+		}
+		opts = append(opts, o...)
+	}
+	return key, opts
+}
@@ -0,0 +1,166 @@
+package conf_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainProviderResolutionOrder(t *testing.T) {
+	type config struct {
+		URL string `env:"DATABASE_URL" envAlias:"DB_URL,PG_URL" envDefault:"postgres://localhost"`
+	}
+	tests := []struct {
+		name   string
+		env    map[string]string
+		dotenv string
+		want   string
+	}{
+		{
+			name: "default when no provider has a key",
+			want: "postgres://localhost",
+		},
+		{
+			name: "key in first provider",
+			env:  map[string]string{"DATABASE_URL": "env"},
+			want: "env",
+		},
+		{
+			name:   "key in second provider",
+			dotenv: "DATABASE_URL=dotenv",
+			want:   "dotenv",
+		},
+		{
+			name:   "first provider wins",
+			env:    map[string]string{"DATABASE_URL": "env"},
+			dotenv: "DATABASE_URL=dotenv",
+			want:   "env",
+		},
+		{
+			name:   "alias in first provider wins over key in second",
+			env:    map[string]string{"PG_URL": "env alias"},
+			dotenv: "DATABASE_URL=dotenv",
+			want:   "env alias",
+		},
+		{
+			name: "key wins over alias in the same provider",
+			env:  map[string]string{"DATABASE_URL": "env", "DB_URL": "env alias"},
+			want: "env",
+		},
+		{
+			name: "aliases are tried in order",
+			env:  map[string]string{"DB_URL": "first alias", "PG_URL": "second alias"},
+			want: "first alias",
+		},
+		{
+			name: "empty key falls through to alias",
+			env:  map[string]string{"DATABASE_URL": "", "DB_URL": "env alias"},
+			want: "env alias",
+		},
+		{
+			name:   "empty in first provider falls through to second",
+			env:    map[string]string{"DATABASE_URL": ""},
+			dotenv: "DB_URL=dotenv alias",
+			want:   "dotenv alias",
+		},
+		{
+			name:   "all empty uses default",
+			env:    map[string]string{"DATABASE_URL": ""},
+			dotenv: "DB_URL=",
+			want:   "postgres://localhost",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer os.Clearenv()
+			for k, v := range tt.env {
+				os.Setenv(k, v)
+			}
+			dotenv, err := conf.NewDotenvProvider(strings.NewReader(tt.dotenv))
+			require.NoError(t, err)
+
+			cfg := config{}
+			assert.NoError(t, conf.Parse(&cfg, conf.NewChainProvider(conf.EnvProvider, dotenv)))
+			assert.Equal(t, tt.want, cfg.URL)
+		})
+	}
+}
+
+func TestChainProviderRequired(t *testing.T) {
+	type config struct {
+		Host string `env:"HOST,required" envAlias:"HOSTNAME"`
+	}
+	defer os.Clearenv()
+
+	dotenv, err := conf.NewDotenvProvider(strings.NewReader("HOSTNAME=dotenv"))
+	require.NoError(t, err)
+	chain := conf.NewChainProvider(conf.EnvProvider, dotenv)
+
+	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, chain))
+	assert.Equal(t, "dotenv", cfg.Host)
+
+	empty, err := conf.NewDotenvProvider(strings.NewReader(""))
+	require.NoError(t, err)
+	assert.EqualError(t, conf.Parse(&cfg, conf.NewChainProvider(conf.EnvProvider, empty)), `env: required environment variable "HOST" is not set`)
+}
+
+func TestChainProviderWarnings(t *testing.T) {
+	type config struct {
+		Host string `env:"HOST,required" envDeprecated:"OLD_HOST"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("OLD_HOST", "old")
+	dotenv, err := conf.NewDotenvProvider(strings.NewReader(""))
+	require.NoError(t, err)
+
+	cfg := config{}
+	warnings, err := conf.ParseWithWarnings(&cfg, conf.NewChainProvider(dotenv, conf.EnvProvider))
+	assert.NoError(t, err)
+	assert.Equal(t, "old", cfg.Host)
+	assert.Equal(t, []string{`env: environment variable "OLD_HOST" is deprecated, use "HOST" instead`}, warnings)
+}
+
+func TestChainProviderMissingPolicy(t *testing.T) {
+	type config struct {
+		Host   string `env:"HOST" envMissing:"warn"`
+		Region string `env:"REGION" envMissing:"error"`
+	}
+	defer os.Clearenv()
+
+	dotenv, err := conf.NewDotenvProvider(strings.NewReader("HOST=dotenv\nREGION=eu-west-1"))
+	require.NoError(t, err)
+	cfg := config{}
+	warnings, err := conf.ParseWithWarnings(&cfg, conf.NewChainProvider(conf.EnvProvider, dotenv))
+	require.NoError(t, err)
+	assert.Equal(t, config{Host: "dotenv", Region: "eu-west-1"}, cfg)
+	assert.Empty(t, warnings)
+
+	empty, err := conf.NewDotenvProvider(strings.NewReader("REGION=eu-west-1"))
+	require.NoError(t, err)
+	warnings, err = conf.ParseWithWarnings(&config{}, conf.NewChainProvider(conf.EnvProvider, empty))
+	require.NoError(t, err)
+	assert.Equal(t, []string{`env: environment variable "HOST" is not set`}, warnings)
+
+	hostOnly, err := conf.NewDotenvProvider(strings.NewReader("HOST=dotenv"))
+	require.NoError(t, err)
+	assert.EqualError(t, conf.Parse(&config{}, conf.NewChainProvider(conf.EnvProvider, hostOnly)), `env: required environment variable "REGION" is not set`)
+}
+
+func TestEnvAlias(t *testing.T) {
+	type config struct {
+		Host string `env:"HOST" envAlias:"HOSTNAME"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("HOSTNAME", "alias")
+
+	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, "alias", cfg.Host)
+}
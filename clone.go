@@ -0,0 +1,79 @@
+package conf
+
+import "reflect"
+
+// CloneWithOverrides deep copies the struct base points to and parses the copy
+// with provider, returning a pointer to the copy. base is left unchanged, so a
+// shared config can be derived per tenant or per request. The values of base
+// act as the defaults of the copy, like with WithInCodeDefaults, so only the
+// values found by provider are overridden, and required fields which are set
+// in base need not be repeated by provider.
+func CloneWithOverrides(base interface{}, provider Provider) (interface{}, error) {
+	ptrRef := reflect.ValueOf(base)
+	if ptrRef.Kind() != reflect.Ptr || ptrRef.IsNil() || ptrRef.Elem().Kind() != reflect.Struct {
+		return nil, ErrNotAStructPtr
+	}
+	clone := deepCopy(ptrRef)
+	if err := ParseWithOptions(clone.Interface(), []Option{WithInCodeDefaults()}, provider); err != nil {
+		return nil, err
+	}
+	return clone.Interface(), nil
+}
+
+// deepCopy copies v along with the pointers, slices and maps it holds.
+// Unexported fields are copied as they are, and pointers to types without
+// exported fields, such as *time.Location, are shared since their values can
+// only be changed through their methods.
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || isOpaque(v.Type().Elem()) {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(deepCopy(v.Elem()))
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(deepCopy(v.Field(i)))
+			}
+		}
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+		}
+		return c
+	default:
+		return v
+	}
+}
+
+func isOpaque(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).PkgPath == "" {
+			return false
+		}
+	}
+	return true
+}
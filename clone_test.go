@@ -0,0 +1,117 @@
+package conf_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tenantDatabase struct {
+	Host string `env:"DB_HOST"`
+	Port int    `env:"DB_PORT"`
+}
+
+type tenantConfig struct {
+	Name     string         `env:"NAME"`
+	Region   string         `env:"REGION" envDefault:"eu-west-1"`
+	Features []string       `env:"FEATURES"`
+	Limits   map[string]int `env:"LIMITS"`
+	Database *tenantDatabase
+	Cache    struct {
+		TTL time.Duration `env:"CACHE_TTL"`
+	}
+	Location *time.Location `env:"TZ"`
+	Labels   map[string]string
+}
+
+func newTenantBase() *tenantConfig {
+	base := &tenantConfig{
+		Name:     "base",
+		Region:   "us-east-1",
+		Features: []string{"a", "b"},
+		Limits:   map[string]int{"rps": 10},
+		Database: &tenantDatabase{Host: "db.internal", Port: 5432},
+		Location: time.UTC,
+		Labels:   map[string]string{"team": "core"},
+	}
+	base.Cache.TTL = time.Minute
+	return base
+}
+
+func TestCloneWithOverrides(t *testing.T) {
+	base := newTenantBase()
+	provider, err := conf.NewDotenvProvider(strings.NewReader(`
+NAME=tenant
+FEATURES=c
+LIMITS=rps=50
+DB_HOST=tenant.db.internal
+CACHE_TTL=5m
+`))
+	require.NoError(t, err)
+
+	clone, err := conf.CloneWithOverrides(base, provider)
+	require.NoError(t, err)
+	cfg := clone.(*tenantConfig)
+
+	assert.Equal(t, "tenant", cfg.Name)
+	assert.Equal(t, "us-east-1", cfg.Region)
+	assert.Equal(t, []string{"c"}, cfg.Features)
+	assert.Equal(t, map[string]int{"rps": 50}, cfg.Limits)
+	assert.Equal(t, &tenantDatabase{Host: "tenant.db.internal", Port: 5432}, cfg.Database)
+	assert.Equal(t, 5*time.Minute, cfg.Cache.TTL)
+	assert.Equal(t, time.UTC, cfg.Location)
+
+	assert.Equal(t, newTenantBase(), base)
+}
+
+func TestCloneWithOverridesCopiesDeeply(t *testing.T) {
+	base := newTenantBase()
+	provider, err := conf.NewDotenvProvider(strings.NewReader(""))
+	require.NoError(t, err)
+
+	clone, err := conf.CloneWithOverrides(base, provider)
+	require.NoError(t, err)
+	cfg := clone.(*tenantConfig)
+	assert.Equal(t, base, cfg)
+
+	cfg.Features[0] = "changed"
+	cfg.Database.Port = 1
+	cfg.Labels["team"] = "changed"
+	assert.Equal(t, newTenantBase(), base)
+}
+
+func TestCloneWithOverridesError(t *testing.T) {
+	base := newTenantBase()
+	provider, err := conf.NewDotenvProvider(strings.NewReader("DB_PORT=invalid"))
+	require.NoError(t, err)
+
+	_, err = conf.CloneWithOverrides(base, provider)
+	assert.EqualError(t, err, `env: parse error on field "Port" of type "int": strconv.ParseInt: parsing "invalid": invalid syntax`)
+	assert.Equal(t, newTenantBase(), base)
+
+	_, err = conf.CloneWithOverrides(*base, provider)
+	assert.Equal(t, conf.ErrNotAStructPtr, err)
+}
+
+func TestCloneWithOverridesRequired(t *testing.T) {
+	type config struct {
+		Host    string `env:"HOST,required"`
+		Token   string `env:"TOKEN,notEmpty"`
+		Region  string `env:"REGION" envMissing:"error"`
+		Port    int    `env:"PORT,required"`
+		Timeout string `env:"TIMEOUT,required"`
+	}
+
+	base := &config{Host: "db.internal", Token: "t0ken", Region: "eu-west-1", Port: 5432}
+	overrides := conf.NewMapProvider(map[string]string{"PORT": "6432", "TIMEOUT": "5s"})
+	clone, err := conf.CloneWithOverrides(base, overrides)
+	require.NoError(t, err)
+	assert.Equal(t, &config{Host: "db.internal", Token: "t0ken", Region: "eu-west-1", Port: 6432, Timeout: "5s"}, clone)
+
+	_, err = conf.CloneWithOverrides(base, conf.NewMapProvider(map[string]string{"PORT": "6432"}))
+	assert.EqualError(t, err, `env: required environment variable "TIMEOUT" is not set`)
+}
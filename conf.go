@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/mail"
+	"net/netip"
 	"net/url"
 	"reflect"
 	"strconv"
@@ -20,6 +22,9 @@ var (
 	// Struct to Parse
 	ErrNotAStructPtr = errors.New("env: expected a pointer to a Struct")
 
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	confParserType      = reflect.TypeOf((*confParser)(nil)).Elem()
+
 	defaultBuiltInParsers = map[reflect.Kind]ParserFunc{
 		reflect.Bool: func(v string) (interface{}, error) {
 			return strconv.ParseBool(v)
@@ -28,7 +33,7 @@ var (
 			return v, nil
 		},
 		reflect.Int: func(v string) (interface{}, error) {
-			i, err := strconv.ParseInt(v, 10, 32)
+			i, err := strconv.ParseInt(v, 10, strconv.IntSize)
 			return int(i), err
 		},
 		reflect.Int16: func(v string) (interface{}, error) {
@@ -47,7 +52,7 @@ var (
 			return int8(i), err
 		},
 		reflect.Uint: func(v string) (interface{}, error) {
-			i, err := strconv.ParseUint(v, 10, 32)
+			i, err := strconv.ParseUint(v, 10, strconv.IntSize)
 			return uint(i), err
 		},
 		reflect.Uint16: func(v string) (interface{}, error) {
@@ -83,6 +88,13 @@ var (
 			}
 			return *u, nil
 		},
+		reflect.TypeOf(RedactedURL{}): func(v string) (interface{}, error) {
+			u, err := parseRedactedURL(v)
+			if err != nil {
+				return nil, fmt.Errorf("unable to parse URL: %v", err)
+			}
+			return u, nil
+		},
 		reflect.TypeOf(time.Nanosecond): func(v string) (interface{}, error) {
 			s, err := time.ParseDuration(v)
 			if err != nil {
@@ -90,21 +102,153 @@ var (
 			}
 			return s, err
 		},
+		reflect.TypeOf(ExtDuration(0)): func(v string) (interface{}, error) {
+			d, err := parseExtDuration(v)
+			if err != nil {
+				return nil, fmt.Errorf("unable to parse duration: %v", err)
+			}
+			return d, nil
+		},
+		outputFileType: func(v string) (interface{}, error) {
+			f, err := parseOutputFile(v)
+			if err != nil {
+				return nil, fmt.Errorf("unable to open output file: %v", err)
+			}
+			return f, nil
+		},
+		inputFileType: func(v string) (interface{}, error) {
+			f, err := parseInputFile(v)
+			if err != nil {
+				return nil, fmt.Errorf("unable to open input file: %v", err)
+			}
+			return f, nil
+		},
+		reflect.TypeOf(mail.Address{}): func(v string) (interface{}, error) {
+			address, err := mail.ParseAddress(v)
+			if err != nil {
+				return nil, fmt.Errorf("unable to parse address: %v", err)
+			}
+			return address, nil
+		},
+		reflect.TypeOf(time.UTC): func(v string) (interface{}, error) {
+			switch strings.ToLower(v) {
+			case "utc":
+				return time.UTC, nil
+			case "local":
+				return time.Local, nil
+			}
+			loc, err := time.LoadLocation(v)
+			if err != nil {
+				return nil, fmt.Errorf("unable to load location: %v", err)
+			}
+			return loc, nil
+		},
+		reflect.TypeOf(time.Sunday): func(v string) (interface{}, error) {
+			for d := time.Sunday; d <= time.Saturday; d++ {
+				if strings.EqualFold(v, d.String()) || strings.EqualFold(v, d.String()[:3]) || v == strconv.Itoa(int(d)) {
+					return d, nil
+				}
+			}
+			return nil, fmt.Errorf("unknown weekday %q", v)
+		},
+		reflect.TypeOf(netip.Addr{}): func(v string) (interface{}, error) {
+			addr, err := netip.ParseAddr(v)
+			if err != nil {
+				return nil, fmt.Errorf("unable to parse IP address: %v", err)
+			}
+			return addr, nil
+		},
+		reflect.TypeOf(netip.Prefix{}): func(v string) (interface{}, error) {
+			prefix, err := netip.ParsePrefix(v)
+			if err != nil {
+				return nil, fmt.Errorf("unable to parse IP prefix: %v", err)
+			}
+			return prefix, nil
+		},
 	}
 )
 
-// ParserFunc defines the signature of a function that can be used within `CustomParsers`
+// ParserFunc defines the signature of a function that can be used within `CustomParsers`.
+// It may return either a value or a pointer to a value of the type it parses.
 type ParserFunc func(v string) (interface{}, error)
 
 // Parse parses a struct containing `env` tags and loads its values from
 // environment variables.
 func Parse(v interface{}, providers ...Provider) error {
-	for _, provider := range providers {
-		if err := ParseWithFuncs(v, map[reflect.Type]ParserFunc{}, provider); err != nil {
-			return err
+	return ParseWithOptions(v, nil, providers...)
+}
+
+// ParseWithOptions is the same as `Parse` except it also accepts options which
+// change how the struct is parsed.
+func ParseWithOptions(v interface{}, opts []Option, providers ...Provider) (err error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	var resolved map[resolvedKey]bool
+	provided := map[resolvedKey]bool{}
+	files := openedFiles{}
+	defer func() {
+		if err != nil {
+			files.closeAll()
 		}
+	}()
+	var missing *MissingRequiredError
+	if o.allMissing {
+		missing = &MissingRequiredError{}
 	}
-	return nil
+	if err := checkPatterns(v); err != nil {
+		return withErrorPrefix(err, o.errorPrefix)
+	}
+	if o.defaults != nil {
+		resolved = map[resolvedKey]bool{}
+		providers = append(providers[:len(providers):len(providers)], o.defaults)
+	}
+	for i, provider := range providers {
+		if c, ok := provider.(configurableProvider); ok {
+			provider = c.withOptions(o)
+		}
+		p := &parser{provider: provider, opts: o, subtrees: i == 0, resolved: resolved, provided: provided, files: files, missing: missing}
+		p.fallback = o.defaults != nil && i == len(providers)-1
+		if err := p.parsePtr(v); err != nil {
+			return withErrorPrefix(withoutValues(err, o), o.errorPrefix)
+		}
+	}
+	if missing != nil && len(missing.Missing) > 0 {
+		return withErrorPrefix(missing, o.errorPrefix)
+	}
+	return withErrorPrefix(afterParse(v, o, provided), o.errorPrefix)
+}
+
+// ParseWithWarnings is the same as `Parse` except it also returns the
+// non-fatal warnings raised while parsing, such as a value being read from a
+// key declared in `envDeprecated`. Warnings never abort parsing, and those
+// raised before an error are returned with it.
+func ParseWithWarnings(v interface{}, providers ...Provider) ([]string, error) {
+	var warnings []string
+	err := ParseWithOptions(v, []Option{
+		WithWarningHandler(func(warning string) {
+			warnings = append(warnings, warning)
+		}),
+	}, providers...)
+	return warnings, err
+}
+
+// ParseWithDefaultsUsed is the same as `Parse` except it also returns, by
+// field name, whether each field which was set used its default, such as
+// from the `envDefault` tag, rather than a value found by a provider. Fields
+// which were not set are not in the map.
+func ParseWithDefaultsUsed(v interface{}, providers ...Provider) (map[string]bool, error) {
+	defaultsUsed := map[string]bool{}
+	err := ParseWithOptions(v, []Option{
+		WithAuditSink(func(event AuditEvent) {
+			// a value found by any provider wins over a default of another
+			if _, ok := defaultsUsed[event.Field]; !ok || !event.Default {
+				defaultsUsed[event.Field] = event.Default
+			}
+		}),
+	}, providers...)
+	return defaultsUsed, err
 }
 
 // MustParse is a helper function to ensure the config is valid and there was no  error when calling the Parse function.
@@ -116,8 +260,119 @@ func MustParse(v interface{}, providers ...Provider) {
 }
 
 // ParseWithFuncs is the same as `Parse` except it also allows the user to pass
-// in custom parsers.
+// in custom parsers. Custom parsers take precedence over `encoding.TextUnmarshaler`
+// implementations and the default parsers.
 func ParseWithFuncs(v interface{}, funcMap map[reflect.Type]ParserFunc, provider Provider) error {
+	p := &parser{funcMap: funcMap, provider: provider, subtrees: true, provided: map[resolvedKey]bool{}, files: openedFiles{}}
+	err := checkPatterns(v)
+	if err == nil {
+		err = p.parsePtr(v)
+	}
+	if err == nil {
+		err = afterParse(v, p.opts, p.provided)
+	}
+	if err != nil {
+		p.files.closeAll()
+	}
+	return err
+}
+
+// afterParse runs the checks which need every field to be resolved first.
+// provided holds the fields which were set from a source rather than a
+// default.
+func afterParse(v interface{}, opts options, provided map[resolvedKey]bool) error {
+	ptrRef := reflect.ValueOf(v)
+	if ptrRef.Kind() != reflect.Ptr || ptrRef.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	if err := checkRequiredIf(ptrRef.Elem(), opts); err != nil {
+		return err
+	}
+	if err := checkItems(ptrRef.Elem(), opts); err != nil {
+		return err
+	}
+	if err := checkOneOfGroups(ptrRef.Elem(), opts); err != nil {
+		return err
+	}
+	if err := checkMustProvide(ptrRef.Elem(), opts, provided); err != nil {
+		return err
+	}
+	if !opts.validators {
+		return nil
+	}
+	return checkValidators(ptrRef.Elem(), opts)
+}
+
+// parser holds the state shared by a single pass over a struct with one provider.
+type parser struct {
+	funcMap  map[reflect.Type]ParserFunc
+	provider Provider
+	opts     options
+	// prefix is prepended to the keys of the fields, from the `envPrefix`
+	// tags of the struct fields being parsed.
+	prefix string
+	// subtrees reports whether this pass parses the struct fields tagged with
+	// `confProvider`, which is done once rather than by every provider.
+	subtrees bool
+	// resolved records the fields set by a provider when there is a defaults
+	// provider, which is the provider of the fallback pass and only sets the
+	// fields which are not resolved yet.
+	resolved map[resolvedKey]bool
+	fallback bool
+	// provided records the fields set from a source rather than a default,
+	// for the `mustProvide` tag option.
+	provided map[resolvedKey]bool
+	// files records the files opened for OutputFile and InputFile fields, which
+	// are closed when a later provider replaces them or parsing fails.
+	files openedFiles
+	// missing collects the required variables which are not set, with
+	// WithAllMissingRequired.
+	missing *MissingRequiredError
+}
+
+// resolvedKey identifies a field of the struct being parsed. The type tells a
+// struct field apart from its first field, which has the same address.
+type resolvedKey struct {
+	addr  uintptr
+	typee reflect.Type
+}
+
+func newResolvedKey(field reflect.Value) resolvedKey {
+	return resolvedKey{addr: field.Addr().Pointer(), typee: field.Type()}
+}
+
+// withProvider returns the parser for the fields of the struct field sf,
+// which are read by the provider named by its `confProvider` tag.
+func (p *parser) withProvider(sf reflect.StructField, name string) (*parser, error) {
+	if _, ok := p.provider.(defaultsProvider); ok {
+		// the defaults of a subtree are its envDefault tags like anywhere else
+		return p.withPrefix(sf), nil
+	}
+	provider, ok := lookupProvider(name)
+	if !ok {
+		return nil, newError(`field "%s" has unknown confProvider %q`, sf.Name, name)
+	}
+	if c, ok := provider.(configurableProvider); ok {
+		provider = c.withOptions(p.opts)
+	}
+	nested := *p.withPrefix(sf)
+	nested.provider = provider
+	return &nested, nil
+}
+
+// withPrefix returns the parser for the fields of the struct field sf, which
+// adds the `envPrefix` of sf to the prefix.
+func (p *parser) withPrefix(sf reflect.StructField) *parser {
+	prefix, ok := sf.Tag.Lookup("envPrefix")
+	if !ok {
+		return p
+	}
+	nested := *p
+	nested.prefix += prefix
+	return &nested
+}
+
+func (p *parser) parsePtr(v interface{}) error {
 	ptrRef := reflect.ValueOf(v)
 	if ptrRef.Kind() != reflect.Ptr {
 		return ErrNotAStructPtr
@@ -126,86 +381,247 @@ func ParseWithFuncs(v interface{}, funcMap map[reflect.Type]ParserFunc, provider
 	if ref.Kind() != reflect.Struct {
 		return ErrNotAStructPtr
 	}
-	var parsers = defaultTypeParsers
-	for k, v := range funcMap {
-		parsers[k] = v
-	}
-	return doParse(ref, parsers, provider)
+	return p.parse(ref)
 }
 
-func doParse(ref reflect.Value, funcMap map[reflect.Type]ParserFunc, provider Provider) error {
-	var refType = ref.Type()
-
-	for i := 0; i < refType.NumField(); i++ {
-		refField := ref.Field(i)
+func (p *parser) parse(ref reflect.Value) error {
+	for _, f := range planFor(ref.Type()).fields {
+		refField := ref.Field(f.index)
 		if !refField.CanSet() {
 			continue
 		}
+		refTypeField := f.sf
+		if p.opts.fieldFilter != nil && !p.opts.fieldFilter(refTypeField) {
+			continue
+		}
+		if f.hasProvider {
+			if err := p.parseSubtree(refField, refTypeField, f.provider); err != nil {
+				return err
+			}
+			continue
+		}
+		if u, ok := asEnvUnmarshaler(refField); ok {
+			if err := p.withPrefix(refTypeField).unmarshalEnv(u, refTypeField); err != nil {
+				return err
+			}
+			continue
+		}
+		if isCredentials(refField.Type()) {
+			if err := p.parseCredentials(refField, refTypeField); err != nil {
+				return err
+			}
+			continue
+		}
+		if f.nested {
+			if err := p.parseNested(refField, refTypeField); err != nil {
+				return err
+			}
+			continue
+		}
 		if reflect.Ptr == refField.Kind() && !refField.IsNil() {
-			err := ParseWithFuncs(refField.Interface(), funcMap, provider)
+			err := p.withPrefix(refTypeField).parsePtr(refField.Interface())
 			if err != nil {
 				return err
 			}
 			continue
 		}
 		if reflect.Struct == refField.Kind() && refField.CanAddr() && refField.Type().Name() == "" {
-			err := Parse(refField.Addr().Interface(), provider)
+			err := p.withPrefix(refTypeField).parsePtr(refField.Addr().Interface())
 			if nil != err {
 				return err
 			}
 			continue
 		}
-		refTypeField := refType.Field(i)
-		value, err := provider.Provide(refTypeField)
+		if p.fallback && p.resolved[newResolvedKey(refField)] {
+			continue
+		}
+		sp := p
+		if f.hasSource {
+			var skip bool
+			if sp, skip = p.withSource(refTypeField, f.source); skip {
+				continue
+			}
+		}
+		providedField := refTypeField
+		if p.opts.inCodeDefaults && !refField.IsZero() {
+			providedField = withoutRequired(refTypeField)
+		}
+		result, err := sp.provide(providedField)
 		if err != nil {
 			return err
 		}
+		value := result.Value
+		if value != "" && !result.Default && !p.fallback && p.provided != nil {
+			p.provided[newResolvedKey(refField)] = true
+		}
 		if value == "" {
 			if reflect.Struct == refField.Kind() {
-				if err := doParse(refField, funcMap, provider); err != nil {
+				if err := p.withPrefix(refTypeField).parse(refField); err != nil {
+					return err
+				}
+			}
+			if isSeededMap(refField, refTypeField) && refField.IsNil() {
+				if err := p.setMap(refField, nil, refTypeField); err != nil {
 					return err
 				}
 			}
+			if err := setRegisteredDefault(refField, refTypeField); err != nil {
+				return err
+			}
 			continue
 		}
-		if err := set(refField, refTypeField, value, funcMap); err != nil {
+		fp, err := p.withEnvParser(refTypeField)
+		if err != nil {
 			return err
 		}
+		previous := p.files.held(refField)
+		if result.Values != nil && reflect.Slice == refField.Kind() {
+			err = fp.setSlice(refField, result.Values, refTypeField)
+		} else if result.Values != nil && reflect.Map == refField.Kind() {
+			err = fp.setMap(refField, result.Values, refTypeField)
+		} else {
+			err = fp.set(refField, refTypeField, value)
+		}
+		if err == nil {
+			err = dedupe(refField, refTypeField)
+		}
+		if err == nil {
+			err = checkNoDuplicates(refField, refTypeField)
+		}
+		if err == nil {
+			err = fp.checkOneOf(refField, refTypeField)
+		}
+		if err == nil {
+			err = checkPattern(refField, refTypeField, f.pattern)
+		}
+		if err != nil {
+			return err
+		}
+		p.files.closeReplaced(previous, refField)
+		if p.resolved != nil {
+			p.resolved[newResolvedKey(refField)] = true
+		}
 	}
 	return nil
 }
 
-func set(field reflect.Value, sf reflect.StructField, value string, funcMap map[reflect.Type]ParserFunc) error {
+// parseSubtree parses a struct field tagged with `confProvider` using the
+// named provider instead of the provider of the pass.
+func (p *parser) parseSubtree(field reflect.Value, sf reflect.StructField, name string) error {
+	if !p.subtrees {
+		return nil
+	}
+	nested, err := p.withProvider(sf, name)
+	if err != nil {
+		return err
+	}
+	switch {
+	case reflect.Struct == field.Kind():
+		return nested.parse(field)
+	case reflect.Ptr == field.Kind() && reflect.Struct == field.Type().Elem().Kind():
+		if field.IsNil() {
+			return nil
+		}
+		return nested.parsePtr(field.Interface())
+	default:
+		return newError(`field "%s" has confProvider but is not a struct`, sf.Name)
+	}
+}
+
+// provide resolves the value of a field, reporting any warnings raised by a
+// ResultProvider.
+func (p *parser) provide(sf reflect.StructField) (Result, error) {
+	sf = withKeyPrefix(sf, p.prefix)
+	result, err := provideResult(p.opts.context(), p.provider, sf)
+	for _, w := range result.Warnings {
+		p.opts.warn(errorPrefix(p.opts.errorPrefix) + ": " + w)
+	}
+	if e, ok := err.(prefixedError); ok && e.missing != "" && p.missing != nil {
+		p.missing.add(sf, e.missing)
+		return Result{}, nil
+	}
+	if err != nil && p.opts.neverEcho {
+		err = withoutProviderValues(sf, err)
+	}
+	if strings.ToLower(sf.Tag.Get("envUnquote")) == "true" {
+		result.Value = unquote(result.Value)
+		if result.Values != nil {
+			values := make([]string, len(result.Values))
+			for i, v := range result.Values {
+				values[i] = unquote(v)
+			}
+			result.Values = values
+		}
+	}
+	if err == nil {
+		result, err = transformResult(sf, result)
+	}
+	if result.Default && p.opts.inCodeDefaults {
+		return Result{}, err
+	}
+	if err == nil && result.Value != "" && p.opts.auditSink != nil {
+		p.opts.auditSink(newAuditEvent(sf, result))
+	}
+	return result, err
+}
+
+// unquote strips a pair of matching single or double quotes around v.
+func unquote(v string) string {
+	if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+		return v[1 : len(v)-1]
+	}
+	return v
+}
+
+func (p *parser) set(field reflect.Value, sf reflect.StructField, value string) error {
+	if field.Type() == orderedPairsType {
+		return handleOrderedPairs(field, value, sf)
+	}
 	if field.Kind() == reflect.Slice {
-		return handleSlice(field, value, sf, funcMap)
+		return p.handleSlice(field, value, sf)
+	}
+	if field.Kind() == reflect.Map {
+		return p.handleMap(field, value, sf)
 	}
 
-	var tm = asTextUnmarshaler(field)
-	valBytes := []byte(value)
-	if tm != nil {
-		var err = tm.UnmarshalText(valBytes)
-		return newParseError(sf, err)
+	// parsers registered for a pointer type, such as *time.Location, set the
+	// pointer they return rather than a copy of the value it points to
+	if sf.Type.Kind() == reflect.Ptr {
+		if parserFunc, ok := p.typeParser(sf.Type); ok {
+			return setParsed(field, sf, value, parserFunc)
+		}
 	}
 
 	var typee = sf.Type
 	var fieldee = field
 	if typee.Kind() == reflect.Ptr {
 		typee = typee.Elem()
+		if field.IsNil() {
+			field.Set(reflect.New(typee))
+		}
 		fieldee = field.Elem()
 	}
 
-	parserFunc, ok := funcMap[typee]
+	parserFunc, ok := p.typeParser(typee)
 	if ok {
-		val, err := parserFunc(value)
-		if err != nil {
-			return newParseError(sf, err)
+		if err := setParsed(fieldee, sf, value, parserFunc); err != nil {
+			return err
 		}
+		return normalizeURL(fieldee, sf, value)
+	}
 
-		fieldee.Set(reflect.ValueOf(val))
-		return nil
+	var tm = asTextUnmarshaler(field)
+	valBytes := []byte(value)
+	if tm != nil {
+		var err = tm.UnmarshalText(valBytes)
+		return newParseError(sf, err)
+	}
+
+	if cp := asConfParser(field); cp != nil {
+		return newParseError(sf, cp.ParseConf(value))
 	}
 
-	parserFunc, ok = defaultBuiltInParsers[typee.Kind()]
+	parserFunc, ok = p.builtInParser(typee.Kind())
 	if ok {
 		val, err := parserFunc(value)
 		if err != nil {
@@ -216,15 +632,28 @@ func set(field reflect.Value, sf reflect.StructField, value string, funcMap map[
 		return nil
 	}
 
+	if u, ok := lookupUnion(typee); ok {
+		return newParseError(sf, p.decodeJSONUnion(valBytes, fieldee, u))
+	}
+
 	if typee.Kind() == reflect.Struct {
 		if json.Valid(valBytes) && isJSONObj(valBytes) {
-			i := reflect.New(typee)
-			newP := i.Interface()
-			err := json.Unmarshal(valBytes, newP)
-			if err != nil {
+			if err := p.checkSchema(sf, valBytes); err != nil {
+				return err
+			}
+			i := reflect.New(typee).Elem()
+			if err := p.decodeJSON(valBytes, i); err != nil {
+				return newParseError(sf, err)
+			}
+			fieldee.Set(i)
+			return nil
+		}
+		if isQuery(value) {
+			i := reflect.New(typee).Elem()
+			if err := p.decodeQuery(value, i, sf); err != nil {
 				return err
 			}
-			fieldee.Set(reflect.ValueOf(newP).Elem())
+			fieldee.Set(i)
 			return nil
 		}
 	}
@@ -232,52 +661,306 @@ func set(field reflect.Value, sf reflect.StructField, value string, funcMap map[
 	return newNoParserError(sf)
 }
 
+// typeParser returns the parser registered for exactly typee. Custom parsers
+// take precedence over everything else, so users can override how a
+// TextUnmarshaler or a default type is parsed.
+func (p *parser) typeParser(typee reflect.Type) (ParserFunc, bool) {
+	if parserFunc, ok := p.funcMap[typee]; ok {
+		return parserFunc, true
+	}
+	if parserFunc, ok := registeredParser(typee); ok {
+		return parserFunc, true
+	}
+	if parserFunc, ok := defaultTypeParsers[typee]; ok {
+		return p.files.track(typee, parserFunc), true
+	}
+	if typee == timeType {
+		return p.parseTime, true
+	}
+	if parserFunc, ok := enumParser(typee); ok {
+		return parserFunc, true
+	}
+	if parserFunc, ok := unitsParser(typee); ok {
+		return parserFunc, true
+	}
+	return flagsParser(typee)
+}
+
+// builtInParser returns the parser for values of kind k.
+func (p *parser) builtInParser(k reflect.Kind) (ParserFunc, bool) {
+	if k == reflect.Bool && p.opts.looseBools {
+		return parseLooseBool, true
+	}
+	parserFunc, ok := defaultBuiltInParsers[k]
+	return parserFunc, ok
+}
+
+// parseLooseBool parses the values accepted by strconv.ParseBool as well as
+// yes/no, y/n and on/off in any case.
+func parseLooseBool(v string) (interface{}, error) {
+	switch strings.ToLower(v) {
+	case "yes", "y", "on":
+		return true, nil
+	case "no", "n", "off":
+		return false, nil
+	}
+	b, err := strconv.ParseBool(strings.ToLower(v))
+	if err != nil {
+		return nil, fmt.Errorf("invalid boolean %q", v)
+	}
+	return b, nil
+}
+
+func setParsed(field reflect.Value, sf reflect.StructField, value string, parserFunc ParserFunc) error {
+	val, err := parserFunc(value)
+	if err != nil {
+		return newParseError(sf, err)
+	}
+
+	v, err := convertParsed(val, field.Type())
+	if err != nil {
+		return newParseError(sf, err)
+	}
+	field.Set(v)
+	return nil
+}
+
+// convertParsed converts the result of a ParserFunc to typee. Parsers may
+// return either a value or a pointer to a value of the type they are
+// registered for.
+func convertParsed(r interface{}, typee reflect.Type) (reflect.Value, error) {
+	v := reflect.ValueOf(r)
+	if !v.IsValid() {
+		return v, fmt.Errorf("parser returned nil, expected %s", typee)
+	}
+	if v.Type() == reflect.PtrTo(typee) {
+		if v.IsNil() {
+			return v, fmt.Errorf("parser returned nil, expected %s", typee)
+		}
+		v = v.Elem()
+	}
+	if v.Type().AssignableTo(typee) {
+		return v.Convert(typee), nil
+	}
+	if kindClass(v.Kind()) == 0 || kindClass(v.Kind()) != kindClass(typee.Kind()) {
+		return v, fmt.Errorf("parser returned %s, expected %s", v.Type(), typee)
+	}
+	return v.Convert(typee), nil
+}
+
+// kindClass groups the kinds which convertParsed converts between: integers,
+// floats, complex numbers, strings and bools, such as an int64 to a
+// time.Duration or a string to a named string type. It is 0 for other kinds,
+// whose results are never converted, and reflect converting an integer to a
+// string as a rune is ruled out by the classes differing.
+func kindClass(k reflect.Kind) int {
+	switch {
+	case isIntKind(k) || isUintKind(k):
+		return 1
+	case k == reflect.Float32 || k == reflect.Float64:
+		return 2
+	case k == reflect.Complex64 || k == reflect.Complex128:
+		return 3
+	case k == reflect.String:
+		return 4
+	case k == reflect.Bool:
+		return 5
+	}
+	return 0
+}
+
 func isJSONObj(s []byte) bool {
 	var js map[string]interface{}
 	return json.Unmarshal(s, &js) == nil
 }
 
-func handleSlice(field reflect.Value, value string, sf reflect.StructField, funcMap map[reflect.Type]ParserFunc) error {
+func isJSONArray(s []byte) bool {
+	var js []json.RawMessage
+	return json.Unmarshal(s, &js) == nil
+}
+
+func (p *parser) handleSlice(field reflect.Value, value string, sf reflect.StructField) error {
+	// maps can't be split out of a list, so slices of maps are only parsed
+	// from JSON arrays of objects
+	if elem := sf.Type.Elem(); elem.Kind() == reflect.Map || elem.Kind() == reflect.Ptr && elem.Elem().Kind() == reflect.Map {
+		valBytes := []byte(value)
+		if !json.Valid(valBytes) || !isJSONArray(valBytes) {
+			return newNoParserError(sf)
+		}
+		s := reflect.New(sf.Type).Elem()
+		if err := p.decodeJSON(valBytes, s); err != nil {
+			return newParseError(sf, err)
+		}
+		field.Set(s)
+		return nil
+	}
+
+	if isAddressList(sf) {
+		return setAddressList(field, value, sf)
+	}
+
 	var separator = sf.Tag.Get("envSeparator")
 	if separator == "" {
 		separator = ","
 	}
-	var parts = strings.Split(value, separator)
-
-	var typee = sf.Type.Elem()
-	if typee.Kind() == reflect.Ptr {
-		typee = typee.Elem()
+	parts, fixedWidth, err := splitWidth(value, sf)
+	if err != nil {
+		return err
 	}
+	if !fixedWidth {
+		if split, ok := sliceParsers[sf.Tag.Get("envParser")]; ok {
+			if parts, err = split(value, separator); err != nil {
+				return newParseError(sf, err)
+			}
+		} else {
+			parts = strings.Split(value, separator)
+		}
+	}
+	// elements are trimmed first, so those holding only whitespace are empty
+	if strings.ToLower(sf.Tag.Get("envTrim")) == "true" {
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+	}
+	if strings.ToLower(sf.Tag.Get("envOmitEmpty")) == "true" {
+		parts = withoutEmpty(parts)
+	}
+	return p.setSlice(field, parts, sf)
+}
 
-	if _, ok := reflect.New(typee).Interface().(encoding.TextUnmarshaler); ok {
-		return parseTextUnmarshalers(field, parts, sf)
+// withoutEmpty returns parts without the empty parts, such as those left by
+// a trailing separator.
+func withoutEmpty(parts []string) []string {
+	nonEmpty := parts[:0]
+	for _, part := range parts {
+		if part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
 	}
+	return nonEmpty
+}
 
-	parserFunc, ok := funcMap[typee]
+// setSlice parses each part as an element of the slice field.
+func (p *parser) setSlice(field reflect.Value, parts []string, sf reflect.StructField) error {
+	elemParser, ok := p.elemParser(sf.Type.Elem())
 	if !ok {
-		parserFunc, ok = defaultBuiltInParsers[typee.Kind()]
-		if !ok {
-			return newNoParserError(sf)
-		}
+		return newNoParserError(sf)
 	}
 
-	var result = reflect.MakeSlice(sf.Type, 0, len(parts))
-	for _, part := range parts {
-		r, err := parserFunc(part)
+	// the elements are set in place, as reflect.Append copies the slice
+	// header and checks the capacity for every element of long lists
+	var result = reflect.MakeSlice(sf.Type, len(parts), len(parts))
+	for i, part := range parts {
+		v, err := elemParser(part)
 		if err != nil {
 			return newParseError(sf, err)
 		}
-		var v = reflect.ValueOf(r).Convert(typee)
-		if sf.Type.Elem().Kind() == reflect.Ptr {
-			v = reflect.New(typee)
-			v.Elem().Set(reflect.ValueOf(r).Convert(typee))
+		result.Index(i).Set(v)
+	}
+	field.Set(result)
+	return nil
+}
+
+func (p *parser) handleMap(field reflect.Value, value string, sf reflect.StructField) error {
+	separator, _ := mapSeparators(sf)
+	return p.setMap(field, strings.Split(value, separator), sf)
+}
+
+// setMap sets field to a map of the key value pairs.
+func (p *parser) setMap(field reflect.Value, pairs []string, sf reflect.StructField) error {
+	_, kvSeparator := mapSeparators(sf)
+
+	keyParser, ok := p.elemParser(sf.Type.Key())
+	if !ok {
+		return newNoParserError(sf)
+	}
+	valueParser, ok := p.elemParser(sf.Type.Elem())
+	if !ok {
+		return newNoParserError(sf)
+	}
+
+	var result = reflect.MakeMapWithSize(sf.Type, len(pairs))
+	if err := seedMap(result, sf, keyParser, valueParser); err != nil {
+		return err
+	}
+	for _, pair := range pairs {
+		k, v, ok := strings.Cut(pair, kvSeparator)
+		if !ok {
+			return newParseError(sf, fmt.Errorf("invalid map item %q, expected key%svalue", pair, kvSeparator))
+		}
+		key, err := keyParser(k)
+		if err != nil {
+			return newParseError(sf, fmt.Errorf("invalid map key %q: %v", k, err))
+		}
+		val, err := valueParser(v)
+		if err != nil {
+			return newParseError(sf, err)
 		}
-		result = reflect.Append(result, v)
+		result.SetMapIndex(key, val)
 	}
 	field.Set(result)
 	return nil
 }
 
+// elemParser returns a function which parses a single value of typee, such as
+// an element of a slice or the key or value of a map. Type parsers are used
+// first, then `encoding.TextUnmarshaler` and finally the built-in parsers.
+func (p *parser) elemParser(typee reflect.Type) (func(string) (reflect.Value, error), bool) {
+	if parserFunc, ok := p.typeParser(typee); ok && typee.Kind() == reflect.Ptr {
+		return func(v string) (reflect.Value, error) {
+			r, err := parserFunc(v)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			return convertParsed(r, typee)
+		}, true
+	}
+	if typee.Kind() == reflect.Ptr {
+		parse, ok := p.elemParser(typee.Elem())
+		if !ok {
+			return nil, false
+		}
+		return func(v string) (reflect.Value, error) {
+			val, err := parse(v)
+			if err != nil {
+				return val, err
+			}
+			ptr := reflect.New(typee.Elem())
+			ptr.Elem().Set(val)
+			return ptr, nil
+		}, true
+	}
+
+	parserFunc, ok := p.typeParser(typee)
+	if !ok {
+		if reflect.PtrTo(typee).Implements(textUnmarshalerType) {
+			return func(v string) (reflect.Value, error) {
+				ptr := reflect.New(typee)
+				err := ptr.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(v))
+				return ptr.Elem(), err
+			}, true
+		}
+		if reflect.PtrTo(typee).Implements(confParserType) {
+			return func(v string) (reflect.Value, error) {
+				ptr := reflect.New(typee)
+				err := ptr.Interface().(confParser).ParseConf(v)
+				return ptr.Elem(), err
+			}, true
+		}
+		parserFunc, ok = p.builtInParser(typee.Kind())
+		if !ok {
+			return nil, false
+		}
+	}
+	return func(v string) (reflect.Value, error) {
+		r, err := parserFunc(v)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return convertParsed(r, typee)
+	}, true
+}
+
 func asTextUnmarshaler(field reflect.Value) encoding.TextUnmarshaler {
 	if reflect.Ptr == field.Kind() {
 		if field.IsNil() {
@@ -294,30 +977,27 @@ func asTextUnmarshaler(field reflect.Value) encoding.TextUnmarshaler {
 	return tm
 }
 
-func parseTextUnmarshalers(field reflect.Value, data []string, sf reflect.StructField) error {
-	s := len(data)
-	elemType := field.Type().Elem()
-	slice := reflect.MakeSlice(reflect.SliceOf(elemType), s, s)
-	for i, v := range data {
-		sv := slice.Index(i)
-		kind := sv.Kind()
-		if kind == reflect.Ptr {
-			sv = reflect.New(elemType.Elem())
-		} else {
-			sv = sv.Addr()
-		}
-		tm := sv.Interface().(encoding.TextUnmarshaler)
-		if err := tm.UnmarshalText([]byte(v)); err != nil {
-			return newParseError(sf, err)
-		}
-		if kind == reflect.Ptr {
-			slice.Index(i).Set(sv)
+// confParser is implemented by types with a `ParseConf(string) error` method,
+// which parse themselves from a config value. It is checked after
+// encoding.TextUnmarshaler.
+type confParser interface {
+	ParseConf(value string) error
+}
+
+func asConfParser(field reflect.Value) confParser {
+	if reflect.Ptr == field.Kind() {
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
 		}
+	} else if field.CanAddr() {
+		field = field.Addr()
 	}
 
-	field.Set(slice)
-
-	return nil
+	cp, ok := field.Interface().(confParser)
+	if !ok {
+		return nil
+	}
+	return cp
 }
 
 func newParseError(sf reflect.StructField, err error) error {
@@ -331,14 +1011,87 @@ func newParseError(sf reflect.StructField, err error) error {
 }
 
 type parseError struct {
-	sf  reflect.StructField
-	err error
+	sf     reflect.StructField
+	err    error
+	prefix string
+	// redacted leaves out err, which may quote the value, from the message.
+	redacted bool
 }
 
 func (e parseError) Error() string {
-	return fmt.Sprintf(`env: parse error on field "%s" of type "%s": %v`, e.sf.Name, e.sf.Type, e.err)
+	if e.redacted {
+		return fmt.Sprintf(`%s: parse error on field "%s" of type "%s"`, errorPrefix(e.prefix), e.sf.Name, e.sf.Type)
+	}
+	return fmt.Sprintf(`%s: parse error on field "%s" of type "%s": %v`, errorPrefix(e.prefix), e.sf.Name, e.sf.Type, e.err)
+}
+
+// withoutValues redacts the parse errors, whose cause may quote the value
+// which failed to parse, when WithNeverEchoValues is set.
+func withoutValues(err error, opts options) error {
+	if e, ok := err.(parseError); ok && opts.neverEcho {
+		e.redacted = true
+		return e
+	}
+	return err
+}
+
+// withoutProviderValues replaces an error from a provider outside this
+// package, which may quote what it read, with one which only names the field.
+func withoutProviderValues(sf reflect.StructField, err error) error {
+	switch err.(type) {
+	case parseError, prefixedError, contextError:
+		return err
+	}
+	return newError(`provider error on field "%s"`, sf.Name)
 }
 
 func newNoParserError(sf reflect.StructField) error {
-	return fmt.Errorf(`env: no parser found for field "%s" of type "%s"`, sf.Name, sf.Type)
+	return newError(`no parser found for field "%s" of type "%s"`, sf.Name, sf.Type)
+}
+
+// newError returns an error which is reported with the prefix set by
+// WithErrorPrefix, "env" by default.
+func newError(format string, a ...interface{}) error {
+	return prefixedError{msg: fmt.Sprintf(format, a...)}
+}
+
+type prefixedError struct {
+	msg    string
+	prefix string
+	// missing is the key of a required variable which is not set, which
+	// WithAllMissingRequired collects rather than failing on.
+	missing string
+}
+
+func (e prefixedError) Error() string {
+	return errorPrefix(e.prefix) + ": " + e.msg
+}
+
+// withErrorPrefix replaces the prefix of the errors created by this package.
+func withErrorPrefix(err error, prefix string) error {
+	switch e := err.(type) {
+	case parseError:
+		e.prefix = prefix
+		return e
+	case prefixedError:
+		e.prefix = prefix
+		return e
+	case *MissingRequiredError:
+		e.prefix = prefix
+		return e
+	case validationError:
+		e.prefix = prefix
+		return e
+	case contextError:
+		e.prefix = prefix
+		return e
+	}
+	return err
+}
+
+func errorPrefix(prefix string) string {
+	if prefix == "" {
+		return "env"
+	}
+	return prefix
 }
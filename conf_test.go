@@ -4,9 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"github.com/steinfletcher/conf"
+	"net"
 	"net/http"
+	"net/netip"
 	"net/url"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strconv"
 	"strings"
@@ -31,6 +34,36 @@ func (d *unmarshaler) UnmarshalText(data []byte) (err error) {
 	return err
 }
 
+type endpoint struct {
+	Host string
+	Port int
+}
+
+// ParseConf parses an endpoint written as host:port
+func (e *endpoint) ParseConf(value string) error {
+	host, port, err := net.SplitHostPort(value)
+	if err != nil {
+		return err
+	}
+	e.Host = host
+	e.Port, err = strconv.Atoi(port)
+	return err
+}
+
+type confParsingUnmarshaler struct {
+	Source string
+}
+
+func (c *confParsingUnmarshaler) UnmarshalText(data []byte) error {
+	c.Source = "text"
+	return nil
+}
+
+func (c *confParsingUnmarshaler) ParseConf(value string) error {
+	c.Source = "conf"
+	return nil
+}
+
 // nolint: maligned
 type Config struct {
 	String     string    `env:"STRING"`
@@ -504,6 +537,41 @@ func TestInvalidBool(t *testing.T) {
 	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), "env: parse error on field \"Bool\" of type \"bool\": strconv.ParseBool: parsing \"should-be-a-bool\": invalid syntax")
 }
 
+func TestLooseBools(t *testing.T) {
+	type config struct {
+		Enabled  bool            `env:"ENABLED"`
+		Flags    []bool          `env:"FLAGS"`
+		FlagPtrs []*bool         `env:"FLAGS"`
+		Features map[string]bool `env:"FEATURES"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("ENABLED", "Yes")
+	os.Setenv("FLAGS", "on,off,yes,no,Y,n,true,FALSE,1")
+	os.Setenv("FEATURES", "search=on,beta=off")
+
+	var cfg config
+	assert.NoError(t, conf.ParseWithOptions(&cfg, []conf.Option{conf.WithLooseBools()}, conf.EnvProvider))
+	assert.True(t, cfg.Enabled)
+	assert.Equal(t, []bool{true, false, true, false, true, false, true, false, true}, cfg.Flags)
+	assert.False(t, *cfg.FlagPtrs[1])
+	assert.Equal(t, map[string]bool{"search": true, "beta": false}, cfg.Features)
+
+	assert.Error(t, conf.Parse(&config{}, conf.EnvProvider))
+}
+
+func TestInvalidLooseBools(t *testing.T) {
+	type config struct {
+		Flags []bool `env:"FLAGS"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("FLAGS", "on,maybe")
+
+	var cfg config
+	assert.EqualError(t, conf.ParseWithOptions(&cfg, []conf.Option{conf.WithLooseBools()}, conf.EnvProvider), "env: parse error on field \"Flags\" of type \"[]bool\": invalid boolean \"maybe\"")
+}
+
 func TestInvalidInt(t *testing.T) {
 	os.Setenv("INT", "should-be-an-int")
 	defer os.Clearenv()
@@ -520,6 +588,64 @@ func TestInvalidUint(t *testing.T) {
 	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), "env: parse error on field \"Uint\" of type \"uint\": strconv.ParseUint: parsing \"-44\": invalid syntax")
 }
 
+func TestParsesWordSizedInts(t *testing.T) {
+	if strconv.IntSize < 64 {
+		t.Skip("int is not 64 bits")
+	}
+	type config struct {
+		Int   int    `env:"INT"`
+		Uint  uint   `env:"UINT"`
+		Ints  []int  `env:"INTS"`
+		Uints []uint `env:"UINTS"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("INT", "3000000000")
+	os.Setenv("UINT", "5000000000")
+	os.Setenv("INTS", "-3000000000,9223372036854775807")
+	os.Setenv("UINTS", "18446744073709551615")
+
+	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, 3000000000, cfg.Int)
+	assert.Equal(t, uint(5000000000), cfg.Uint)
+	assert.Equal(t, []int{-3000000000, 9223372036854775807}, cfg.Ints)
+	assert.Equal(t, []uint{18446744073709551615}, cfg.Uints)
+}
+
+func TestIntOverflow(t *testing.T) {
+	tests := []struct {
+		key   string
+		value string
+		err   string
+	}{
+		{"INT", "9223372036854775808", "env: parse error on field \"Int\" of type \"int\": strconv.ParseInt: parsing \"9223372036854775808\": value out of range"},
+		{"UINT", "18446744073709551616", "env: parse error on field \"Uint\" of type \"uint\": strconv.ParseUint: parsing \"18446744073709551616\": value out of range"},
+		{"INT8", "128", "env: parse error on field \"Int8\" of type \"int8\": strconv.ParseInt: parsing \"128\": value out of range"},
+		{"INT32", "2147483648", "env: parse error on field \"Int32\" of type \"int32\": strconv.ParseInt: parsing \"2147483648\": value out of range"},
+		{"UINT16", "65536", "env: parse error on field \"Uint16\" of type \"uint16\": strconv.ParseUint: parsing \"65536\": value out of range"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if strconv.IntSize < 64 && (tt.key == "INT" || tt.key == "UINT") {
+				t.Skip("int is not 64 bits")
+			}
+			type config struct {
+				Int    int    `env:"INT"`
+				Uint   uint   `env:"UINT"`
+				Int8   int8   `env:"INT8"`
+				Int32  int32  `env:"INT32"`
+				Uint16 uint16 `env:"UINT16"`
+			}
+			defer os.Clearenv()
+			os.Setenv(tt.key, tt.value)
+
+			cfg := config{}
+			assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), tt.err)
+		})
+	}
+}
+
 func TestInvalidFloat32(t *testing.T) {
 	os.Setenv("FLOAT32", "AAA")
 	defer os.Clearenv()
@@ -607,7 +733,7 @@ func TestInvalidDuration(t *testing.T) {
 	defer os.Clearenv()
 
 	cfg := Config{}
-	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), "env: parse error on field \"Duration\" of type \"time.Duration\": unable to parser duration: time: invalid duration should-be-a-valid-duration")
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), "env: parse error on field \"Duration\" of type \"time.Duration\": unable to parser duration: time: invalid duration \"should-be-a-valid-duration\"")
 }
 
 func TestInvalidDurations(t *testing.T) {
@@ -615,7 +741,7 @@ func TestInvalidDurations(t *testing.T) {
 	defer os.Clearenv()
 
 	cfg := Config{}
-	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), "env: parse error on field \"Durations\" of type \"[]time.Duration\": unable to parser duration: time: invalid duration contains-an-invalid-duration")
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), "env: parse error on field \"Durations\" of type \"[]time.Duration\": unable to parser duration: time: invalid duration \"contains-an-invalid-duration\"")
 }
 
 func TestParseStructWithoutEnvTag(t *testing.T) {
@@ -645,6 +771,94 @@ func TestUnsupportedSliceType(t *testing.T) {
 	assert.EqualError(t, conf.Parse(cfg, conf.EnvProvider), "env: no parser found for field \"WontWork\" of type \"[]map[int]int\"")
 }
 
+func TestParsesMaps(t *testing.T) {
+	type config struct {
+		Timeouts    map[string]time.Duration  `env:"TIMEOUTS"`
+		TimeoutPtrs map[string]*time.Duration `env:"TIMEOUTS"`
+		Weights     map[string]int            `env:"WEIGHTS" envSeparator:";" envKeyValSeparator:":"`
+		Flags       map[int]bool              `env:"FLAGS"`
+		Missing     map[string]string         `env:"MISSING"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("TIMEOUTS", "read=5s,write=10s")
+	os.Setenv("WEIGHTS", "a:1;b:2")
+	os.Setenv("FLAGS", "1=true,2=false")
+
+	var cfg config
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, map[string]time.Duration{"read": 5 * time.Second, "write": 10 * time.Second}, cfg.Timeouts)
+	assert.Equal(t, 10*time.Second, *cfg.TimeoutPtrs["write"])
+	assert.Equal(t, map[string]int{"a": 1, "b": 2}, cfg.Weights)
+	assert.Equal(t, map[int]bool{1: true, 2: false}, cfg.Flags)
+	assert.Nil(t, cfg.Missing)
+}
+
+func TestInvalidMaps(t *testing.T) {
+	defer os.Clearenv()
+
+	t.Run("item", func(t *testing.T) {
+		type config struct {
+			Timeouts map[string]time.Duration `env:"TIMEOUTS"`
+		}
+		os.Setenv("TIMEOUTS", "read=5s,write")
+		var cfg config
+		assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), "env: parse error on field \"Timeouts\" of type \"map[string]time.Duration\": invalid map item \"write\", expected key=value")
+	})
+
+	t.Run("value", func(t *testing.T) {
+		type config struct {
+			Timeouts map[string]time.Duration `env:"TIMEOUTS"`
+		}
+		os.Setenv("TIMEOUTS", "read=5s,write=soon")
+		var cfg config
+		assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), "env: parse error on field \"Timeouts\" of type \"map[string]time.Duration\": unable to parser duration: time: invalid duration \"soon\"")
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		type config struct {
+			Clients map[string]http.Client `env:"CLIENTS"`
+		}
+		os.Setenv("CLIENTS", "a=b")
+		var cfg config
+		assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), "env: no parser found for field \"Clients\" of type \"map[string]http.Client\"")
+	})
+
+	t.Run("key", func(t *testing.T) {
+		type config struct {
+			Days map[time.Weekday]bool `env:"DAYS"`
+		}
+		os.Setenv("DAYS", "monday=true,someday=false")
+		var cfg config
+		assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: parse error on field "Days" of type "map[time.Weekday]bool": invalid map key "someday": unknown weekday "someday"`)
+	})
+
+	t.Run("address key", func(t *testing.T) {
+		type config struct {
+			Weights map[netip.Addr]int `env:"WEIGHTS"`
+		}
+		os.Setenv("WEIGHTS", "10.0.0.1=1,10.0.0=2")
+		var cfg config
+		assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: parse error on field "Weights" of type "map[netip.Addr]int": invalid map key "10.0.0": unable to parse IP address: ParseAddr("10.0.0"): IPv4 address too short`)
+	})
+}
+
+func TestTypedMapKeys(t *testing.T) {
+	type config struct {
+		Days    map[time.Weekday]bool `env:"DAYS"`
+		Weights map[netip.Addr]int    `env:"WEIGHTS"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("DAYS", "Monday=true,sat=false,0=true")
+	os.Setenv("WEIGHTS", "10.0.0.1=1,::1=2")
+
+	var cfg config
+	require.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, map[time.Weekday]bool{time.Monday: true, time.Saturday: false, time.Sunday: true}, cfg.Days)
+	assert.Equal(t, map[netip.Addr]int{netip.MustParseAddr("10.0.0.1"): 1, netip.MustParseAddr("::1"): 2}, cfg.Weights)
+}
+
 func TestBadSeparator(t *testing.T) {
 	type config struct {
 		WontWork []int `env:"WONTWORK" envSeparator:":"`
@@ -679,178 +893,1211 @@ func TestErrorRequiredNotSet(t *testing.T) {
 	assert.EqualError(t, conf.Parse(cfg, conf.EnvProvider), "env: required environment variable \"IS_REQUIRED\" is not set")
 }
 
-func TestParseExpandOption(t *testing.T) {
+func TestDeprecatedKey(t *testing.T) {
 	type config struct {
-		Host        string `env:"HOST" envDefault:"localhost"`
-		Port        int    `env:"PORT" envDefault:"3000" envExpand:"True"`
-		SecretKey   string `env:"SECRET_KEY" envExpand:"True"`
-		ExpandKey   string `env:"EXPAND_KEY"`
-		CompoundKey string `env:"HOST_PORT" envDefault:"${HOST}:${PORT}" envExpand:"True"`
-		Default     string `env:"DEFAULT" envDefault:"def1"  envExpand:"True"`
+		Host string `env:"HOST,required" envDeprecated:"OLD_HOST,OLDER_HOST"`
+		Port int    `env:"PORT" envDeprecated:"OLD_PORT" envDefault:"3000"`
 	}
 	defer os.Clearenv()
 
-	os.Setenv("HOST", "localhost")
-	os.Setenv("PORT", "3000")
-	os.Setenv("EXPAND_KEY", "qwerty12345")
-	os.Setenv("SECRET_KEY", "${EXPAND_KEY}")
+	os.Setenv("OLDER_HOST", "localhost")
+	os.Setenv("OLD_PORT", "8080")
 
+	var warnings []string
 	cfg := config{}
-	err := conf.Parse(&cfg, conf.EnvProvider)
+	err := conf.ParseWithOptions(&cfg, []conf.Option{
+		conf.WithWarningHandler(func(w string) {
+			warnings = append(warnings, w)
+		}),
+	}, conf.EnvProvider)
 
 	assert.NoError(t, err)
 	assert.Equal(t, "localhost", cfg.Host)
-	assert.Equal(t, 3000, cfg.Port)
-	assert.Equal(t, "qwerty12345", cfg.SecretKey)
-	assert.Equal(t, "qwerty12345", cfg.ExpandKey)
-	assert.Equal(t, "localhost:3000", cfg.CompoundKey)
-	assert.Equal(t, "def1", cfg.Default)
+	assert.Equal(t, 8080, cfg.Port)
+	assert.Equal(t, []string{
+		"env: environment variable \"OLDER_HOST\" is deprecated, use \"HOST\" instead",
+		"env: environment variable \"OLD_PORT\" is deprecated, use \"PORT\" instead",
+	}, warnings)
 }
 
-func TestCustomParser(t *testing.T) {
-	type foo struct {
-		name string
-	}
-
-	type bar struct {
-		Name string `env:"OTHER"`
-		Foo  *foo   `env:"BLAH"`
-	}
-
+func TestDeprecatedKeyIgnoredWhenKeySet(t *testing.T) {
 	type config struct {
-		Var   foo  `env:"VAR"`
-		Foo   *foo `env:"BLAH"`
-		Other *bar
+		Host string `env:"HOST" envDeprecated:"OLD_HOST"`
 	}
+	defer os.Clearenv()
 
-	os.Setenv("VAR", "test")
-	defer os.Unsetenv("VAR")
-	os.Setenv("OTHER", "test2")
-	defer os.Unsetenv("OTHER")
-	os.Setenv("BLAH", "test3")
-	defer os.Unsetenv("BLAH")
+	os.Setenv("HOST", "new.host")
+	os.Setenv("OLD_HOST", "old.host")
 
-	cfg := &config{
-		Other: &bar{},
-	}
-	err := conf.ParseWithFuncs(cfg, map[reflect.Type]conf.ParserFunc{
-		reflect.TypeOf(foo{}): func(v string) (interface{}, error) {
-			return foo{name: v}, nil
-		},
+	var warnings []string
+	cfg := config{}
+	err := conf.ParseWithOptions(&cfg, []conf.Option{
+		conf.WithWarningHandler(func(w string) {
+			warnings = append(warnings, w)
+		}),
 	}, conf.EnvProvider)
 
 	assert.NoError(t, err)
-	assert.Equal(t, cfg.Var.name, "test")
-	assert.Equal(t, cfg.Foo.name, "test3")
-	assert.Equal(t, cfg.Other.Name, "test2")
-	assert.Equal(t, cfg.Other.Foo.name, "test3")
+	assert.Equal(t, "new.host", cfg.Host)
+	assert.Empty(t, warnings)
 }
 
-func TestParseWithFuncsNoPtr(t *testing.T) {
-	type foo struct{}
-	err := conf.ParseWithFuncs(foo{}, nil, nil)
-	assert.EqualError(t, err, "env: expected a pointer to a Struct")
-}
+func TestNumberedSlice(t *testing.T) {
+	type config struct {
+		Peers []string `env:"PEER" envNumbered:"true"`
+		Ports []int    `env:"PORT" envNumbered:"true"`
+	}
+	defer os.Clearenv()
 
-func TestParseWithFuncsInvalidType(t *testing.T) {
-	var c int
-	err := conf.ParseWithFuncs(&c, nil, nil)
-	assert.EqualError(t, err, "env: expected a pointer to a Struct")
+	os.Setenv("PEER_1", "a,b")
+	os.Setenv("PEER_2", "c")
+	os.Setenv("PEER_3", "d")
+	os.Setenv("PORT_1", "80")
+	os.Setenv("PORT_2", "443")
+
+	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, []string{"a,b", "c", "d"}, cfg.Peers)
+	assert.Equal(t, []int{80, 443}, cfg.Ports)
 }
 
-func TestCustomParserError(t *testing.T) {
-	type foo struct {
-		name string
+func TestNumberedSliceStopsAtGap(t *testing.T) {
+	type config struct {
+		Peers []string `env:"PEER" envNumbered:"true"`
 	}
+	defer os.Clearenv()
 
-	customParserFunc := func(v string) (interface{}, error) {
-		return nil, errors.New("something broke")
-	}
+	os.Setenv("PEER_1", "a")
+	os.Setenv("PEER_3", "c")
 
-	t.Run("single", func(t *testing.T) {
-		type config struct {
-			Var foo `env:"VAR"`
-		}
+	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, []string{"a"}, cfg.Peers)
+}
 
-		os.Setenv("VAR", "single")
-		cfg := &config{}
-		err := conf.ParseWithFuncs(cfg, map[reflect.Type]conf.ParserFunc{
-			reflect.TypeOf(foo{}): customParserFunc,
-		}, conf.EnvProvider)
+func TestNumberedSliceDefault(t *testing.T) {
+	type config struct {
+		Peers []string `env:"PEER" envNumbered:"true" envDefault:"x,y"`
+	}
+	defer os.Clearenv()
 
-		assert.Empty(t, cfg.Var.name)
-		assert.EqualError(t, err, "env: parse error on field \"Var\" of type \"conf_test.foo\": something broke")
-	})
+	os.Setenv("PEER_2", "b")
 
-	t.Run("slice", func(t *testing.T) {
-		type config struct {
-			Var []foo `env:"VAR2"`
-		}
-		os.Setenv("VAR2", "slice,slace")
+	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, []string{"x", "y"}, cfg.Peers)
+}
 
-		cfg := &config{}
-		err := conf.ParseWithFuncs(cfg, map[reflect.Type]conf.ParserFunc{
-			reflect.TypeOf(foo{}): customParserFunc,
-		}, conf.EnvProvider)
+func TestNumberedSliceRequired(t *testing.T) {
+	type config struct {
+		Peers []string `env:"PEER,required" envNumbered:"true"`
+	}
+	defer os.Clearenv()
 
-		assert.Empty(t, cfg.Var)
-		assert.EqualError(t, err, "env: parse error on field \"Var\" of type \"[]conf_test.foo\": something broke")
-	})
-}
+	os.Setenv("PEER", "a")
 
-func TestCustomParserBasicType(t *testing.T) {
-	type ConstT int32
+	cfg := config{}
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), "env: required environment variable \"PEER\" is not set")
+}
 
+func TestNumberedSliceMerge(t *testing.T) {
 	type config struct {
-		Const ConstT `env:"CONST_"`
+		Peers []string `env:"PEER,required" envNumbered:"merge"`
+		Ports []int    `env:"PORT" envNumbered:"merge" envSeparator:";"`
 	}
+	defer os.Clearenv()
 
-	exp := ConstT(123)
-	os.Setenv("CONST_", fmt.Sprintf("%d", exp))
-
-	customParserFunc := func(v string) (interface{}, error) {
-		i, err := strconv.Atoi(v)
-		if err != nil {
-			return nil, err
-		}
-		r := ConstT(i)
-		return r, nil
+	for name, tc := range map[string]struct {
+		env   map[string]string
+		peers []string
+		ports []int
+	}{
+		"joined only": {
+			env:   map[string]string{"PEER": "a,b", "PORT": "80;443"},
+			peers: []string{"a", "b"},
+			ports: []int{80, 443},
+		},
+		"numbered only": {
+			env:   map[string]string{"PEER_1": "a", "PEER_2": "b", "PORT_1": "80"},
+			peers: []string{"a", "b"},
+			ports: []int{80},
+		},
+		"both": {
+			env:   map[string]string{"PEER": "a,b", "PEER_1": "c", "PEER_2": "d", "PORT": "80", "PORT_1": "443"},
+			peers: []string{"a", "b", "c", "d"},
+			ports: []int{80, 443},
+		},
+		"empty joined": {
+			env:   map[string]string{"PEER": "", "PEER_1": "c"},
+			peers: []string{"c"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			os.Clearenv()
+			for k, v := range tc.env {
+				os.Setenv(k, v)
+			}
+			cfg := config{}
+			require.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+			assert.Equal(t, tc.peers, cfg.Peers)
+			assert.Equal(t, tc.ports, cfg.Ports)
+		})
 	}
+}
 
-	cfg := &config{}
-	err := conf.ParseWithFuncs(cfg, map[reflect.Type]conf.ParserFunc{
-		reflect.TypeOf(ConstT(0)): customParserFunc,
-	}, conf.EnvProvider)
+func TestNumberedSliceMergeRequired(t *testing.T) {
+	type config struct {
+		Peers []string `env:"PEER,required" envNumbered:"merge"`
+	}
+	defer os.Clearenv()
 
-	assert.NoError(t, err)
-	assert.Equal(t, exp, cfg.Const)
+	cfg := config{}
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), "env: required environment variable \"PEER\" is not set")
 }
 
-func TestCustomParserUint64Alias(t *testing.T) {
-	type T uint64
+func TestPresenceFlag(t *testing.T) {
+	type config struct {
+		Debug   bool  `env:"DEBUG" envPresence:"true"`
+		Verbose *bool `env:"VERBOSE" envPresence:"true"`
+		Trace   bool  `env:"TRACE" envPresence:"true"`
+	}
+	defer os.Clearenv()
 
-	var one T = 1
+	os.Setenv("DEBUG", "")
+	os.Setenv("VERBOSE", "false")
+
+	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.True(t, cfg.Debug)
+	require.NotNil(t, cfg.Verbose)
+	assert.True(t, *cfg.Verbose)
+	assert.False(t, cfg.Trace)
+}
 
+func TestPresenceFlagNotABool(t *testing.T) {
 	type config struct {
-		Val T `env:"" envDefault:"1x"`
+		Debug string `env:"DEBUG" envPresence:"true"`
 	}
 
-	parserCalled := false
+	cfg := config{}
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: field "Debug" has envPresence but is not a bool`)
+}
 
-	tParser := func(value string) (interface{}, error) {
-		parserCalled = true
-		trimmed := strings.TrimSuffix(value, "x")
-		i, err := strconv.Atoi(trimmed)
-		if err != nil {
-			return nil, err
-		}
-		return T(i), nil
+func TestPresenceFlagDefault(t *testing.T) {
+	type config struct {
+		Debug bool `env:"DEBUG" envPresence:"true" envDefault:"true"`
 	}
+	defer os.Clearenv()
 
 	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.True(t, cfg.Debug)
+}
 
-	err := conf.ParseWithFuncs(&cfg, map[reflect.Type]conf.ParserFunc{
-		reflect.TypeOf(one): tParser,
+func TestNegate(t *testing.T) {
+	type config struct {
+		Color    bool  `env:"NO_COLOR" envPresence:"true" envNegate:"true" envDefault:"true"`
+		Progress bool  `env:"NO_PROGRESS" envPresence:"true" envNegate:"true" envDefault:"true"`
+		Cache    bool  `env:"DISABLE_CACHE" envNegate:"true" envDefault:"true"`
+		TLS      *bool `env:"INSECURE" envNegate:"true"`
+		Retry    bool  `env:"NO_RETRY" envNegate:"true"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("NO_COLOR", "")
+	os.Setenv("DISABLE_CACHE", "false")
+	os.Setenv("INSECURE", "true")
+	os.Setenv("NO_RETRY", "")
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.False(t, cfg.Color)
+	assert.True(t, cfg.Progress)
+	assert.True(t, cfg.Cache)
+	require.NotNil(t, cfg.TLS)
+	assert.False(t, *cfg.TLS)
+	assert.False(t, cfg.Retry)
+
+	os.Setenv("DISABLE_CACHE", "yes")
+	loose := config{}
+	require.NoError(t, conf.ParseWithOptions(&loose, []conf.Option{conf.WithLooseBools()}, conf.EnvProvider))
+	assert.False(t, loose.Cache)
+}
+
+func TestNegateErrors(t *testing.T) {
+	defer os.Clearenv()
+
+	type notABool struct {
+		Color string `env:"NO_COLOR" envNegate:"true"`
+	}
+	assert.EqualError(t, conf.Parse(&notABool{}, conf.EnvProvider), `env: field "Color" has envNegate but is not a bool`)
+
+	type config struct {
+		Color bool `env:"NO_COLOR" envNegate:"true"`
+	}
+	os.Setenv("NO_COLOR", "always")
+	assert.EqualError(t, conf.Parse(&config{}, conf.EnvProvider), `env: parse error on field "Color" of type "bool": strconv.ParseBool: parsing "always": invalid syntax`)
+}
+
+func TestMapProvider(t *testing.T) {
+	type config struct {
+		Host  string   `env:"HOST,required"`
+		Port  int      `env:"PORT" envDefault:"8080"`
+		Peers []string `env:"PEERS"`
+	}
+
+	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, conf.NewMapProvider(map[string]string{
+		"HOST":  "localhost",
+		"PEERS": "a,b",
+	})))
+	assert.Equal(t, config{Host: "localhost", Port: 8080, Peers: []string{"a", "b"}}, cfg)
+}
+
+func TestWithDefaultsProvider(t *testing.T) {
+	type database struct {
+		Host string `env:"HOST"`
+		User string `env:"USER"`
+	}
+	type config struct {
+		Host     string   `env:"HOST"`
+		Port     int      `env:"PORT" envDefault:"8080"`
+		Timeout  string   `env:"TIMEOUT"`
+		Token    string   `secret:"TOKEN"`
+		Database database `envPrefix:"DB_"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("HOST", "env.local")
+	os.Setenv("TOKEN", "s3cr3t")
+	os.Setenv("DB_USER", "admin")
+
+	defaults := conf.NewMapProvider(map[string]string{
+		"HOST":    "defaults.local",
+		"PORT":    "9090",
+		"TIMEOUT": "5s",
+		"DB_HOST": "db.local",
+		"DB_USER": "nobody",
+	})
+
+	cfg := config{}
+	err := conf.ParseWithOptions(&cfg, []conf.Option{conf.WithDefaultsProvider(defaults)}, conf.EnvProvider, conf.SecretEnvProvider)
+	assert.NoError(t, err)
+	assert.Equal(t, config{
+		Host:     "env.local",
+		Port:     8080,
+		Timeout:  "5s",
+		Token:    "s3cr3t",
+		Database: database{Host: "db.local", User: "admin"},
+	}, cfg)
+}
+
+func TestWithDefaultsProviderRequired(t *testing.T) {
+	type config struct {
+		Host string `env:"HOST,required"`
+	}
+	defer os.Clearenv()
+
+	defaults := conf.NewMapProvider(map[string]string{"HOST": "defaults.local"})
+
+	cfg := config{}
+	err := conf.ParseWithOptions(&cfg, []conf.Option{conf.WithDefaultsProvider(defaults)}, conf.EnvProvider)
+	assert.EqualError(t, err, `env: required environment variable "HOST" is not set`)
+}
+
+func TestEnvProviderWithDefaults(t *testing.T) {
+	type database struct {
+		Host string `env:"HOST"`
+	}
+	type config struct {
+		Host     string   `env:"HOST"`
+		Port     int      `env:"PORT" envDefault:"8080"`
+		Timeout  string   `env:"TIMEOUT"`
+		Region   string   `env:"REGION"`
+		Database database `envPrefix:"DB_"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("HOST", "env.local")
+
+	provider := conf.NewEnvProviderWithDefaults(map[string]string{
+		"HOST":    "defaults.local",
+		"PORT":    "9090",
+		"TIMEOUT": "5s",
+		"DB_HOST": "db.local",
+	})
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, provider))
+	assert.Equal(t, config{
+		Host:     "env.local",
+		Port:     8080,
+		Timeout:  "5s",
+		Database: database{Host: "db.local"},
+	}, cfg)
+
+	cfg = config{}
+	require.NoError(t, conf.ParseWithOptions(&cfg, []conf.Option{conf.WithInCodeDefaults()}, provider))
+	assert.Equal(t, config{Host: "env.local"}, cfg)
+}
+
+func TestEnvProviderWithDefaultsRequired(t *testing.T) {
+	type config struct {
+		Host string `env:"HOST,required"`
+	}
+	defer os.Clearenv()
+
+	cfg := config{}
+	err := conf.Parse(&cfg, conf.NewEnvProviderWithDefaults(map[string]string{"HOST": "defaults.local"}))
+	assert.EqualError(t, err, `env: required environment variable "HOST" is not set`)
+}
+
+func TestUnquote(t *testing.T) {
+	type config struct {
+		Value string `env:"VALUE" envUnquote:"true"`
+		Raw   string `env:"VALUE"`
+	}
+	defer os.Clearenv()
+
+	for name, tc := range map[string]struct {
+		value, want string
+	}{
+		"double quoted":     {`"a b"`, "a b"},
+		"single quoted":     {`'a b'`, "a b"},
+		"unquoted":          {`a b`, "a b"},
+		"leading quote":     {`"a b`, `"a b`},
+		"trailing quote":    {`a b"`, `a b"`},
+		"mismatched":        {`"a b'`, `"a b'`},
+		"single quote":      {`"`, `"`},
+		"empty quotes":      {`""`, ""},
+		"inner quotes":      {`"a "b" c"`, `a "b" c`},
+		"only one stripped": {`""a""`, `"a"`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			os.Setenv("VALUE", tc.value)
+
+			cfg := config{}
+			assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+			assert.Equal(t, tc.want, cfg.Value)
+			assert.Equal(t, tc.value, cfg.Raw)
+		})
+	}
+}
+
+func TestUnquoteBeforeParsing(t *testing.T) {
+	type config struct {
+		Port  int      `env:"PORT" envUnquote:"true"`
+		Peers []string `env:"PEER" envNumbered:"true" envUnquote:"true"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("PORT", `"8080"`)
+	os.Setenv("PEER_1", `'a'`)
+	os.Setenv("PEER_2", `"b"`)
+
+	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, config{Port: 8080, Peers: []string{"a", "b"}}, cfg)
+}
+
+func TestFileOption(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	for name, tc := range map[string]struct {
+		content, trimmed, kept string
+	}{
+		"newline":          {"s3cr3t\n", "s3cr3t", "s3cr3t\n"},
+		"crlf":             {"s3cr3t\r\n", "s3cr3t", "s3cr3t\r\n"},
+		"no newline":       {"s3cr3t", "s3cr3t", "s3cr3t"},
+		"two newlines":     {"s3cr3t\n\n", "s3cr3t\n", "s3cr3t\n\n"},
+		"carriage return":  {"s3cr3t\r", "s3cr3t\r", "s3cr3t\r"},
+		"multi-line value": {"a\nb\n", "a\nb", "a\nb\n"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			type config struct {
+				Trimmed string `env:"SECRET_FILE,file"`
+				Kept    string `env:"SECRET_FILE,file" envKeepNewline:"true"`
+			}
+			defer os.Clearenv()
+			os.Setenv("SECRET_FILE", write(name, tc.content))
+
+			cfg := config{}
+			assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+			assert.Equal(t, tc.trimmed, cfg.Trimmed)
+			assert.Equal(t, tc.kept, cfg.Kept)
+		})
+	}
+}
+
+func TestFileOptionChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("t0k3n\n"), 0o600))
+
+	type config struct {
+		Token string `env:"TOKEN_FILE,file,required"`
+	}
+
+	dotenv, err := conf.NewDotenvProvider(strings.NewReader("TOKEN_FILE=" + path))
+	require.NoError(t, err)
+
+	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, conf.NewChainProvider(conf.EnvProvider, dotenv)))
+	assert.Equal(t, "t0k3n", cfg.Token)
+}
+
+func TestFileOptionMissingFile(t *testing.T) {
+	type config struct {
+		Token string `env:"TOKEN_FILE,file"`
+	}
+	defer os.Clearenv()
+
+	path := filepath.Join(t.TempDir(), "missing")
+	os.Setenv("TOKEN_FILE", path)
+
+	cfg := config{}
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: unable to read file of environment variable "TOKEN_FILE": file not found`)
+}
+
+func TestFileOptionMissingFileNeverEcho(t *testing.T) {
+	type config struct {
+		Token string `env:"TOKEN_FILE,file"`
+	}
+
+	path := filepath.Join(t.TempDir(), "secret-path")
+	for _, provider := range []conf.Provider{
+		conf.NewMapProvider(map[string]string{"TOKEN_FILE": path}),
+		conf.NewChainProvider(conf.NewMapProvider(map[string]string{"TOKEN_FILE": path})),
+	} {
+		cfg := config{}
+		err := conf.ParseWithOptions(&cfg, []conf.Option{conf.WithNeverEchoValues()}, provider)
+		assert.EqualError(t, err, `env: unable to read file of environment variable "TOKEN_FILE": file not found`)
+		assert.NotContains(t, err.Error(), "secret-path")
+	}
+}
+
+func TestFileOptionDirectory(t *testing.T) {
+	type config struct {
+		Token string `env:"TOKEN_FILE,file"`
+	}
+
+	cfg := config{}
+	err := conf.Parse(&cfg, conf.NewMapProvider(map[string]string{"TOKEN_FILE": t.TempDir()}))
+	assert.EqualError(t, err, `env: unable to read file of environment variable "TOKEN_FILE": is a directory`)
+}
+
+func TestSliceOmitEmpty(t *testing.T) {
+	type config struct {
+		Hosts     []string `env:"HOSTS" envOmitEmpty:"true"`
+		AllHosts  []string `env:"HOSTS"`
+		Ports     []int    `env:"PORTS" envOmitEmpty:"true"`
+		Separated []int    `env:"SEPARATED" envSeparator:";" envOmitEmpty:"true"`
+		Ranges    []int    `env:"RANGES" envParser:"intrange" envOmitEmpty:"true"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("HOSTS", ",a,,b,")
+	os.Setenv("PORTS", "80,443,")
+	os.Setenv("SEPARATED", ";1;;2;")
+	os.Setenv("RANGES", "1-3")
+
+	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, []string{"a", "b"}, cfg.Hosts)
+	assert.Equal(t, []string{"", "a", "", "b", ""}, cfg.AllHosts)
+	assert.Equal(t, []int{80, 443}, cfg.Ports)
+	assert.Equal(t, []int{1, 2}, cfg.Separated)
+	assert.Equal(t, []int{1, 2, 3}, cfg.Ranges)
+
+	os.Setenv("HOSTS", ",")
+	cfg = config{}
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, []string{}, cfg.Hosts)
+}
+
+func TestSliceTrim(t *testing.T) {
+	type config struct {
+		Trimmed []string `env:"HOSTS" envTrim:"true"`
+		Cleaned []string `env:"HOSTS" envTrim:"true" envOmitEmpty:"true"`
+		Omitted []string `env:"HOSTS" envOmitEmpty:"true"`
+		Ports   []int    `env:"PORTS" envSeparator:";" envTrim:"true" envOmitEmpty:"true"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("HOSTS", "  a , b , , c  ,")
+	os.Setenv("PORTS", " 80 ; ;443; ")
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, []string{"a", "b", "", "c", ""}, cfg.Trimmed)
+	assert.Equal(t, []string{"a", "b", "c"}, cfg.Cleaned)
+	assert.Equal(t, []string{"  a ", " b ", " ", " c  "}, cfg.Omitted)
+	assert.Equal(t, []int{80, 443}, cfg.Ports)
+}
+
+func TestSliceKeepsEmpty(t *testing.T) {
+	type config struct {
+		Ports []int `env:"PORTS" envOmitEmpty:"false"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("PORTS", "80,443,")
+
+	cfg := config{}
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: parse error on field "Ports" of type "[]int": strconv.ParseInt: parsing "": invalid syntax`)
+}
+
+func TestParseWithWarnings(t *testing.T) {
+	type config struct {
+		Host string `env:"HOST" envDeprecated:"OLD_HOST"`
+		Port int    `env:"PORT,required"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("OLD_HOST", "localhost")
+	os.Setenv("PORT", "8080")
+
+	cfg := config{}
+	warnings, err := conf.ParseWithWarnings(&cfg, conf.EnvProvider)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost", cfg.Host)
+	assert.Equal(t, 8080, cfg.Port)
+	assert.Equal(t, []string{
+		"env: environment variable \"OLD_HOST\" is deprecated, use \"HOST\" instead",
+	}, warnings)
+}
+
+func TestParseWithWarningsNone(t *testing.T) {
+	type config struct {
+		Host string `env:"HOST" envDeprecated:"OLD_HOST"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("HOST", "localhost")
+
+	cfg := config{}
+	warnings, err := conf.ParseWithWarnings(&cfg, conf.EnvProvider)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost", cfg.Host)
+	assert.Empty(t, warnings)
+}
+
+func TestParseWithWarningsReturnedWithError(t *testing.T) {
+	type config struct {
+		Host string `env:"HOST" envDeprecated:"OLD_HOST"`
+		Port int    `env:"PORT,required"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("OLD_HOST", "localhost")
+
+	cfg := config{}
+	warnings, err := conf.ParseWithWarnings(&cfg, conf.EnvProvider)
+
+	assert.EqualError(t, err, "env: required environment variable \"PORT\" is not set")
+	assert.Len(t, warnings, 1)
+}
+
+func TestParseWithDefaultsUsed(t *testing.T) {
+	type config struct {
+		Host    string `env:"HOST" envDefault:"localhost"`
+		Port    int    `env:"PORT" envDefault:"8080"`
+		Region  string `env:"REGION"`
+		Timeout string `env:"TIMEOUT"`
+	}
+
+	cfg := config{}
+	defaultsUsed, err := conf.ParseWithDefaultsUsed(&cfg, conf.NewMapProvider(map[string]string{
+		"PORT":   "9090",
+		"REGION": "eu-west-1",
+	}))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost", cfg.Host)
+	assert.Equal(t, 9090, cfg.Port)
+	assert.Equal(t, map[string]bool{
+		"Host":   true,
+		"Port":   false,
+		"Region": false,
+	}, defaultsUsed)
+}
+
+func TestParseWithDefaultsUsedProviderWins(t *testing.T) {
+	type config struct {
+		Host string `env:"HOST" envDefault:"localhost"`
+	}
+
+	cfg := config{}
+	defaultsUsed, err := conf.ParseWithDefaultsUsed(&cfg,
+		conf.NewMapProvider(map[string]string{}),
+		conf.NewMapProvider(map[string]string{"HOST": "example.com"}),
+	)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com", cfg.Host)
+	assert.Equal(t, map[string]bool{"Host": false}, defaultsUsed)
+}
+
+func TestParseWithDefaultsUsedProviderDefaults(t *testing.T) {
+	type config struct {
+		Host string `env:"HOST"`
+	}
+
+	cfg := config{}
+	defaultsUsed, err := conf.ParseWithDefaultsUsed(&cfg, conf.NewEnvProviderWithDefaults(map[string]string{
+		"HOST": "localhost",
+	}))
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"Host": true}, defaultsUsed)
+}
+
+func TestMissingPolicy(t *testing.T) {
+	type config struct {
+		Host    string `env:"HOST" envMissing:"error"`
+		Port    int    `env:"PORT" envMissing:"default" envDefault:"8080"`
+		Region  string `env:"REGION" envMissing:"warn" envDefault:"eu-west-1"`
+		Zone    string `env:"ZONE" envMissing:"warn"`
+		Timeout string `env:"TIMEOUT" envDefault:"5s"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("HOST", "localhost")
+
+	cfg := config{}
+	warnings, err := conf.ParseWithWarnings(&cfg, conf.EnvProvider)
+	require.NoError(t, err)
+	assert.Equal(t, config{Host: "localhost", Port: 8080, Region: "eu-west-1", Timeout: "5s"}, cfg)
+	assert.Equal(t, []string{
+		`env: environment variable "REGION" is not set`,
+		`env: environment variable "ZONE" is not set`,
+	}, warnings)
+
+	os.Setenv("REGION", "us-east-1")
+	os.Setenv("ZONE", "")
+	warnings, err = conf.ParseWithWarnings(&cfg, conf.EnvProvider)
+	require.NoError(t, err)
+	assert.Equal(t, "us-east-1", cfg.Region)
+	assert.Empty(t, warnings)
+}
+
+func TestMissingPolicyError(t *testing.T) {
+	type config struct {
+		Host string `env:"HOST" envMissing:"error" envDefault:"localhost"`
+	}
+	defer os.Clearenv()
+
+	assert.EqualError(t, conf.Parse(&config{}, conf.EnvProvider), `env: required environment variable "HOST" is not set`)
+
+	os.Setenv("HOST", "")
+	assert.NoError(t, conf.Parse(&config{}, conf.EnvProvider))
+}
+
+func TestMissingPolicyNotEmpty(t *testing.T) {
+	type config struct {
+		Host string `env:"HOST,notEmpty" envMissing:"warn"`
+	}
+	defer os.Clearenv()
+
+	warnings, err := conf.ParseWithWarnings(&config{}, conf.EnvProvider)
+	assert.EqualError(t, err, `env: environment variable "HOST" should not be empty`)
+	assert.Equal(t, []string{`env: environment variable "HOST" is not set`}, warnings)
+}
+
+func TestMissingPolicyInvalid(t *testing.T) {
+	type config struct {
+		Host string `env:"HOST" envMissing:"ignore"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("HOST", "localhost")
+	assert.EqualError(t, conf.Parse(&config{}, conf.EnvProvider), `env: field "Host" has invalid envMissing "ignore", expected "error", "default" or "warn"`)
+}
+
+func TestConfProvider(t *testing.T) {
+	type credentials struct {
+		User     string `secret:"DB_USER"`
+		Password string `secret:"DB_PASSWORD"`
+		Host     string `env:"DB_HOST"`
+	}
+	type config struct {
+		Host        string       `env:"HOST"`
+		Credentials credentials  `confProvider:"secret"`
+		Admin       *credentials `confProvider:"secret" envPrefix:"ADMIN_"`
+		Unset       *credentials `confProvider:"secret"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("HOST", "localhost")
+	os.Setenv("DB_USER", "app")
+	os.Setenv("DB_PASSWORD", "hunter2")
+	os.Setenv("DB_HOST", "db.internal")
+	os.Setenv("ADMIN_DB_USER", "admin")
+
+	for _, providers := range [][]conf.Provider{
+		{conf.EnvProvider},
+		{conf.EnvProvider, conf.SecretEnvProvider},
+	} {
+		cfg := config{Admin: &credentials{}}
+		assert.NoError(t, conf.Parse(&cfg, providers...))
+		assert.Equal(t, "localhost", cfg.Host)
+		assert.Equal(t, credentials{User: "app", Password: "hunter2"}, cfg.Credentials)
+		assert.Equal(t, &credentials{User: "admin"}, cfg.Admin)
+		assert.Nil(t, cfg.Unset)
+	}
+}
+
+func TestConfProviderErrors(t *testing.T) {
+	type unknown struct {
+		Credentials struct {
+			User string `env:"USER"`
+		} `confProvider:"vault"`
+	}
+	assert.EqualError(t, conf.Parse(&unknown{}, conf.EnvProvider), `env: field "Credentials" has unknown confProvider "vault"`)
+
+	type notStruct struct {
+		User string `env:"USER" confProvider:"secret"`
+	}
+	assert.EqualError(t, conf.Parse(&notStruct{}, conf.EnvProvider), `env: field "User" has confProvider but is not a struct`)
+}
+
+func TestConfProviderResetToDefaults(t *testing.T) {
+	type credentials struct {
+		User string `secret:"DB_USER" envDefault:"app"`
+	}
+	type config struct {
+		Credentials credentials `confProvider:"secret"`
+	}
+
+	cfg := config{Credentials: credentials{User: "admin"}}
+	assert.NoError(t, conf.ResetToDefaults(&cfg))
+	assert.Equal(t, "app", cfg.Credentials.User)
+}
+
+func TestFieldFilter(t *testing.T) {
+	type database struct {
+		Host string `env:"DB_HOST" envGroup:"db"`
+	}
+	type config struct {
+		DatabaseURL string   `env:"DATABASE_URL,required" envGroup:"db"`
+		Database    database `envGroup:"db"`
+		LogLevel    string   `env:"LOG_LEVEL,required" envGroup:"log"`
+		Other       database
+	}
+	defer os.Clearenv()
+
+	os.Setenv("DATABASE_URL", "postgres://localhost/db")
+	os.Setenv("DB_HOST", "localhost")
+
+	group := func(name string) []conf.Option {
+		return []conf.Option{conf.WithFieldFilter(func(sf reflect.StructField) bool {
+			return sf.Tag.Get("envGroup") == name
+		})}
+	}
+
+	cfg := config{}
+	assert.NoError(t, conf.ParseWithOptions(&cfg, group("db"), conf.EnvProvider))
+	assert.Equal(t, "postgres://localhost/db", cfg.DatabaseURL)
+	assert.Equal(t, "localhost", cfg.Database.Host)
+	assert.Empty(t, cfg.LogLevel)
+	assert.Empty(t, cfg.Other.Host)
+
+	assert.EqualError(t, conf.ParseWithOptions(&cfg, group("log"), conf.EnvProvider), "env: required environment variable \"LOG_LEVEL\" is not set")
+
+	os.Setenv("LOG_LEVEL", "debug")
+	assert.NoError(t, conf.ParseWithOptions(&cfg, group("log"), conf.EnvProvider))
+	assert.Equal(t, "debug", cfg.LogLevel)
+}
+
+func TestFieldFilterSkipsProvider(t *testing.T) {
+	type config struct {
+		Parsed  string `env:"PARSED"`
+		Skipped string `env:"SKIPPED"`
+	}
+
+	var provided []string
+	provider := providerFunc(func(sf reflect.StructField) (string, error) {
+		provided = append(provided, sf.Name)
+		return "value", nil
+	})
+
+	cfg := config{}
+	assert.NoError(t, conf.ParseWithOptions(&cfg, []conf.Option{
+		conf.WithFieldFilter(func(sf reflect.StructField) bool {
+			return sf.Name != "Skipped"
+		}),
+	}, provider))
+	assert.Equal(t, []string{"Parsed"}, provided)
+	assert.Equal(t, "value", cfg.Parsed)
+	assert.Empty(t, cfg.Skipped)
+}
+
+type providerFunc func(sf reflect.StructField) (string, error)
+
+func (f providerFunc) Provide(sf reflect.StructField) (string, error) {
+	return f(sf)
+}
+
+func TestInCodeDefaults(t *testing.T) {
+	type config struct {
+		Host    string        `env:"HOST" envDefault:"tag.default"`
+		Port    int           `env:"PORT" envDefault:"3000"`
+		Timeout time.Duration `env:"TIMEOUT"`
+		Debug   bool          `env:"DEBUG"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("PORT", "8080")
+
+	cfg := config{
+		Host:    "code.default",
+		Port:    80,
+		Timeout: time.Minute,
+	}
+	err := conf.ParseWithOptions(&cfg, []conf.Option{conf.WithInCodeDefaults()}, conf.EnvProvider)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "code.default", cfg.Host)
+	assert.Equal(t, 8080, cfg.Port)
+	assert.Equal(t, time.Minute, cfg.Timeout)
+	assert.False(t, cfg.Debug)
+
+	cfg = config{Host: "code.default"}
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, "tag.default", cfg.Host)
+}
+
+func TestNotEmpty(t *testing.T) {
+	type config struct {
+		Host string `env:"HOST,notEmpty"`
+		Port string `env:"PORT,notEmpty" envDefault:"3000"`
+	}
+	defer os.Clearenv()
+
+	var cfg config
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), "env: environment variable \"HOST\" should not be empty")
+
+	os.Setenv("HOST", "")
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), "env: environment variable \"HOST\" should not be empty")
+
+	os.Setenv("HOST", "localhost")
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, "localhost", cfg.Host)
+	assert.Equal(t, "3000", cfg.Port)
+}
+
+func TestStrictRequired(t *testing.T) {
+	type config struct {
+		IsRequired string `env:"IS_REQUIRED,required"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("IS_REQUIRED", "")
+
+	cfg := &config{}
+	assert.NoError(t, conf.Parse(cfg, conf.EnvProvider))
+	assert.EqualError(t, conf.ParseWithOptions(cfg, []conf.Option{conf.WithStrictRequired()}, conf.EnvProvider), "env: environment variable \"IS_REQUIRED\" should not be empty")
+
+	os.Unsetenv("IS_REQUIRED")
+	assert.EqualError(t, conf.ParseWithOptions(cfg, []conf.Option{conf.WithStrictRequired()}, conf.EnvProvider), "env: required environment variable \"IS_REQUIRED\" is not set")
+
+	os.Setenv("IS_REQUIRED", "yes")
+	assert.NoError(t, conf.ParseWithOptions(cfg, []conf.Option{conf.WithStrictRequired()}, conf.EnvProvider))
+	assert.Equal(t, "yes", cfg.IsRequired)
+}
+
+func TestParseExpandOption(t *testing.T) {
+	type config struct {
+		Host        string `env:"HOST" envDefault:"localhost"`
+		Port        int    `env:"PORT" envDefault:"3000" envExpand:"True"`
+		SecretKey   string `env:"SECRET_KEY" envExpand:"True"`
+		ExpandKey   string `env:"EXPAND_KEY"`
+		CompoundKey string `env:"HOST_PORT" envDefault:"${HOST}:${PORT}" envExpand:"True"`
+		Default     string `env:"DEFAULT" envDefault:"def1"  envExpand:"True"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("HOST", "localhost")
+	os.Setenv("PORT", "3000")
+	os.Setenv("EXPAND_KEY", "qwerty12345")
+	os.Setenv("SECRET_KEY", "${EXPAND_KEY}")
+
+	cfg := config{}
+	err := conf.Parse(&cfg, conf.EnvProvider)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost", cfg.Host)
+	assert.Equal(t, 3000, cfg.Port)
+	assert.Equal(t, "qwerty12345", cfg.SecretKey)
+	assert.Equal(t, "qwerty12345", cfg.ExpandKey)
+	assert.Equal(t, "localhost:3000", cfg.CompoundKey)
+	assert.Equal(t, "def1", cfg.Default)
+}
+
+func TestCustomParser(t *testing.T) {
+	type foo struct {
+		name string
+	}
+
+	type bar struct {
+		Name string `env:"OTHER"`
+		Foo  *foo   `env:"BLAH"`
+	}
+
+	type config struct {
+		Var   foo  `env:"VAR"`
+		Foo   *foo `env:"BLAH"`
+		Other *bar
+	}
+
+	os.Setenv("VAR", "test")
+	defer os.Unsetenv("VAR")
+	os.Setenv("OTHER", "test2")
+	defer os.Unsetenv("OTHER")
+	os.Setenv("BLAH", "test3")
+	defer os.Unsetenv("BLAH")
+
+	cfg := &config{
+		Other: &bar{},
+	}
+	err := conf.ParseWithFuncs(cfg, map[reflect.Type]conf.ParserFunc{
+		reflect.TypeOf(foo{}): func(v string) (interface{}, error) {
+			return foo{name: v}, nil
+		},
+	}, conf.EnvProvider)
+
+	assert.NoError(t, err)
+	assert.Equal(t, cfg.Var.name, "test")
+	assert.Equal(t, cfg.Foo.name, "test3")
+	assert.Equal(t, cfg.Other.Name, "test2")
+	assert.Equal(t, cfg.Other.Foo.name, "test3")
+}
+
+func TestCustomParserNestedStruct(t *testing.T) {
+	type foo struct {
+		name string
+	}
+
+	type bar struct {
+		Foo foo `env:"FOO"`
+	}
+
+	type config struct {
+		Inline struct {
+			Foo foo `env:"FOO"`
+		}
+		Bar    bar
+		BarPtr *bar
+	}
+
+	os.Setenv("FOO", "nested")
+	defer os.Clearenv()
+
+	cfg := &config{BarPtr: &bar{}}
+	err := conf.ParseWithFuncs(cfg, map[reflect.Type]conf.ParserFunc{
+		reflect.TypeOf(foo{}): func(v string) (interface{}, error) {
+			return foo{name: v}, nil
+		},
+	}, conf.EnvProvider)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "nested", cfg.Inline.Foo.name)
+	assert.Equal(t, "nested", cfg.Bar.Foo.name)
+	assert.Equal(t, "nested", cfg.BarPtr.Foo.name)
+}
+
+func TestCustomParserPointerSlices(t *testing.T) {
+	type foo struct {
+		name string
+	}
+
+	type config struct {
+		URLs    []*url.URL `env:"URLS"`
+		Foos    []*foo     `env:"FOOS"`
+		FooPtrs []*foo     `env:"FOOS"`
+		Foo     foo        `env:"FOO"`
+		FooPtr  *foo       `env:"FOO"`
+	}
+
+	os.Setenv("URLS", "https://a.com,https://b.com")
+	os.Setenv("FOOS", "one,two")
+	os.Setenv("FOO", "three")
+	defer os.Clearenv()
+
+	cfg := &config{}
+	err := conf.ParseWithFuncs(cfg, map[reflect.Type]conf.ParserFunc{
+		reflect.TypeOf(url.URL{}): func(v string) (interface{}, error) {
+			return url.Parse(v)
+		},
+		reflect.TypeOf(foo{}): func(v string) (interface{}, error) {
+			return &foo{name: v}, nil
+		},
+	}, conf.EnvProvider)
+
+	assert.NoError(t, err)
+	require.Len(t, cfg.URLs, 2)
+	assert.Equal(t, "https://a.com", cfg.URLs[0].String())
+	assert.Equal(t, "https://b.com", cfg.URLs[1].String())
+	require.Len(t, cfg.Foos, 2)
+	assert.Equal(t, "one", cfg.Foos[0].name)
+	assert.Equal(t, "two", cfg.Foos[1].name)
+	assert.True(t, cfg.Foos[0] != cfg.FooPtrs[0], "each element should be allocated separately")
+	assert.Equal(t, "three", cfg.Foo.name)
+	assert.Equal(t, "three", cfg.FooPtr.name)
+}
+
+func TestCustomParserWrongType(t *testing.T) {
+	type foo struct{}
+
+	type config struct {
+		Foos []*foo `env:"FOOS"`
+	}
+
+	os.Setenv("FOOS", "one")
+	defer os.Clearenv()
+
+	cfg := &config{}
+	err := conf.ParseWithFuncs(cfg, map[reflect.Type]conf.ParserFunc{
+		reflect.TypeOf(foo{}): func(v string) (interface{}, error) {
+			return v, nil
+		},
+	}, conf.EnvProvider)
+
+	assert.EqualError(t, err, "env: parse error on field \"Foos\" of type \"[]*conf_test.foo\": parser returned string, expected conf_test.foo")
+}
+
+func TestCustomParserNotConvertedToString(t *testing.T) {
+	type config struct {
+		Name    string `env:"NAME"`
+		Timeout string `env:"TIMEOUT" envParser:"iso8601"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("NAME", "A")
+	err := conf.ParseWithFuncs(&config{}, map[reflect.Type]conf.ParserFunc{
+		reflect.TypeOf(""): func(v string) (interface{}, error) {
+			return 65, nil
+		},
+	}, conf.EnvProvider)
+	assert.EqualError(t, err, "env: parse error on field \"Name\" of type \"string\": parser returned int, expected string")
+
+	os.Clearenv()
+	os.Setenv("TIMEOUT", "PT1M")
+	err = conf.Parse(&config{}, conf.EnvProvider)
+	assert.EqualError(t, err, "env: parse error on field \"Timeout\" of type \"string\": parser returned time.Duration, expected string")
+}
+
+func TestParseWithFuncsNoPtr(t *testing.T) {
+	type foo struct{}
+	err := conf.ParseWithFuncs(foo{}, nil, nil)
+	assert.EqualError(t, err, "env: expected a pointer to a Struct")
+}
+
+func TestParseWithFuncsInvalidType(t *testing.T) {
+	var c int
+	err := conf.ParseWithFuncs(&c, nil, nil)
+	assert.EqualError(t, err, "env: expected a pointer to a Struct")
+}
+
+func TestCustomParserError(t *testing.T) {
+	type foo struct {
+		name string
+	}
+
+	customParserFunc := func(v string) (interface{}, error) {
+		return nil, errors.New("something broke")
+	}
+
+	t.Run("single", func(t *testing.T) {
+		type config struct {
+			Var foo `env:"VAR"`
+		}
+
+		os.Setenv("VAR", "single")
+		cfg := &config{}
+		err := conf.ParseWithFuncs(cfg, map[reflect.Type]conf.ParserFunc{
+			reflect.TypeOf(foo{}): customParserFunc,
+		}, conf.EnvProvider)
+
+		assert.Empty(t, cfg.Var.name)
+		assert.EqualError(t, err, "env: parse error on field \"Var\" of type \"conf_test.foo\": something broke")
+	})
+
+	t.Run("slice", func(t *testing.T) {
+		type config struct {
+			Var []foo `env:"VAR2"`
+		}
+		os.Setenv("VAR2", "slice,slace")
+
+		cfg := &config{}
+		err := conf.ParseWithFuncs(cfg, map[reflect.Type]conf.ParserFunc{
+			reflect.TypeOf(foo{}): customParserFunc,
+		}, conf.EnvProvider)
+
+		assert.Empty(t, cfg.Var)
+		assert.EqualError(t, err, "env: parse error on field \"Var\" of type \"[]conf_test.foo\": something broke")
+	})
+}
+
+func TestCustomParserBasicType(t *testing.T) {
+	type ConstT int32
+
+	type config struct {
+		Const ConstT `env:"CONST_"`
+	}
+
+	exp := ConstT(123)
+	os.Setenv("CONST_", fmt.Sprintf("%d", exp))
+
+	customParserFunc := func(v string) (interface{}, error) {
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, err
+		}
+		r := ConstT(i)
+		return r, nil
+	}
+
+	cfg := &config{}
+	err := conf.ParseWithFuncs(cfg, map[reflect.Type]conf.ParserFunc{
+		reflect.TypeOf(ConstT(0)): customParserFunc,
+	}, conf.EnvProvider)
+
+	assert.NoError(t, err)
+	assert.Equal(t, exp, cfg.Const)
+}
+
+func TestCustomParserUint64Alias(t *testing.T) {
+	type T uint64
+
+	var one T = 1
+
+	type config struct {
+		Val T `env:"" envDefault:"1x"`
+	}
+
+	parserCalled := false
+
+	tParser := func(value string) (interface{}, error) {
+		parserCalled = true
+		trimmed := strings.TrimSuffix(value, "x")
+		i, err := strconv.Atoi(trimmed)
+		if err != nil {
+			return nil, err
+		}
+		return T(i), nil
+	}
+
+	cfg := config{}
+
+	err := conf.ParseWithFuncs(&cfg, map[reflect.Type]conf.ParserFunc{
+		reflect.TypeOf(one): tParser,
 	}, conf.EnvProvider)
 
 	assert.True(t, parserCalled, "tParser should have been called")
@@ -961,13 +2208,179 @@ func TestErrorOptionNotRecognized(t *testing.T) {
 	assert.EqualError(t, conf.Parse(cfg, conf.EnvProvider), "env: tag option \"not_supported!\" not supported")
 }
 
+func TestErrorPrefix(t *testing.T) {
+	defer os.Clearenv()
+	opts := []conf.Option{conf.WithErrorPrefix("cfg")}
+
+	t.Run("parse error", func(t *testing.T) {
+		type config struct {
+			Port int `env:"PORT"`
+		}
+		os.Setenv("PORT", "eighty")
+		var cfg config
+		assert.EqualError(t, conf.ParseWithOptions(&cfg, opts, conf.EnvProvider), "cfg: parse error on field \"Port\" of type \"int\": strconv.ParseInt: parsing \"eighty\": invalid syntax")
+	})
+
+	t.Run("no parser", func(t *testing.T) {
+		type config struct {
+			Client http.Client `env:"CLIENT"`
+		}
+		os.Setenv("CLIENT", "client")
+		var cfg config
+		assert.EqualError(t, conf.ParseWithOptions(&cfg, opts, conf.EnvProvider), "cfg: no parser found for field \"Client\" of type \"http.Client\"")
+	})
+
+	t.Run("required", func(t *testing.T) {
+		type config struct {
+			Secret string `secret:"API_SECRET,required"`
+		}
+		var cfg config
+		assert.EqualError(t, conf.ParseWithOptions(&cfg, opts, conf.SecretEnvProvider), "cfg: required environment variable \"API_SECRET\" is not set")
+	})
+
+	t.Run("option", func(t *testing.T) {
+		type config struct {
+			Var string `env:"VAR,nope"`
+		}
+		var cfg config
+		assert.EqualError(t, conf.ParseWithOptions(&cfg, opts, conf.EnvProvider), "cfg: tag option \"nope\" not supported")
+	})
+
+	t.Run("not a struct pointer", func(t *testing.T) {
+		assert.Equal(t, conf.ErrNotAStructPtr, conf.ParseWithOptions("config", opts, conf.EnvProvider))
+	})
+
+	t.Run("required if", func(t *testing.T) {
+		type config struct {
+			Backend string `env:"BACKEND" envDefault:"s3"`
+			Bucket  string `env:"BUCKET" envRequiredIf:"BACKEND=s3"`
+		}
+		var cfg config
+		assert.EqualError(t, conf.ParseWithOptions(&cfg, opts, conf.EnvProvider), "cfg: required environment variable \"BUCKET\" is not set when BACKEND=s3")
+	})
+
+	t.Run("warning", func(t *testing.T) {
+		type config struct {
+			Host string `env:"HOST" envDeprecated:"OLD_HOST"`
+		}
+		os.Setenv("OLD_HOST", "localhost")
+		var warnings []string
+		var cfg config
+		assert.NoError(t, conf.ParseWithOptions(&cfg, append(opts, conf.WithWarningHandler(func(w string) {
+			warnings = append(warnings, w)
+		})), conf.EnvProvider))
+		assert.Equal(t, []string{"cfg: environment variable \"OLD_HOST\" is deprecated, use \"HOST\" instead"}, warnings)
+	})
+}
+
+func TestNeverEchoValues(t *testing.T) {
+	type db struct {
+		Port int `json:"port"`
+	}
+	const value = "hunter2"
+	opts := []conf.Option{conf.WithNeverEchoValues()}
+
+	for name, tc := range map[string]struct {
+		config   interface{}
+		value    string
+		provider conf.Provider
+		err      string
+	}{
+		"int": {
+			config: &struct {
+				Port int `env:"VALUE"`
+			}{},
+			err: `env: parse error on field "Port" of type "int"`,
+		},
+		"duration": {
+			config: &struct {
+				TTL conf.ExtDuration `env:"VALUE"`
+			}{},
+			err: `env: parse error on field "TTL" of type "conf.ExtDuration"`,
+		},
+		"text unmarshaler": {
+			config: &struct {
+				Timeout unmarshaler `env:"VALUE"`
+			}{},
+			err: `env: parse error on field "Timeout" of type "conf_test.unmarshaler"`,
+		},
+		"parse conf": {
+			config: &struct {
+				Endpoint endpoint `env:"VALUE"`
+			}{},
+			err: `env: parse error on field "Endpoint" of type "conf_test.endpoint"`,
+		},
+		"slice": {
+			config: &struct {
+				Ports []int `env:"VALUE"`
+			}{},
+			value: "80," + value,
+			err:   `env: parse error on field "Ports" of type "[]int"`,
+		},
+		"map": {
+			config: &struct {
+				Labels map[string]string `env:"VALUE"`
+			}{},
+			err: `env: parse error on field "Labels" of type "map[string]string"`,
+		},
+		"json": {
+			config: &struct {
+				DB db `env:"VALUE"`
+			}{},
+			value: `{"port": "` + value + `"}`,
+			err:   `env: parse error on field "DB" of type "conf_test.db"`,
+		},
+		"env parser": {
+			config: &struct {
+				Ports []int `env:"VALUE" envParser:"intrange"`
+			}{},
+			err: `env: parse error on field "Ports" of type "[]int"`,
+		},
+		"provider": {
+			config: &struct {
+				Host string `env:"VALUE"`
+			}{},
+			provider: providerFunc(func(reflect.StructField) (string, error) {
+				return "", fmt.Errorf("unexpected %q", value)
+			}),
+			err: `env: provider error on field "Host"`,
+		},
+		"encoded provider": {
+			config: &struct {
+				Host string `env:"VALUE"`
+			}{},
+			value: value + "!",
+			provider: func() conf.Provider {
+				p, _ := conf.NewEncodedProvider(conf.EnvProvider, "base64")
+				return p
+			}(),
+			err: `env: unable to decode field "Host" as base64: illegal base64 data at input byte 7`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer os.Clearenv()
+			if tc.value == "" {
+				tc.value = value
+			}
+			if tc.provider == nil {
+				tc.provider = conf.EnvProvider
+			}
+			os.Setenv("VALUE", tc.value)
+
+			err := conf.ParseWithOptions(tc.config, opts, tc.provider)
+			assert.EqualError(t, err, tc.err)
+			assert.NotContains(t, err.Error(), value)
+		})
+	}
+}
+
 func TestTextUnmarshalerError(t *testing.T) {
 	type config struct {
 		Unmarshaler unmarshaler `env:"UNMARSHALER"`
 	}
 	os.Setenv("UNMARSHALER", "invalid")
 	cfg := &config{}
-	assert.EqualError(t, conf.Parse(cfg, conf.EnvProvider), "env: parse error on field \"Unmarshaler\" of type \"conf_test.unmarshaler\": time: invalid duration invalid")
+	assert.EqualError(t, conf.Parse(cfg, conf.EnvProvider), "env: parse error on field \"Unmarshaler\" of type \"conf_test.unmarshaler\": time: invalid duration \"invalid\"")
 }
 
 func TestTextUnmarshalersError(t *testing.T) {
@@ -976,7 +2389,54 @@ func TestTextUnmarshalersError(t *testing.T) {
 	}
 	os.Setenv("UNMARSHALERS", "1s,invalid")
 	cfg := &config{}
-	assert.EqualError(t, conf.Parse(cfg, conf.EnvProvider), "env: parse error on field \"Unmarshalers\" of type \"[]conf_test.unmarshaler\": time: invalid duration invalid")
+	assert.EqualError(t, conf.Parse(cfg, conf.EnvProvider), "env: parse error on field \"Unmarshalers\" of type \"[]conf_test.unmarshaler\": time: invalid duration \"invalid\"")
+}
+
+func TestParseConfMethod(t *testing.T) {
+	type config struct {
+		Endpoint     endpoint    `env:"ENDPOINT"`
+		EndpointPtr  *endpoint   `env:"ENDPOINT"`
+		Endpoints    []endpoint  `env:"ENDPOINTS"`
+		EndpointPtrs []*endpoint `env:"ENDPOINTS"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("ENDPOINT", "localhost:8080")
+	os.Setenv("ENDPOINTS", "a:1,b:2")
+
+	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, endpoint{Host: "localhost", Port: 8080}, cfg.Endpoint)
+	assert.Equal(t, &endpoint{Host: "localhost", Port: 8080}, cfg.EndpointPtr)
+	assert.Equal(t, []endpoint{{Host: "a", Port: 1}, {Host: "b", Port: 2}}, cfg.Endpoints)
+	assert.Equal(t, []*endpoint{{Host: "a", Port: 1}, {Host: "b", Port: 2}}, cfg.EndpointPtrs)
+}
+
+func TestParseConfMethodError(t *testing.T) {
+	type config struct {
+		Endpoint endpoint `env:"ENDPOINT"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("ENDPOINT", "localhost")
+
+	cfg := config{}
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), "env: parse error on field \"Endpoint\" of type \"conf_test.endpoint\": address localhost: missing port in address")
+}
+
+func TestParseConfMethodAfterTextUnmarshaler(t *testing.T) {
+	type config struct {
+		Value  confParsingUnmarshaler   `env:"VALUE"`
+		Values []confParsingUnmarshaler `env:"VALUE"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("VALUE", "x")
+
+	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, "text", cfg.Value.Source)
+	assert.Equal(t, "text", cfg.Values[0].Source)
 }
 
 func TestParseURL(t *testing.T) {
@@ -994,7 +2454,115 @@ func TestParseInvalidURL(t *testing.T) {
 	}
 	var cfg config
 	os.Setenv("EXAMPLE_URL_2", "nope://s s/")
-	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), "env: parse error on field \"ExampleURL\" of type \"url.URL\": unable parse URL: parse nope://s s/: invalid character \" \" in host name")
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), "env: parse error on field \"ExampleURL\" of type \"url.URL\": unable parse URL: parse \"nope://s s/\": invalid character \" \" in host name")
+}
+
+func TestParseNetipAddr(t *testing.T) {
+	type config struct {
+		V4      netip.Addr    `env:"ADDR_V4"`
+		V6      netip.Addr    `env:"ADDR_V6"`
+		Zoned   netip.Addr    `env:"ADDR_ZONED"`
+		Ptr     *netip.Addr   `env:"ADDR_V4"`
+		Addrs   []netip.Addr  `env:"ADDRS"`
+		AddrPtr []*netip.Addr `env:"ADDRS"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("ADDR_V4", "192.168.0.1")
+	os.Setenv("ADDR_V6", "2001:db8::1")
+	os.Setenv("ADDR_ZONED", "fe80::1%eth0")
+	os.Setenv("ADDRS", "10.0.0.1,::1")
+
+	var cfg config
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, netip.MustParseAddr("192.168.0.1"), cfg.V4)
+	assert.True(t, cfg.V4.Is4())
+	assert.Equal(t, netip.MustParseAddr("2001:db8::1"), cfg.V6)
+	assert.True(t, cfg.V6.Is6())
+	assert.Equal(t, "eth0", cfg.Zoned.Zone())
+	assert.Equal(t, netip.MustParseAddr("192.168.0.1"), *cfg.Ptr)
+	assert.Equal(t, []netip.Addr{netip.MustParseAddr("10.0.0.1"), netip.MustParseAddr("::1")}, cfg.Addrs)
+	assert.Len(t, cfg.AddrPtr, 2)
+	assert.Equal(t, netip.MustParseAddr("::1"), *cfg.AddrPtr[1])
+}
+
+func TestParseNetipPrefix(t *testing.T) {
+	type config struct {
+		V4       netip.Prefix    `env:"PREFIX_V4"`
+		V6       netip.Prefix    `env:"PREFIX_V6"`
+		Prefixes []netip.Prefix  `env:"PREFIXES"`
+		PtrSlice []*netip.Prefix `env:"PREFIXES"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("PREFIX_V4", "10.0.0.0/8")
+	os.Setenv("PREFIX_V6", "2001:db8::/32")
+	os.Setenv("PREFIXES", "192.168.0.0/16,fd00::/8")
+
+	var cfg config
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, netip.MustParsePrefix("10.0.0.0/8"), cfg.V4)
+	assert.Equal(t, 32, cfg.V6.Bits())
+	assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("192.168.0.0/16"), netip.MustParsePrefix("fd00::/8")}, cfg.Prefixes)
+	assert.Equal(t, netip.MustParsePrefix("fd00::/8"), *cfg.PtrSlice[1])
+}
+
+func TestParseInvalidNetip(t *testing.T) {
+	defer os.Clearenv()
+
+	t.Run("addr", func(t *testing.T) {
+		type config struct {
+			Addr netip.Addr `env:"ADDR"`
+		}
+		os.Setenv("ADDR", "256.0.0.1")
+		var cfg config
+		assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), "env: parse error on field \"Addr\" of type \"netip.Addr\": unable to parse IP address: ParseAddr(\"256.0.0.1\"): IPv4 field has value >255")
+	})
+
+	t.Run("prefix", func(t *testing.T) {
+		type config struct {
+			Prefixes []netip.Prefix `env:"PREFIXES"`
+		}
+		os.Setenv("PREFIXES", "10.0.0.0/8,10.0.0.0/33")
+		var cfg config
+		assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), "env: parse error on field \"Prefixes\" of type \"[]netip.Prefix\": unable to parse IP prefix: netip.ParsePrefix(\"10.0.0.0/33\"): prefix length out of range")
+	})
+}
+
+func TestParseLocation(t *testing.T) {
+	type config struct {
+		TZ        *time.Location   `env:"TZ"`
+		UTC       *time.Location   `env:"UTC_TZ"`
+		Local     *time.Location   `env:"LOCAL_TZ"`
+		Locations []*time.Location `env:"TZS"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("TZ", "America/New_York")
+	os.Setenv("UTC_TZ", "UTC")
+	os.Setenv("LOCAL_TZ", "Local")
+	os.Setenv("TZS", "Europe/London,utc")
+
+	var cfg config
+	require.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, "America/New_York", cfg.TZ.String())
+	assert.True(t, time.UTC == cfg.UTC)
+	assert.True(t, time.Local == cfg.Local)
+	require.Len(t, cfg.Locations, 2)
+	assert.Equal(t, "Europe/London", cfg.Locations[0].String())
+	assert.True(t, time.UTC == cfg.Locations[1])
+}
+
+func TestParseInvalidLocation(t *testing.T) {
+	type config struct {
+		TZ *time.Location `env:"TZ"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("TZ", "Mars/Olympus_Mons")
+
+	var cfg config
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), "env: parse error on field \"TZ\" of type \"*time.Location\": unable to load location: unknown time zone Mars/Olympus_Mons")
 }
 
 func ExampleParse() {
@@ -1078,6 +2646,32 @@ func TestPrecedenceUnmarshalText(t *testing.T) {
 	assert.Equal(t, []LogLevel{DebugLevel, InfoLevel}, cfg.LogLevels)
 }
 
+func TestCustomParserPrecedesUnmarshalText(t *testing.T) {
+	os.Setenv("LOG_LEVEL", "verbose")
+	os.Setenv("LOG_LEVELS", "verbose,quiet")
+	defer os.Unsetenv("LOG_LEVEL")
+	defer os.Unsetenv("LOG_LEVELS")
+
+	type config struct {
+		LogLevel     LogLevel    `env:"LOG_LEVEL"`
+		LogLevelPtr  *LogLevel   `env:"LOG_LEVEL"`
+		LogLevels    []LogLevel  `env:"LOG_LEVELS"`
+		LogLevelPtrs []*LogLevel `env:"LOG_LEVELS"`
+	}
+	var cfg config
+
+	assert.NoError(t, conf.ParseWithFuncs(&cfg, map[reflect.Type]conf.ParserFunc{
+		reflect.TypeOf(LogLevel(0)): func(v string) (interface{}, error) {
+			return InfoLevel, nil
+		},
+	}, conf.EnvProvider))
+	assert.Equal(t, InfoLevel, cfg.LogLevel)
+	assert.Equal(t, InfoLevel, *cfg.LogLevelPtr)
+	assert.Equal(t, []LogLevel{InfoLevel, InfoLevel}, cfg.LogLevels)
+	assert.Len(t, cfg.LogLevelPtrs, 2)
+	assert.Equal(t, InfoLevel, *cfg.LogLevelPtrs[1])
+}
+
 func ExampleParseWithFuncs() {
 	type thing struct {
 		desc string
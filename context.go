@@ -0,0 +1,69 @@
+package conf
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// ContextProvider is implemented by providers which read from the network,
+// such as a config service, so a slow lookup is aborted when the context set
+// with WithContext, or by ParseWithTimeout, is done. When a provider
+// implements it, the parser calls `ProvideContext` instead of `ProvideResult`
+// and `Provide`.
+type ContextProvider interface {
+	Provider
+	ProvideContext(ctx context.Context, field reflect.StructField) (Result, error)
+}
+
+// ParseWithTimeout is the same as `Parse` except the parse must finish within
+// d, so a slow config store does not hang startup. Fields are resolved with a
+// context with that deadline, which aborts the lookups of ContextProviders,
+// and no field is resolved once it has passed. The error names the field
+// being resolved when the deadline passed and matches
+// context.DeadlineExceeded with errors.Is.
+func ParseWithTimeout(d time.Duration, v interface{}, providers ...Provider) error {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	return ParseWithOptions(v, []Option{WithContext(ctx)}, providers...)
+}
+
+// provideResult resolves field with provider, passing ctx to a
+// ContextProvider. Once ctx is done, the error of the lookup is replaced
+// by one naming the field.
+func provideResult(ctx context.Context, provider Provider, field reflect.StructField) (Result, error) {
+	if err := ctx.Err(); err != nil {
+		return Result{}, contextError{field: field.Name, err: err}
+	}
+	var result Result
+	var err error
+	switch p := provider.(type) {
+	case ContextProvider:
+		result, err = p.ProvideContext(ctx, field)
+	case ResultProvider:
+		result, err = p.ProvideResult(field)
+	default:
+		result.Value, err = provider.Provide(field)
+	}
+	if err != nil && ctx.Err() != nil {
+		return Result{}, contextError{field: field.Name, err: ctx.Err()}
+	}
+	return result, err
+}
+
+// contextError is returned when the context of a parse is done before a
+// field is resolved, such as when the deadline of ParseWithTimeout passes.
+type contextError struct {
+	field  string
+	err    error
+	prefix string
+}
+
+func (e contextError) Error() string {
+	return fmt.Sprintf(`%s: unable to resolve field "%s": %v`, errorPrefix(e.prefix), e.field, e.err)
+}
+
+func (e contextError) Unwrap() error {
+	return e.err
+}
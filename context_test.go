@@ -0,0 +1,127 @@
+package conf_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowProvider is a network backed store which takes delay to answer a
+// lookup of any key in slow.
+type slowProvider struct {
+	values map[string]string
+	slow   map[string]bool
+	delay  time.Duration
+}
+
+func (p slowProvider) Provide(field reflect.StructField) (string, error) {
+	result, err := p.ProvideContext(context.Background(), field)
+	return result.Value, err
+}
+
+func (p slowProvider) ProvideContext(ctx context.Context, field reflect.StructField) (conf.Result, error) {
+	key := field.Tag.Get("env")
+	if p.slow[key] {
+		select {
+		case <-ctx.Done():
+			return conf.Result{}, errors.New("lookup aborted: " + ctx.Err().Error())
+		case <-time.After(p.delay):
+		}
+	}
+	return conf.Result{Value: p.values[key], Key: key, Source: "slow"}, nil
+}
+
+type timeoutConfig struct {
+	Host     string `env:"HOST"`
+	Password string `env:"PASSWORD"`
+}
+
+func TestParseWithTimeout(t *testing.T) {
+	cfg := timeoutConfig{}
+	err := conf.ParseWithTimeout(time.Second, &cfg, slowProvider{
+		values: map[string]string{"HOST": "localhost", "PASSWORD": "hunter2"},
+		slow:   map[string]bool{"PASSWORD": true},
+		delay:  time.Millisecond,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, timeoutConfig{Host: "localhost", Password: "hunter2"}, cfg)
+}
+
+func TestParseWithTimeoutExceeded(t *testing.T) {
+	cfg := timeoutConfig{}
+	start := time.Now()
+	err := conf.ParseWithTimeout(20*time.Millisecond, &cfg, slowProvider{
+		values: map[string]string{"HOST": "localhost", "PASSWORD": "hunter2"},
+		slow:   map[string]bool{"PASSWORD": true},
+		delay:  time.Minute,
+	})
+	assert.EqualError(t, err, `env: unable to resolve field "Password": context deadline exceeded`)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Less(t, int64(time.Since(start)), int64(time.Minute))
+	assert.Equal(t, "localhost", cfg.Host)
+}
+
+func TestParseWithTimeoutChain(t *testing.T) {
+	cfg := timeoutConfig{}
+	err := conf.ParseWithTimeout(20*time.Millisecond, &cfg, conf.NewChainProvider(
+		conf.NewMapProvider(map[string]string{"HOST": "localhost"}),
+		slowProvider{slow: map[string]bool{"PASSWORD": true}, delay: time.Minute},
+	))
+	assert.EqualError(t, err, `env: unable to resolve field "Password": context deadline exceeded`)
+}
+
+// blockingProvider does not take a context, so a lookup cannot be aborted.
+type blockingProvider struct {
+	delay time.Duration
+}
+
+func (p blockingProvider) Provide(field reflect.StructField) (string, error) {
+	time.Sleep(p.delay)
+	return "value", nil
+}
+
+func TestParseWithTimeoutPlainProvider(t *testing.T) {
+	cfg := timeoutConfig{}
+	err := conf.ParseWithTimeout(10*time.Millisecond, &cfg, blockingProvider{delay: 50 * time.Millisecond})
+	assert.EqualError(t, err, `env: unable to resolve field "Password": context deadline exceeded`)
+	assert.Equal(t, "value", cfg.Host)
+}
+
+func TestParseWithContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cfg := timeoutConfig{}
+	err := conf.ParseWithOptions(&cfg, []conf.Option{conf.WithContext(ctx), conf.WithErrorPrefix("config")},
+		conf.NewMapProvider(map[string]string{"HOST": "localhost"}))
+	assert.EqualError(t, err, `config: unable to resolve field "Host": context canceled`)
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestParseWithTimeoutFetch(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	type config struct {
+		Token string `env:"TOKEN,fetch"`
+	}
+
+	cfg := config{}
+	err := conf.ParseWithTimeout(20*time.Millisecond, &cfg, conf.NewMapProvider(map[string]string{"TOKEN": server.URL}))
+	assert.EqualError(t, err, `env: unable to resolve field "Token": context deadline exceeded`)
+}
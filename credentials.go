@@ -0,0 +1,123 @@
+package conf
+
+import (
+	"reflect"
+	"strings"
+)
+
+// nolint: gochecknoglobals
+var credentialsType = reflect.TypeOf(Credentials{})
+
+// Credentials is a username and password read from two variables whose keys
+// are derived from the key of the field, e.g. a field tagged `env:"DB"` is
+// read from DB_USERNAME and DB_PASSWORD. The options of the key apply to
+// both halves, and so do the `envPrefix` of the enclosing struct fields and
+// the keys of `envAlias` and `envDeprecated`.
+//
+// The password is printed as "***" by String, MarshalEnv, MarshalJSONMasked
+// and audit events.
+type Credentials struct {
+	Username string
+	Password string `mask:"true"`
+}
+
+// String returns the username and a masked password, e.g. "app:***".
+func (c Credentials) String() string {
+	if c.Username == "" && c.Password == "" {
+		return ""
+	}
+	return c.Username + ":" + maskedValue
+}
+
+// isCredentials reports whether a field is a Credentials or a pointer to one.
+func isCredentials(typee reflect.Type) bool {
+	return typee == credentialsType || typee.Kind() == reflect.Ptr && typee.Elem() == credentialsType
+}
+
+// parseCredentials sets the halves of a Credentials field from the keys of
+// the field with the _USERNAME and _PASSWORD suffixes. A half which is not
+// provided keeps its value, and a nil pointer is only allocated once either
+// half is provided.
+func (p *parser) parseCredentials(field reflect.Value, sf reflect.StructField) error {
+	if tagKey(sf, "env") == "" && tagKey(sf, "secret") == "" {
+		return newError(`field "%s" of type "%s" has no key`, sf.Name, sf.Type)
+	}
+	if p.fallback && p.resolved[newResolvedKey(field)] {
+		return nil
+	}
+
+	var creds Credentials
+	if field.Kind() == reflect.Ptr && !field.IsNil() {
+		creds = *field.Interface().(*Credentials)
+	} else if field.Kind() == reflect.Struct {
+		creds = field.Interface().(Credentials)
+	}
+	halves := []struct {
+		suffix string
+		value  *string
+	}{
+		{"_USERNAME", &creds.Username},
+		{"_PASSWORD", &creds.Password},
+	}
+	var set, provided bool
+	for _, half := range halves {
+		hsf := withKeySuffix(sf, half.suffix)
+		if half.suffix == "_PASSWORD" {
+			hsf.Tag += ` mask:"true"`
+		}
+		result, err := p.provide(hsf)
+		if err != nil {
+			return err
+		}
+		if result.Value == "" {
+			continue
+		}
+		*half.value = result.Value
+		set = true
+		provided = provided || !result.Default && !p.fallback
+	}
+	if !set {
+		return nil
+	}
+
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			field.Set(reflect.New(credentialsType))
+		}
+		field.Elem().Set(reflect.ValueOf(creds))
+	} else {
+		field.Set(reflect.ValueOf(creds))
+	}
+	if provided && p.provided != nil {
+		p.provided[newResolvedKey(field)] = true
+	}
+	if p.resolved != nil {
+		p.resolved[newResolvedKey(field)] = true
+	}
+	return nil
+}
+
+// withKeySuffix returns sf with suffix appended to the keys in its tags. The
+// `envDefault` of the field is removed as it can't be split between keys.
+func withKeySuffix(sf reflect.StructField, suffix string) reflect.StructField {
+	var tags []structTag
+	for _, tag := range parseTag(sf.Tag) {
+		switch {
+		case tag.name == "envDefault":
+			continue
+		case contains(prefixedTags, tag.name):
+			if key, opts := parseKeyForOption(tag.value); key != "" {
+				tag.value = strings.Join(append([]string{key + suffix}, opts...), ",")
+			}
+		case contains(prefixedListTags, tag.name) && tag.value != "":
+			keys := strings.Split(tag.value, ",")
+			for i := range keys {
+				keys[i] += suffix
+			}
+			tag.value = strings.Join(keys, ",")
+		}
+		tags = append(tags, tag)
+	}
+	sf.Tag = formatTag(tags)
+	return sf
+}
@@ -0,0 +1,91 @@
+package conf_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCredentials(t *testing.T) {
+	type database struct {
+		Admin conf.Credentials  `env:"ADMIN"`
+		User  *conf.Credentials `env:"USER"`
+	}
+	type config struct {
+		DB      database          `envPrefix:"DB_"`
+		Cache   conf.Credentials  `env:"CACHE" envAlias:"REDIS"`
+		Missing *conf.Credentials `env:"MISSING"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("DB_ADMIN_USERNAME", "root")
+	os.Setenv("DB_ADMIN_PASSWORD", "s3cret")
+	os.Setenv("DB_USER_USERNAME", "app")
+	os.Setenv("REDIS_PASSWORD", "hunter2")
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, conf.Credentials{Username: "root", Password: "s3cret"}, cfg.DB.Admin)
+	assert.Equal(t, &conf.Credentials{Username: "app"}, cfg.DB.User)
+	assert.Equal(t, conf.Credentials{Password: "hunter2"}, cfg.Cache)
+	assert.Nil(t, cfg.Missing)
+	assert.Equal(t, "root:***", cfg.DB.Admin.String())
+	assert.Equal(t, "", conf.Credentials{}.String())
+}
+
+func TestCredentialsOptions(t *testing.T) {
+	defer os.Clearenv()
+
+	type required struct {
+		DB conf.Credentials `env:"DB,required"`
+	}
+	os.Setenv("DB_USERNAME", "app")
+	assert.EqualError(t, conf.Parse(&required{}, conf.EnvProvider), `env: required environment variable "DB_PASSWORD" is not set`)
+
+	type file struct {
+		DB conf.Credentials `env:"DB,file"`
+	}
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "username"), []byte("app\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "password"), []byte("s3cret\n"), 0o600))
+	os.Setenv("DB_USERNAME", filepath.Join(dir, "username"))
+	os.Setenv("DB_PASSWORD", filepath.Join(dir, "password"))
+
+	cfg := file{}
+	require.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, conf.Credentials{Username: "app", Password: "s3cret"}, cfg.DB)
+
+	type noKey struct {
+		DB conf.Credentials
+	}
+	assert.EqualError(t, conf.Parse(&noKey{}, conf.EnvProvider), `env: field "DB" of type "conf.Credentials" has no key`)
+}
+
+func TestCredentialsMasked(t *testing.T) {
+	type config struct {
+		DB conf.Credentials `env:"DB"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("DB_USERNAME", "app")
+	os.Setenv("DB_PASSWORD", "s3cret")
+
+	var events []conf.AuditEvent
+	cfg := config{}
+	require.NoError(t, conf.ParseWithOptions(&cfg, []conf.Option{
+		conf.WithAuditSink(func(e conf.AuditEvent) { events = append(events, e) }),
+	}, conf.EnvProvider))
+	require.Len(t, events, 2)
+	assert.Equal(t, "DB_USERNAME", events[0].Key)
+	assert.False(t, events[0].Masked)
+	assert.Equal(t, "DB_PASSWORD", events[1].Key)
+	assert.True(t, events[1].Masked)
+
+	b, err := conf.MarshalEnv(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "DB_USERNAME=app\nDB_PASSWORD=***\n", string(b))
+}
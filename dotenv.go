@@ -0,0 +1,153 @@
+package conf
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// NewDotenvProvider reads `KEY=value` lines from r and returns a provider which
+// resolves `env` tags from them instead of the environment. It supports the
+// same tags and options as EnvProvider.
+//
+// Blank lines and lines starting with # are skipped, and keys may be preceded
+// by `export `. An unquoted value ends at a # preceded by whitespace, so
+// `KEY=a#b` keeps its hash, and `\#` is a literal hash. Values in single quotes
+// are kept as written, including any #, while \n, \t, \r, \" and \\ are
+// unescaped in double quoted values. Quoted values may span several lines,
+// continuing until the closing quote, so PEM blocks can be written as they
+// are.
+func NewDotenvProvider(r io.Reader) (Provider, error) {
+	values, err := parseDotenv(r)
+	if err != nil {
+		return nil, err
+	}
+	return envProvider{tag: "env", source: "dotenv", keys: mapKeys(values), lookup: func(key string) (string, bool) {
+		value, ok := values[key]
+		return value, ok
+	}}, nil
+}
+
+// NewDotenvFileProvider is the same as NewDotenvProvider, reading the file at
+// path.
+func NewDotenvFileProvider(path string) (Provider, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, newError("unable to read dotenv file: %v", err)
+	}
+	defer f.Close()
+	return NewDotenvProvider(f)
+}
+
+// nolint: gochecknoglobals
+var errUnterminatedQuote = errors.New("unterminated quoted value")
+
+func parseDotenv(r io.Reader) (map[string]string, error) {
+	values := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if trimmed := strings.TrimSpace(line); trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		key, raw, ok := strings.Cut(line, "=")
+		key = dotenvKey(key)
+		if !ok || key == "" {
+			return nil, newError("dotenv line %d: expected KEY=value", n)
+		}
+		start := n
+		value, err := parseDotenvValue(raw)
+		// a quoted value continues on the next lines until its closing quote
+		for err == errUnterminatedQuote && scanner.Scan() {
+			n++
+			raw += "\n" + scanner.Text()
+			value, err = parseDotenvValue(raw)
+		}
+		if err != nil {
+			return nil, newError("dotenv line %d: %v", start, err)
+		}
+		values[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, newError("unable to read dotenv: %v", err)
+	}
+	return values, nil
+}
+
+// dotenvKey trims the key of a line and strips a leading export, so files
+// written to be sourced by a shell can be read too.
+func dotenvKey(s string) string {
+	s = strings.TrimSpace(s)
+	if rest := strings.TrimPrefix(s, "export"); rest != s && rest != "" && isSpace(rest[0]) {
+		s = strings.TrimSpace(rest)
+	}
+	return s
+}
+
+// parseDotenvValue parses everything after the = of a line.
+func parseDotenvValue(s string) (string, error) {
+	if trimmed := strings.TrimLeft(s, " \t"); trimmed != "" && (trimmed[0] == '"' || trimmed[0] == '\'') {
+		return parseQuotedDotenvValue(trimmed)
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == '#':
+			b.WriteByte('#')
+			i++
+		case s[i] == '#' && i > 0 && isSpace(s[i-1]):
+			return strings.TrimSpace(b.String()), nil
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// parseQuotedDotenvValue parses a value in single or double quotes. Single
+// quoted values are kept as written, while \n, \t, \r, \" and \\ are
+// unescaped in double quoted values.
+func parseQuotedDotenvValue(s string) (string, error) {
+	quote := s[0]
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		if quote == '"' && s[i] == '\\' && i+1 < len(s) {
+			i++
+			b.WriteString(unescapeDotenv(s[i]))
+			continue
+		}
+		if s[i] != quote {
+			b.WriteByte(s[i])
+			continue
+		}
+		if rest := strings.TrimSpace(s[i+1:]); rest != "" && rest[0] != '#' {
+			return "", fmt.Errorf("unexpected %q after quoted value", rest)
+		}
+		return b.String(), nil
+	}
+	return "", errUnterminatedQuote
+}
+
+// unescapeDotenv returns the character escaped by c after a backslash. Other
+// escapes are kept as written.
+func unescapeDotenv(c byte) string {
+	switch c {
+	case 'n':
+		return "\n"
+	case 't':
+		return "\t"
+	case 'r':
+		return "\r"
+	case '"', '\\':
+		return string(c)
+	}
+	return "\\" + string(c)
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t'
+}
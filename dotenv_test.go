@@ -0,0 +1,190 @@
+package conf_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDotenvInlineComments(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{"plain", `VALUE=value`, "value"},
+		{"comment", `VALUE=value # comment`, "value"},
+		{"tab before comment", "VALUE=value\t# comment", "value"},
+		{"hash without space", `VALUE=value#notacomment`, "value#notacomment"},
+		{"leading hash", `VALUE=#value`, "#value"},
+		{"only comment", `VALUE= # comment`, ""},
+		{"escaped hash", `VALUE=value \# not a comment`, "value # not a comment"},
+		{"escaped hash then comment", `VALUE=a\#b # comment`, "a#b"},
+		{"double quoted hash", `VALUE="value # not a comment"`, "value # not a comment"},
+		{"single quoted hash", `VALUE='value # not a comment'`, "value # not a comment"},
+		{"quoted then comment", `VALUE="value" # comment`, "value"},
+		{"quoted with escaped quote", `VALUE="a\"#b" # comment`, `a"#b`},
+		{"surrounding whitespace", `VALUE =  value  `, "value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			type config struct {
+				Value string `env:"VALUE"`
+			}
+			provider, err := conf.NewDotenvProvider(strings.NewReader(tt.line))
+			require.NoError(t, err)
+
+			cfg := config{}
+			assert.NoError(t, conf.Parse(&cfg, provider))
+			assert.Equal(t, tt.want, cfg.Value)
+		})
+	}
+}
+
+func TestDotenvNormalization(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{"export", `export VALUE=value`, "value"},
+		{"export with tab", "export\tVALUE=value", "value"},
+		{"export with whitespace", `  export   VALUE = value`, "value"},
+		{"indented key", "\t VALUE=value", "value"},
+		{"double quotes", `VALUE="value"`, "value"},
+		{"single quotes", `VALUE='value'`, "value"},
+		{"newline", `VALUE="a\nb"`, "a\nb"},
+		{"tab", `VALUE="a\tb"`, "a\tb"},
+		{"carriage return", `VALUE="a\rb"`, "a\rb"},
+		{"escaped backslash", `VALUE="a\\nb"`, `a\nb`},
+		{"unknown escape", `VALUE="a\qb"`, `a\qb`},
+		{"single quotes keep escapes", `VALUE='a\nb'`, `a\nb`},
+		{"unquoted keeps escapes", `VALUE=a\nb`, `a\nb`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			type config struct {
+				Value string `env:"VALUE"`
+			}
+			provider, err := conf.NewDotenvProvider(strings.NewReader(tt.line))
+			require.NoError(t, err)
+
+			cfg := config{}
+			assert.NoError(t, conf.Parse(&cfg, provider))
+			assert.Equal(t, tt.want, cfg.Value)
+		})
+	}
+}
+
+func TestDotenvExportedKeyNamedExport(t *testing.T) {
+	type config struct {
+		Export string `env:"EXPORT_DIR"`
+		Value  string `env:"export"`
+	}
+	provider, err := conf.NewDotenvProvider(strings.NewReader("EXPORT_DIR=/tmp\nexport=yes"))
+	require.NoError(t, err)
+
+	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, provider))
+	assert.Equal(t, config{Export: "/tmp", Value: "yes"}, cfg)
+}
+
+func TestDotenvProvider(t *testing.T) {
+	type config struct {
+		Host  string   `env:"HOST,required"`
+		Port  int      `env:"PORT" envDefault:"8080"`
+		Peers []string `env:"PEERS"`
+		Home  string   `env:"HOME"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("HOME", "/root")
+	provider, err := conf.NewDotenvProvider(strings.NewReader(`
+# database
+HOST=localhost
+
+PEERS=a,b
+`))
+	require.NoError(t, err)
+
+	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, provider))
+	assert.Equal(t, config{Host: "localhost", Port: 8080, Peers: []string{"a", "b"}}, cfg)
+}
+
+func TestDotenvFileProvider(t *testing.T) {
+	type config struct {
+		Host string `env:"HOST"`
+	}
+	path := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(path, []byte("HOST=localhost # local\n"), 0600))
+
+	provider, err := conf.NewDotenvFileProvider(path)
+	require.NoError(t, err)
+
+	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, provider))
+	assert.Equal(t, "localhost", cfg.Host)
+}
+
+func TestDotenvMultiLineValues(t *testing.T) {
+	const pem = "-----BEGIN CERTIFICATE-----\nMIIBszCCAVmgAwIBAgIU\nZmFrZSBjZXJ0aWZpY2F0ZQ==\n-----END CERTIFICATE-----"
+	type config struct {
+		Cert   string `env:"CERT"`
+		Key    string `env:"KEY"`
+		Note   string `env:"NOTE"`
+		Host   string `env:"HOST"`
+		Escape string `env:"ESCAPE"`
+	}
+	input := "CERT=\"" + pem + "\"\n" +
+		"KEY='" + pem + "' # comment after the closing quote\n" +
+		"NOTE=\"first line\n\n# not a comment\nlast \\\"line\\\"\"\n" +
+		"HOST=localhost\n" +
+		"ESCAPE=\"one\\ntwo\"\n"
+
+	provider, err := conf.NewDotenvProvider(strings.NewReader(input))
+	require.NoError(t, err)
+
+	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, provider))
+	assert.Equal(t, pem, cfg.Cert)
+	assert.Equal(t, pem, cfg.Key)
+	assert.Equal(t, "first line\n\n# not a comment\nlast \"line\"", cfg.Note)
+	assert.Equal(t, "localhost", cfg.Host)
+	assert.Equal(t, "one\ntwo", cfg.Escape)
+}
+
+func TestDotenvMultiLineCRLF(t *testing.T) {
+	type config struct {
+		Cert string `env:"CERT"`
+	}
+	provider, err := conf.NewDotenvProvider(strings.NewReader("CERT=\"a\r\nb\"\r\nHOST=x\r\n"))
+	require.NoError(t, err)
+
+	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, provider))
+	assert.Equal(t, "a\nb", cfg.Cert)
+}
+
+func TestDotenvErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"HOST=localhost\nPORT", "env: dotenv line 2: expected KEY=value"},
+		{"=localhost", "env: dotenv line 1: expected KEY=value"},
+		{`HOST="localhost`, "env: dotenv line 1: unterminated quoted value"},
+		{`HOST="local"host`, `env: dotenv line 1: unexpected "host" after quoted value`},
+		{"A=a\nCERT=\"-----BEGIN\nabc\nB=b", "env: dotenv line 2: unterminated quoted value"},
+		{"CERT='-----BEGIN\nabc\n-----END' trailing", `env: dotenv line 1: unexpected "trailing" after quoted value`},
+	}
+	for _, tt := range tests {
+		_, err := conf.NewDotenvProvider(strings.NewReader(tt.input))
+		assert.EqualError(t, err, tt.err)
+	}
+}
@@ -0,0 +1,310 @@
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExtDuration is a time.Duration which is parsed with the units of
+// time.ParseDuration plus "d" for days and "w" for weeks, e.g. "30d" or
+// "1w2d3h". A day is always 24 hours.
+type ExtDuration time.Duration
+
+// Duration returns d as a time.Duration.
+func (d ExtDuration) Duration() time.Duration {
+	return time.Duration(d)
+}
+
+func (d ExtDuration) String() string {
+	return time.Duration(d).String()
+}
+
+// nolint: gochecknoglobals
+var extDurationUnits = map[string]float64{
+	"ns": float64(time.Nanosecond),
+	"us": float64(time.Microsecond),
+	"µs": float64(time.Microsecond),
+	"μs": float64(time.Microsecond),
+	"ms": float64(time.Millisecond),
+	"s":  float64(time.Second),
+	"m":  float64(time.Minute),
+	"h":  float64(time.Hour),
+	"d":  float64(24 * time.Hour),
+	"w":  float64(7 * 24 * time.Hour),
+}
+
+func parseExtDuration(v string) (ExtDuration, error) {
+	s := v
+	var neg bool
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+	if s == "0" {
+		return 0, nil
+	}
+	if s == "" {
+		return 0, fmt.Errorf("invalid duration %q", v)
+	}
+
+	var total float64
+	for s != "" {
+		i := 0
+		for i < len(s) && (s[i] == '.' || '0' <= s[i] && s[i] <= '9') {
+			i++
+		}
+		n, err := strconv.ParseFloat(s[:i], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", v)
+		}
+		s = s[i:]
+
+		i = 0
+		for i < len(s) && s[i] != '.' && (s[i] < '0' || s[i] > '9') {
+			i++
+		}
+		if i == 0 {
+			return 0, fmt.Errorf("missing unit in duration %q", v)
+		}
+		unit, ok := extDurationUnits[s[:i]]
+		if !ok {
+			return 0, fmt.Errorf("unknown unit %q in duration %q", s[:i], v)
+		}
+		s = s[i:]
+		total += n * unit
+	}
+	if total > math.MaxInt64 {
+		return 0, fmt.Errorf("invalid duration %q", v)
+	}
+	if neg {
+		total = -total
+	}
+	return ExtDuration(total), nil
+}
+
+// parseISO8601Duration parses an ISO 8601 duration such as "PT1H30M" or
+// "P1W2D". Years and months have no fixed length, so they are rejected.
+func parseISO8601Duration(v string) (time.Duration, error) {
+	s := v
+	var neg bool
+	if strings.HasPrefix(s, "-") {
+		neg = true
+		s = s[1:]
+	}
+	if !strings.HasPrefix(s, "P") || s == "P" || strings.HasSuffix(s, "T") {
+		return 0, fmt.Errorf("invalid duration %q", v)
+	}
+	s = s[1:]
+
+	var total float64
+	var inTime bool
+	for s != "" {
+		if s[0] == 'T' {
+			if inTime {
+				return 0, fmt.Errorf("invalid duration %q", v)
+			}
+			inTime = true
+			s = s[1:]
+			continue
+		}
+		i := 0
+		for i < len(s) && (s[i] == '.' || '0' <= s[i] && s[i] <= '9') {
+			i++
+		}
+		if i == 0 || i == len(s) {
+			return 0, fmt.Errorf("invalid duration %q", v)
+		}
+		n, err := strconv.ParseFloat(s[:i], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", v)
+		}
+		unit, err := iso8601Unit(s[i], inTime)
+		if err != nil {
+			return 0, err
+		}
+		total += n * float64(unit)
+		s = s[i+1:]
+	}
+	if total > math.MaxInt64 {
+		return 0, fmt.Errorf("invalid duration %q", v)
+	}
+	if neg {
+		total = -total
+	}
+	return time.Duration(total), nil
+}
+
+func iso8601Unit(designator byte, inTime bool) (time.Duration, error) {
+	switch {
+	case designator == 'Y' && !inTime:
+		return 0, errors.New("years are not supported, their length varies")
+	case designator == 'M' && !inTime:
+		return 0, errors.New("months are not supported, their length varies")
+	case designator == 'W' && !inTime:
+		return 7 * 24 * time.Hour, nil
+	case designator == 'D' && !inTime:
+		return 24 * time.Hour, nil
+	case designator == 'H' && inTime:
+		return time.Hour, nil
+	case designator == 'M' && inTime:
+		return time.Minute, nil
+	case designator == 'S' && inTime:
+		return time.Second, nil
+	}
+	return 0, fmt.Errorf("unexpected designator %q", designator)
+}
+
+// nolint: gochecknoglobals
+var humanDurationUnits = map[string]time.Duration{
+	"nanosecond":  time.Nanosecond,
+	"microsecond": time.Microsecond,
+	"millisecond": time.Millisecond,
+	"msec":        time.Millisecond,
+	"second":      time.Second,
+	"sec":         time.Second,
+	"minute":      time.Minute,
+	"min":         time.Minute,
+	"hour":        time.Hour,
+	"hr":          time.Hour,
+	"day":         24 * time.Hour,
+	"week":        7 * 24 * time.Hour,
+}
+
+// parseHumanDuration parses a duration written in English words, such as
+// "5 seconds" or "1 hour, 30 minutes and 10 seconds", or else in the form of
+// time.ParseDuration. Units may be singular, plural or abbreviated, and a
+// day is always 24 hours.
+func parseHumanDuration(v string) (time.Duration, error) {
+	if d, err := time.ParseDuration(v); err == nil {
+		return d, nil
+	}
+	words := strings.Fields(strings.ToLower(strings.ReplaceAll(v, ",", " ")))
+	if len(words) == 0 {
+		return 0, fmt.Errorf("invalid duration %q", v)
+	}
+
+	var total float64
+	for i := 0; len(words) > 0; i++ {
+		// terms after the first may be joined with "and"
+		if i > 0 && words[0] == "and" {
+			words = words[1:]
+		}
+		if len(words) == 0 {
+			return 0, fmt.Errorf("invalid duration %q", v)
+		}
+		n, err := strconv.ParseFloat(words[0], 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration %q, expected a number before each unit", v)
+		}
+		if len(words) == 1 {
+			return 0, fmt.Errorf("missing unit in duration %q", v)
+		}
+		unit, ok := humanDurationUnits[words[1]]
+		if !ok {
+			unit, ok = humanDurationUnits[strings.TrimSuffix(words[1], "s")]
+		}
+		if !ok {
+			return 0, fmt.Errorf("unknown unit %q in duration %q", words[1], v)
+		}
+		total += n * float64(unit)
+		words = words[2:]
+	}
+	if total > math.MaxInt64 {
+		return 0, fmt.Errorf("invalid duration %q", v)
+	}
+	return time.Duration(total), nil
+}
+
+// splitRepeatingInterval expands an ISO 8601 repeating interval, such as
+// "R4/PT15M" or "R4/2024-01-01T00:00:00Z/PT15M", to the offsets of the start
+// of each repetition from the start of the first, here 0s, 15m, 30m and 45m.
+// The interval is a duration, or a start and an end or a duration in RFC
+// 3339. The start itself is not part of the offsets, and the number of
+// repetitions is required as an unbounded interval has no end.
+func splitRepeatingInterval(value, _ string) ([]string, error) {
+	parts := strings.Split(strings.TrimSpace(value), "/")
+	if len(parts) < 2 || len(parts) > 3 || !strings.HasPrefix(parts[0], "R") {
+		return nil, fmt.Errorf("invalid repeating interval %q, expected Rn/interval", value)
+	}
+	n, err := strconv.Atoi(parts[0][1:])
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("invalid number of repetitions %q", parts[0])
+	}
+	if n > maxExpandedElements {
+		return nil, fmt.Errorf("%d repetitions exceed the limit of %d", n, maxExpandedElements)
+	}
+
+	var interval time.Duration
+	if len(parts) == 2 {
+		interval, err = parseISO8601Duration(parts[1])
+	} else {
+		interval, err = parseIntervalLength(parts[1], parts[2])
+	}
+	if err != nil {
+		return nil, err
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid repeating interval %q, expected a positive interval", value)
+	}
+
+	offsets := make([]string, n)
+	for i := range offsets {
+		offsets[i] = (time.Duration(i) * interval).String()
+	}
+	return offsets, nil
+}
+
+// parseIntervalLength returns the length of an ISO 8601 interval given as a
+// start and an end, a start and a duration or a duration and an end.
+func parseIntervalLength(first, second string) (time.Duration, error) {
+	if strings.HasPrefix(first, "P") {
+		if _, err := time.Parse(time.RFC3339, second); err != nil {
+			return 0, fmt.Errorf("invalid interval end %q", second)
+		}
+		return parseISO8601Duration(first)
+	}
+	start, err := time.Parse(time.RFC3339, first)
+	if err != nil {
+		return 0, fmt.Errorf("invalid interval start %q", first)
+	}
+	if strings.HasPrefix(second, "P") {
+		return parseISO8601Duration(second)
+	}
+	end, err := time.Parse(time.RFC3339, second)
+	if err != nil {
+		return 0, fmt.Errorf("invalid interval end %q", second)
+	}
+	return end.Sub(start), nil
+}
+
+// nolint: gochecknoglobals
+var (
+	durationType    = reflect.TypeOf(time.Duration(0))
+	extDurationType = reflect.TypeOf(ExtDuration(0))
+)
+
+// withDurationUnit returns parserFunc, or the type parser of typee when it is
+// nil, reading numbers without a unit in unit, so "30" is read as "30s" with
+// the unit "s" while "1m" is read as it is.
+func (p *parser) withDurationUnit(parserFunc ParserFunc, typee reflect.Type, unit string, sf reflect.StructField) (ParserFunc, error) {
+	if typee != durationType && typee != extDurationType {
+		return nil, newError(`field "%s" has envDurationUnit but is not a duration`, sf.Name)
+	}
+	if parserFunc == nil {
+		parserFunc, _ = p.typeParser(typee)
+	}
+	if _, err := parserFunc("1" + unit); err != nil || unit == "" {
+		return nil, newError(`field "%s" has invalid envDurationUnit %q`, sf.Name, unit)
+	}
+	return func(v string) (interface{}, error) {
+		if _, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			v = strings.TrimSpace(v) + unit
+		}
+		return parserFunc(v)
+	}, nil
+}
@@ -0,0 +1,313 @@
+package conf_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtDuration(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"30d", 30 * 24 * time.Hour},
+		{"2w", 14 * 24 * time.Hour},
+		{"1w2d3h", 9*24*time.Hour + 3*time.Hour},
+		{"1.5d", 36 * time.Hour},
+		{"1h30m", 90 * time.Minute},
+		{"250ms", 250 * time.Millisecond},
+		{"-1d", -24 * time.Hour},
+		{"0", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			type config struct {
+				Retention conf.ExtDuration `env:"RETENTION"`
+			}
+			defer os.Clearenv()
+			os.Setenv("RETENTION", tt.value)
+
+			cfg := config{}
+			assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+			assert.Equal(t, tt.want, cfg.Retention.Duration())
+		})
+	}
+}
+
+func TestExtDurationSlice(t *testing.T) {
+	type config struct {
+		Intervals []conf.ExtDuration `env:"INTERVALS"`
+	}
+	defer os.Clearenv()
+	os.Setenv("INTERVALS", "1d,1w")
+
+	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, []conf.ExtDuration{conf.ExtDuration(24 * time.Hour), conf.ExtDuration(7 * 24 * time.Hour)}, cfg.Intervals)
+}
+
+func TestExtDurationInvalid(t *testing.T) {
+	tests := []struct {
+		value string
+		err   string
+	}{
+		{"30y", `unknown unit "y" in duration "30y"`},
+		{"30", `missing unit in duration "30"`},
+		{"d", `invalid duration "d"`},
+		{"-", `invalid duration "-"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			type config struct {
+				Retention conf.ExtDuration `env:"RETENTION"`
+			}
+			defer os.Clearenv()
+			os.Setenv("RETENTION", tt.value)
+
+			cfg := config{}
+			assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: parse error on field "Retention" of type "conf.ExtDuration": unable to parse duration: `+tt.err)
+		})
+	}
+}
+
+func TestISO8601Duration(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"PT1H30M", 90 * time.Minute},
+		{"PT45S", 45 * time.Second},
+		{"PT0.5S", 500 * time.Millisecond},
+		{"P1D", 24 * time.Hour},
+		{"P2W", 14 * 24 * time.Hour},
+		{"P1DT12H", 36 * time.Hour},
+		{"-PT5M", -5 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			type config struct {
+				Timeout    time.Duration    `env:"TIMEOUT" envParser:"iso8601"`
+				TimeoutPtr *time.Duration   `env:"TIMEOUT" envParser:"iso8601"`
+				Timeouts   []time.Duration  `env:"TIMEOUT" envParser:"iso8601"`
+				Retention  conf.ExtDuration `env:"TIMEOUT" envParser:"iso8601"`
+			}
+			defer os.Clearenv()
+			os.Setenv("TIMEOUT", tt.value)
+
+			cfg := config{}
+			assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+			assert.Equal(t, tt.want, cfg.Timeout)
+			assert.Equal(t, tt.want, *cfg.TimeoutPtr)
+			assert.Equal(t, []time.Duration{tt.want}, cfg.Timeouts)
+			assert.Equal(t, tt.want, cfg.Retention.Duration())
+		})
+	}
+}
+
+func TestHumanDuration(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"5 seconds", 5 * time.Second},
+		{"1 second", time.Second},
+		{"2 minutes", 2 * time.Minute},
+		{"1.5 hours", 90 * time.Minute},
+		{"1 hour 30 mins", 90 * time.Minute},
+		{"1 hour, 30 minutes and 10 seconds", time.Hour + 30*time.Minute + 10*time.Second},
+		{"2 Days", 48 * time.Hour},
+		{"1 week", 7 * 24 * time.Hour},
+		{"250 msec", 250 * time.Millisecond},
+		{"1h30m", 90 * time.Minute},
+		{"-5s", -5 * time.Second},
+		{"0", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			type config struct {
+				Timeout   time.Duration    `env:"TIMEOUT" envParser:"humantime"`
+				Timeouts  []time.Duration  `env:"TIMEOUT" envParser:"humantime" envSeparator:";"`
+				Retention conf.ExtDuration `env:"TIMEOUT" envParser:"humantime"`
+			}
+			defer os.Clearenv()
+			os.Setenv("TIMEOUT", tt.value)
+
+			cfg := config{}
+			assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+			assert.Equal(t, tt.want, cfg.Timeout)
+			assert.Equal(t, []time.Duration{tt.want}, cfg.Timeouts)
+			assert.Equal(t, tt.want, cfg.Retention.Duration())
+		})
+	}
+}
+
+func TestHumanDurationInvalid(t *testing.T) {
+	tests := []struct {
+		value string
+		err   string
+	}{
+		{"5 fortnights", `unknown unit "fortnights" in duration "5 fortnights"`},
+		{"5", `missing unit in duration "5"`},
+		{"seconds", `invalid duration "seconds", expected a number before each unit`},
+		{"and 5 seconds", `invalid duration "and 5 seconds", expected a number before each unit`},
+		{"5 seconds and", `invalid duration "5 seconds and"`},
+		{" , ", `invalid duration " , "`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			type config struct {
+				Timeout time.Duration `env:"TIMEOUT" envParser:"humantime"`
+			}
+			defer os.Clearenv()
+			os.Setenv("TIMEOUT", tt.value)
+
+			cfg := config{}
+			assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: parse error on field "Timeout" of type "time.Duration": unable to parse duration: `+tt.err)
+		})
+	}
+}
+
+func TestISO8601DurationInvalid(t *testing.T) {
+	tests := []struct {
+		value string
+		err   string
+	}{
+		{"P1Y", "years are not supported, their length varies"},
+		{"P2M", "months are not supported, their length varies"},
+		{"P1Y2M3D", "years are not supported, their length varies"},
+		{"1h30m", `invalid duration "1h30m"`},
+		{"P", `invalid duration "P"`},
+		{"PT", `invalid duration "PT"`},
+		{"P1DT", `invalid duration "P1DT"`},
+		{"PT1D", `unexpected designator 'D'`},
+		{"P1H", `unexpected designator 'H'`},
+		{"PT1", `invalid duration "PT1"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			type config struct {
+				Timeout time.Duration `env:"TIMEOUT" envParser:"iso8601"`
+			}
+			defer os.Clearenv()
+			os.Setenv("TIMEOUT", tt.value)
+
+			cfg := config{}
+			assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: parse error on field "Timeout" of type "time.Duration": unable to parse ISO 8601 duration: `+tt.err)
+		})
+	}
+}
+
+func TestISO8601RepeatingInterval(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []time.Duration
+	}{
+		{"R4/PT15M", []time.Duration{0, 15 * time.Minute, 30 * time.Minute, 45 * time.Minute}},
+		{"R1/P1D", []time.Duration{0}},
+		{"R3/2024-01-01T00:00:00Z/PT1H30M", []time.Duration{0, 90 * time.Minute, 3 * time.Hour}},
+		{"R2/2024-01-01T00:00:00Z/2024-01-01T06:00:00Z", []time.Duration{0, 6 * time.Hour}},
+		{"R2/PT2H/2024-01-01T06:00:00Z", []time.Duration{0, 2 * time.Hour}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			type config struct {
+				Offsets []time.Duration `env:"SCHEDULE" envParser:"iso8601interval"`
+			}
+			defer os.Clearenv()
+			os.Setenv("SCHEDULE", tt.value)
+
+			cfg := config{}
+			assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+			assert.Equal(t, tt.want, cfg.Offsets)
+		})
+	}
+}
+
+func TestISO8601RepeatingIntervalInvalid(t *testing.T) {
+	tests := []struct {
+		value string
+		err   string
+	}{
+		{"PT15M", `invalid repeating interval "PT15M", expected Rn/interval`},
+		{"R/PT15M", `invalid number of repetitions "R"`},
+		{"R0/PT15M", `invalid number of repetitions "R0"`},
+		{"R100001/PT15M", `100001 repetitions exceed the limit of 100000`},
+		{"R999999999999999999999/PT15M", `invalid number of repetitions "R999999999999999999999"`},
+		{"R999999999999/PT15M", `999999999999 repetitions exceed the limit of 100000`},
+		{"R2/PT15M/PT1H/PT1H", `invalid repeating interval "R2/PT15M/PT1H/PT1H", expected Rn/interval`},
+		{"R2/15m", `invalid duration "15m"`},
+		{"R2/PT0S", `invalid repeating interval "R2/PT0S", expected a positive interval`},
+		{"R2/yesterday/PT1H", `invalid interval start "yesterday"`},
+		{"R2/2024-01-01T06:00:00Z/2024-01-01T00:00:00Z", `invalid repeating interval "R2/2024-01-01T06:00:00Z/2024-01-01T00:00:00Z", expected a positive interval`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			type config struct {
+				Offsets []time.Duration `env:"SCHEDULE" envParser:"iso8601interval"`
+			}
+			defer os.Clearenv()
+			os.Setenv("SCHEDULE", tt.value)
+
+			cfg := config{}
+			assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: parse error on field "Offsets" of type "[]time.Duration": `+tt.err)
+		})
+	}
+}
+
+func TestDurationUnit(t *testing.T) {
+	type config struct {
+		Timeout  time.Duration    `env:"TIMEOUT" envDurationUnit:"s"`
+		Explicit time.Duration    `env:"EXPLICIT" envDurationUnit:"s"`
+		Ptr      *time.Duration   `env:"TIMEOUT" envDurationUnit:"ms"`
+		Backoffs []time.Duration  `env:"BACKOFFS" envDurationUnit:"s"`
+		Mixed    []*time.Duration `env:"MIXED" envDurationUnit:"s"`
+		Retain   conf.ExtDuration `env:"RETAIN" envDurationUnit:"d"`
+		Default  time.Duration    `env:"DEFAULT" envDurationUnit:"m" envDefault:"5"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("TIMEOUT", "30")
+	os.Setenv("EXPLICIT", "1m30s")
+	os.Setenv("BACKOFFS", "30, 60,90,1.5")
+	os.Setenv("MIXED", "30,1m,250ms")
+	os.Setenv("RETAIN", "7")
+
+	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, 30*time.Second, cfg.Timeout)
+	assert.Equal(t, 90*time.Second, cfg.Explicit)
+	assert.Equal(t, 30*time.Millisecond, *cfg.Ptr)
+	assert.Equal(t, []time.Duration{30 * time.Second, time.Minute, 90 * time.Second, 1500 * time.Millisecond}, cfg.Backoffs)
+	require.Len(t, cfg.Mixed, 3)
+	assert.Equal(t, 30*time.Second, *cfg.Mixed[0])
+	assert.Equal(t, time.Minute, *cfg.Mixed[1])
+	assert.Equal(t, 250*time.Millisecond, *cfg.Mixed[2])
+	assert.Equal(t, 7*24*time.Hour, cfg.Retain.Duration())
+	assert.Equal(t, 5*time.Minute, cfg.Default)
+}
+
+func TestDurationUnitInvalid(t *testing.T) {
+	defer os.Clearenv()
+	os.Setenv("TIMEOUT", "30")
+
+	type unknownUnit struct {
+		Timeout time.Duration `env:"TIMEOUT" envDurationUnit:"d"`
+	}
+	assert.EqualError(t, conf.Parse(&unknownUnit{}, conf.EnvProvider), `env: field "Timeout" has invalid envDurationUnit "d"`)
+
+	type notADuration struct {
+		Timeout int `env:"TIMEOUT" envDurationUnit:"s"`
+	}
+	assert.EqualError(t, conf.Parse(&notADuration{}, conf.EnvProvider), `env: field "Timeout" has envDurationUnit but is not a duration`)
+
+	type invalidElement struct {
+		Timeouts []time.Duration `env:"TIMEOUTS" envDurationUnit:"s"`
+	}
+	os.Setenv("TIMEOUTS", "30,soon")
+	assert.EqualError(t, conf.Parse(&invalidElement{}, conf.EnvProvider), `env: parse error on field "Timeouts" of type "[]time.Duration": unable to parser duration: time: invalid duration "soon"`)
+}
@@ -0,0 +1,161 @@
+package conf
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// nolint: gochecknoglobals
+var decoders = map[string]func([]byte) ([]byte, error){
+	"base64": func(b []byte) ([]byte, error) {
+		out := make([]byte, base64.StdEncoding.DecodedLen(len(b)))
+		n, err := base64.StdEncoding.Decode(out, bytes.TrimSpace(b))
+		return out[:n], err
+	},
+	"gzip": func(b []byte) ([]byte, error) {
+		r, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	},
+}
+
+// nolint: gochecknoglobals
+var documentFormats = map[string]func(io.Reader) (Provider, error){
+	"dotenv": NewDotenvProvider,
+	"json":   NewJSONProvider,
+}
+
+type encodedProvider struct {
+	inner     Provider
+	encodings []string
+}
+
+// NewEncodedProvider decorates inner so every non-empty value it provides is
+// decoded, except for defaults such as `envDefault` tags, which are written
+// as they are. Encodings are applied in the order given, so a gzipped blob which
+// was then base64 encoded is read with
+//
+//	conf.NewEncodedProvider(conf.EnvProvider, "base64", "gzip")
+//
+// The supported encodings are "base64" and "gzip". The decoded value is
+// parsed like any other value, so a JSON document can populate a struct field.
+// To read the keys of an encoded dotenv or JSON document instead, use
+// NewEncodedDocumentProvider.
+func NewEncodedProvider(inner Provider, encodings ...string) (Provider, error) {
+	for _, encoding := range encodings {
+		if _, ok := decoders[encoding]; !ok {
+			return nil, fmt.Errorf("env: encoding %q not supported", encoding)
+		}
+	}
+	return encodedProvider{inner: inner, encodings: encodings}, nil
+}
+
+// NewEncodedDocumentProvider reads a whole config document held encoded in
+// the single key of inner, such as a `CONFIG_BLOB` variable, and resolves the
+// fields from the keys of the document rather than from inner. The value is
+// decoded once, here, with the encodings in the order given, and read with the
+// provider of format, "dotenv" for NewDotenvProvider or "json" for
+// NewJSONProvider:
+//
+//	conf.NewEncodedDocumentProvider(conf.EnvProvider, "CONFIG_BLOB", "dotenv", "base64", "gzip")
+//
+// key is resolved as if a field were tagged `env:"KEY"`, and it is an error
+// for it not to be set.
+func NewEncodedDocumentProvider(inner Provider, key, format string, encodings ...string) (Provider, error) {
+	newProvider, ok := documentFormats[format]
+	if !ok {
+		return nil, fmt.Errorf("env: document format %q not supported", format)
+	}
+	for _, encoding := range encodings {
+		if _, ok := decoders[encoding]; !ok {
+			return nil, fmt.Errorf("env: encoding %q not supported", encoding)
+		}
+	}
+
+	field := reflect.StructField{Name: key, Type: reflect.TypeOf(""), Tag: reflect.StructTag(`env:` + strconv.Quote(key))}
+	result, err := provideResult(context.Background(), inner, field)
+	if err != nil {
+		return nil, err
+	}
+	if result.Value == "" {
+		return nil, fmt.Errorf("env: encoded document %q is not set", key)
+	}
+	document, encoding, err := decode(encodings, result.Value)
+	if err != nil {
+		return nil, fmt.Errorf("env: unable to decode %q as %s: %v", key, encoding, err)
+	}
+	return newProvider(bytes.NewReader(document))
+}
+
+func (p encodedProvider) withOptions(opts options) Provider {
+	if c, ok := p.inner.(configurableProvider); ok {
+		p.inner = c.withOptions(opts)
+	}
+	return p
+}
+
+func (p encodedProvider) Provide(field reflect.StructField) (string, error) {
+	result, err := p.ProvideResult(field)
+	return result.Value, err
+}
+
+func (p encodedProvider) ProvideResult(field reflect.StructField) (Result, error) {
+	return p.ProvideContext(context.Background(), field)
+}
+
+// ProvideContext resolves field with the inner provider, passing ctx on when
+// it is a ContextProvider. Defaults, such as those of `envDefault` tags, are
+// not encoded, so they are returned as they are.
+func (p encodedProvider) ProvideContext(ctx context.Context, field reflect.StructField) (Result, error) {
+	result, err := provideResult(ctx, p.inner, field)
+	if err != nil || result.Value == "" || result.Default {
+		return result, err
+	}
+
+	if result.Values == nil {
+		result.Value, err = p.decode(field, result.Value)
+		return result, err
+	}
+	// the elements are encoded separately, so Value, which joins them, is
+	// rebuilt from the decoded elements rather than decoded itself
+	values := make([]string, len(result.Values))
+	for i, value := range result.Values {
+		if values[i], err = p.decode(field, value); err != nil {
+			return result, err
+		}
+	}
+	result.Values = values
+	result.Value = strings.Join(values, ",")
+	return result, nil
+}
+
+func (p encodedProvider) decode(field reflect.StructField, s string) (string, error) {
+	value, encoding, err := decode(p.encodings, s)
+	if err != nil {
+		return "", newError(`unable to decode field "%s" as %s: %v`, field.Name, encoding, err)
+	}
+	return string(value), nil
+}
+
+// decode applies the decoders of encodings to s in order, returning the
+// encoding which failed with the error.
+func decode(encodings []string, s string) ([]byte, string, error) {
+	value := []byte(s)
+	var err error
+	for _, encoding := range encodings {
+		if value, err = decoders[encoding](value); err != nil {
+			return nil, encoding, err
+		}
+	}
+	return value, "", nil
+}
@@ -0,0 +1,217 @@
+package conf_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gzipString(t *testing.T, s string) string {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(s))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.String()
+}
+
+func TestEncodedProviderBase64(t *testing.T) {
+	os.Setenv("PASSWORD", base64.StdEncoding.EncodeToString([]byte("s3cr3t")))
+	defer os.Clearenv()
+
+	type config struct {
+		Password string `env:"PASSWORD"`
+		Missing  string `env:"MISSING"`
+	}
+
+	provider, err := conf.NewEncodedProvider(conf.EnvProvider, "base64")
+	require.NoError(t, err)
+
+	var cfg config
+	assert.NoError(t, conf.Parse(&cfg, provider))
+	assert.Equal(t, "s3cr3t", cfg.Password)
+	assert.Empty(t, cfg.Missing)
+}
+
+func TestEncodedProviderGzip(t *testing.T) {
+	// gzip output contains NUL bytes, which environment variables cannot hold
+	compressed := gzipString(t, "hello world")
+	inner := providerFunc(func(sf reflect.StructField) (string, error) {
+		return compressed, nil
+	})
+
+	type config struct {
+		Motd string `env:"MOTD"`
+	}
+
+	provider, err := conf.NewEncodedProvider(inner, "gzip")
+	require.NoError(t, err)
+
+	var cfg config
+	assert.NoError(t, conf.Parse(&cfg, provider))
+	assert.Equal(t, "hello world", cfg.Motd)
+}
+
+func TestEncodedProviderPipeline(t *testing.T) {
+	blob := base64.StdEncoding.EncodeToString([]byte(gzipString(t, `{"host": "db.local", "port": 5432}`)))
+	os.Setenv("CONFIG_BLOB", blob)
+	defer os.Clearenv()
+
+	type database struct {
+		Host string `json:"host"`
+		Port int    `json:"port"`
+	}
+	type config struct {
+		Database database `env:"CONFIG_BLOB"`
+	}
+
+	provider, err := conf.NewEncodedProvider(conf.EnvProvider, "base64", "gzip")
+	require.NoError(t, err)
+
+	var cfg config
+	assert.NoError(t, conf.Parse(&cfg, provider))
+	assert.Equal(t, database{Host: "db.local", Port: 5432}, cfg.Database)
+}
+
+func TestEncodedProviderNumbered(t *testing.T) {
+	encode := func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	}
+
+	type config struct {
+		Peers []string `env:"PEER" envNumbered:"true"`
+		Ports []int    `env:"PORT" envNumbered:"true"`
+	}
+
+	provider, err := conf.NewEncodedProvider(conf.NewMapProvider(map[string]string{
+		"PEER_1": encode("peer-a:7000"),
+		"PEER_2": encode("peer-b:7000"),
+		"PORT_1": encode("80"),
+		"PORT_2": encode("443"),
+	}), "base64")
+	require.NoError(t, err)
+
+	var cfg config
+	require.NoError(t, conf.Parse(&cfg, provider))
+	assert.Equal(t, []string{"peer-a:7000", "peer-b:7000"}, cfg.Peers)
+	assert.Equal(t, []int{80, 443}, cfg.Ports)
+}
+
+func TestEncodedProviderDecodeError(t *testing.T) {
+	defer os.Clearenv()
+
+	type config struct {
+		Blob string `env:"CONFIG_BLOB"`
+	}
+
+	t.Run("base64", func(t *testing.T) {
+		os.Setenv("CONFIG_BLOB", "not base64!")
+		provider, err := conf.NewEncodedProvider(conf.EnvProvider, "base64", "gzip")
+		require.NoError(t, err)
+
+		var cfg config
+		assert.EqualError(t, conf.Parse(&cfg, provider), `env: unable to decode field "Blob" as base64: illegal base64 data at input byte 3`)
+	})
+
+	t.Run("gzip", func(t *testing.T) {
+		os.Setenv("CONFIG_BLOB", base64.StdEncoding.EncodeToString([]byte("plain text")))
+		provider, err := conf.NewEncodedProvider(conf.EnvProvider, "base64", "gzip")
+		require.NoError(t, err)
+
+		var cfg config
+		assert.EqualError(t, conf.Parse(&cfg, provider), `env: unable to decode field "Blob" as gzip: gzip: invalid header`)
+	})
+}
+
+func TestEncodedProviderUnsupportedEncoding(t *testing.T) {
+	_, err := conf.NewEncodedProvider(conf.EnvProvider, "rot13")
+	assert.EqualError(t, err, `env: encoding "rot13" not supported`)
+}
+
+func TestEncodedDocumentProvider(t *testing.T) {
+	defer os.Clearenv()
+
+	type config struct {
+		Host string `env:"db.host"`
+		Port int    `env:"db.port"`
+	}
+
+	t.Run("dotenv", func(t *testing.T) {
+		os.Setenv("CONFIG_BLOB", base64.StdEncoding.EncodeToString([]byte("db.host=localhost\ndb.port=5432\n")))
+		provider, err := conf.NewEncodedDocumentProvider(conf.EnvProvider, "CONFIG_BLOB", "dotenv", "base64")
+		require.NoError(t, err)
+
+		var cfg config
+		require.NoError(t, conf.Parse(&cfg, provider))
+		assert.Equal(t, config{Host: "localhost", Port: 5432}, cfg)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		blob := gzipString(t, `{"db": {"host": "db.internal", "port": 6543}}`)
+		os.Setenv("CONFIG_BLOB", base64.StdEncoding.EncodeToString([]byte(blob)))
+		provider, err := conf.NewEncodedDocumentProvider(conf.EnvProvider, "CONFIG_BLOB", "json", "base64", "gzip")
+		require.NoError(t, err)
+
+		var cfg config
+		require.NoError(t, conf.Parse(&cfg, provider))
+		assert.Equal(t, config{Host: "db.internal", Port: 6543}, cfg)
+	})
+}
+
+func TestEncodedDocumentProviderErrors(t *testing.T) {
+	defer os.Clearenv()
+
+	_, err := conf.NewEncodedDocumentProvider(conf.EnvProvider, "CONFIG_BLOB", "dotenv", "base64")
+	assert.EqualError(t, err, `env: encoded document "CONFIG_BLOB" is not set`)
+
+	os.Setenv("CONFIG_BLOB", "not base64!")
+	_, err = conf.NewEncodedDocumentProvider(conf.EnvProvider, "CONFIG_BLOB", "dotenv", "base64")
+	assert.EqualError(t, err, `env: unable to decode "CONFIG_BLOB" as base64: illegal base64 data at input byte 3`)
+
+	_, err = conf.NewEncodedDocumentProvider(conf.EnvProvider, "CONFIG_BLOB", "yaml", "base64")
+	assert.EqualError(t, err, `env: document format "yaml" not supported`)
+
+	_, err = conf.NewEncodedDocumentProvider(conf.EnvProvider, "CONFIG_BLOB", "json", "rot13")
+	assert.EqualError(t, err, `env: encoding "rot13" not supported`)
+}
+
+func TestEncodedProviderDefault(t *testing.T) {
+	defer os.Clearenv()
+	os.Setenv("PASSWORD", base64.StdEncoding.EncodeToString([]byte("s3cr3t")))
+
+	type config struct {
+		Password string `env:"PASSWORD" envDefault:"changeme"`
+		Region   string `env:"REGION" envDefault:"eu-west-1"`
+	}
+
+	provider, err := conf.NewEncodedProvider(conf.EnvProvider, "base64")
+	require.NoError(t, err)
+
+	var cfg config
+	require.NoError(t, conf.Parse(&cfg, provider))
+	assert.Equal(t, config{Password: "s3cr3t", Region: "eu-west-1"}, cfg)
+}
+
+func TestEncodedProviderContext(t *testing.T) {
+	provider, err := conf.NewEncodedProvider(slowProvider{
+		values: map[string]string{"HOST": base64.StdEncoding.EncodeToString([]byte("localhost"))},
+		slow:   map[string]bool{"PASSWORD": true},
+		delay:  time.Minute,
+	}, "base64")
+	require.NoError(t, err)
+
+	cfg := timeoutConfig{}
+	start := time.Now()
+	err = conf.ParseWithTimeout(20*time.Millisecond, &cfg, provider)
+	assert.EqualError(t, err, `env: unable to resolve field "Password": context deadline exceeded`)
+	assert.Less(t, int64(time.Since(start)), int64(time.Minute))
+	assert.Equal(t, "localhost", cfg.Host)
+}
@@ -0,0 +1,152 @@
+package conf
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// nolint: gochecknoglobals
+var (
+	enumsMu sync.RWMutex
+	enums   = map[reflect.Type]map[string]int64{}
+	flags   = map[reflect.Type]map[string]int64{}
+)
+
+// RegisterEnum registers the names of the values of an integer type, so fields
+// of that type can be configured by name, e.g. `MODE=fast`, or by one of the
+// registered values, e.g. `MODE=1`. Names are matched exactly and parsing
+// fails for names and numbers which are not registered, including those of
+// `envDefault` tags. RegisterEnum panics if t is not an integer type.
+func RegisterEnum(t reflect.Type, values map[string]int64) {
+	register(enums, "RegisterEnum", t, values)
+}
+
+// RegisterFlags registers the names of the bits of an integer bitmask type,
+// so fields of that type can be configured by a comma separated list of names
+// which are ORed together, e.g. `PERMS=read,write`. Names are matched exactly
+// and parsing fails for names which are not registered. RegisterFlags panics
+// if t is not an integer type.
+func RegisterFlags(t reflect.Type, values map[string]int64) {
+	register(flags, "RegisterFlags", t, values)
+}
+
+func register(registry map[reflect.Type]map[string]int64, fn string, t reflect.Type, values map[string]int64) {
+	if !isIntKind(t.Kind()) && !isUintKind(t.Kind()) {
+		panic(fmt.Sprintf("conf: %s called with non integer type %s", fn, t))
+	}
+	copied := make(map[string]int64, len(values))
+	for name, value := range values {
+		copied[name] = value
+	}
+
+	enumsMu.Lock()
+	defer enumsMu.Unlock()
+	registry[t] = copied
+}
+
+func enumParser(t reflect.Type) (ParserFunc, bool) {
+	enumsMu.RLock()
+	values, ok := enums[t]
+	enumsMu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+
+	return func(v string) (interface{}, error) {
+		i, ok := values[v]
+		if !ok {
+			if i, ok = enumNumber(values, v); !ok {
+				return nil, fmt.Errorf("unknown value %q, expected one of %s", v, strings.Join(enumNames(values), ", "))
+			}
+		}
+		return enumValue(t, i, v)
+	}, true
+}
+
+// enumNumber returns v as an integer if it is one of the registered values.
+func enumNumber(values map[string]int64, v string) (int64, bool) {
+	i, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	for _, value := range values {
+		if value == i {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func flagsParser(t reflect.Type) (ParserFunc, bool) {
+	enumsMu.RLock()
+	values, ok := flags[t]
+	enumsMu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+
+	return func(v string) (interface{}, error) {
+		var mask int64
+		for _, name := range strings.Split(v, ",") {
+			name = strings.TrimSpace(name)
+			i, ok := values[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown flag %q, expected any of %s", name, strings.Join(enumNames(values), ", "))
+			}
+			mask |= i
+		}
+		return enumValue(t, mask, v)
+	}, true
+}
+
+// enumValue converts the value i of the name v to the integer type t.
+func enumValue(t reflect.Type, i int64, v string) (interface{}, error) {
+	e := reflect.New(t).Elem()
+	if isUintKind(t.Kind()) {
+		if i < 0 || e.OverflowUint(uint64(i)) {
+			return nil, fmt.Errorf("value %d of %q overflows %s", i, v, t)
+		}
+		e.SetUint(uint64(i))
+		return e.Interface(), nil
+	}
+	if e.OverflowInt(i) {
+		return nil, fmt.Errorf("value %d of %q overflows %s", i, v, t)
+	}
+	e.SetInt(i)
+	return e.Interface(), nil
+}
+
+// enumNames returns the names of values ordered by value.
+func enumNames(values map[string]int64) []string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if values[names[i]] == values[names[j]] {
+			return names[i] < names[j]
+		}
+		return values[names[i]] < values[names[j]]
+	})
+	return names
+}
+
+func isIntKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
+
+func isUintKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
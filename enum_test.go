@@ -0,0 +1,218 @@
+package conf_test
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+)
+
+type Mode int
+
+const (
+	ModeSlow Mode = iota
+	ModeFast
+	ModeTurbo
+)
+
+func (m Mode) String() string {
+	return [...]string{"slow", "fast", "turbo"}[m]
+}
+
+type Priority uint8
+
+type Perm uint8
+
+const (
+	PermRead Perm = 1 << iota
+	PermWrite
+	PermExec
+)
+
+func init() {
+	conf.RegisterEnum(reflect.TypeOf(ModeSlow), map[string]int64{
+		"slow":  int64(ModeSlow),
+		"fast":  int64(ModeFast),
+		"turbo": int64(ModeTurbo),
+	})
+	conf.RegisterEnum(reflect.TypeOf(Priority(0)), map[string]int64{
+		"low":  1,
+		"high": 200,
+		"huge": 300,
+	})
+	conf.RegisterFlags(reflect.TypeOf(Perm(0)), map[string]int64{
+		"read":  int64(PermRead),
+		"write": int64(PermWrite),
+		"exec":  int64(PermExec),
+		"all":   int64(PermRead | PermWrite | PermExec),
+		"huge":  512,
+	})
+}
+
+func TestParsesEnumByName(t *testing.T) {
+	os.Setenv("MODE", "fast")
+	os.Setenv("MODES", "turbo,slow")
+	os.Setenv("PRIORITY", "high")
+	defer os.Clearenv()
+
+	type config struct {
+		Mode     Mode     `env:"MODE"`
+		ModePtr  *Mode    `env:"MODE"`
+		Modes    []Mode   `env:"MODES"`
+		Priority Priority `env:"PRIORITY"`
+	}
+
+	var cfg config
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, ModeFast, cfg.Mode)
+	assert.Equal(t, ModeFast, *cfg.ModePtr)
+	assert.Equal(t, []Mode{ModeTurbo, ModeSlow}, cfg.Modes)
+	assert.Equal(t, Priority(200), cfg.Priority)
+}
+
+func TestUnknownEnumName(t *testing.T) {
+	os.Setenv("MODE", "warp")
+	defer os.Clearenv()
+
+	type config struct {
+		Mode Mode `env:"MODE"`
+	}
+
+	var cfg config
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: parse error on field "Mode" of type "conf_test.Mode": unknown value "warp", expected one of slow, fast, turbo`)
+}
+
+func TestParsesEnumByNumber(t *testing.T) {
+	type config struct {
+		Mode  Mode   `env:"MODE"`
+		Modes []Mode `env:"MODES"`
+	}
+
+	var cfg config
+	assert.NoError(t, conf.Parse(&cfg, conf.NewMapProvider(map[string]string{
+		"MODE":  "2",
+		"MODES": "1,slow",
+	})))
+	assert.Equal(t, ModeTurbo, cfg.Mode)
+	assert.Equal(t, []Mode{ModeFast, ModeSlow}, cfg.Modes)
+}
+
+func TestUnknownEnumNumber(t *testing.T) {
+	type config struct {
+		Mode Mode `env:"MODE"`
+	}
+
+	var cfg config
+	err := conf.Parse(&cfg, conf.NewMapProvider(map[string]string{"MODE": "7"}))
+	assert.EqualError(t, err, `env: parse error on field "Mode" of type "conf_test.Mode": unknown value "7", expected one of slow, fast, turbo`)
+}
+
+func TestEnumDefault(t *testing.T) {
+	type config struct {
+		Mode     Mode     `env:"MODE" envDefault:"fast"`
+		ModePtr  *Mode    `env:"MODE_PTR" envDefault:"turbo"`
+		Modes    []Mode   `env:"MODES" envDefault:"turbo,slow"`
+		Number   Mode     `env:"NUMBER" envDefault:"2"`
+		Priority Priority `env:"PRIORITY" envDefault:"high"`
+	}
+
+	var cfg config
+	assert.NoError(t, conf.Parse(&cfg, conf.NewMapProvider(map[string]string{})))
+	assert.Equal(t, ModeFast, cfg.Mode)
+	assert.Equal(t, ModeTurbo, *cfg.ModePtr)
+	assert.Equal(t, []Mode{ModeTurbo, ModeSlow}, cfg.Modes)
+	assert.Equal(t, ModeTurbo, cfg.Number)
+	assert.Equal(t, Priority(200), cfg.Priority)
+
+	assert.NoError(t, conf.ResetToDefaults(&cfg))
+	assert.Equal(t, ModeFast, cfg.Mode)
+}
+
+func TestEnumDefaultOverridden(t *testing.T) {
+	type config struct {
+		Mode Mode `env:"MODE" envDefault:"fast"`
+	}
+
+	var cfg config
+	assert.NoError(t, conf.Parse(&cfg, conf.NewMapProvider(map[string]string{"MODE": "slow"})))
+	assert.Equal(t, ModeSlow, cfg.Mode)
+}
+
+func TestUnknownEnumDefault(t *testing.T) {
+	type config struct {
+		Mode Mode `env:"MODE" envDefault:"warp"`
+	}
+
+	var cfg config
+	err := conf.Parse(&cfg, conf.NewMapProvider(map[string]string{}))
+	assert.EqualError(t, err, `env: parse error on field "Mode" of type "conf_test.Mode": unknown value "warp", expected one of slow, fast, turbo`)
+}
+
+func TestEnumValueOverflow(t *testing.T) {
+	os.Setenv("PRIORITY", "huge")
+	defer os.Clearenv()
+
+	type config struct {
+		Priority Priority `env:"PRIORITY"`
+	}
+
+	var cfg config
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: parse error on field "Priority" of type "conf_test.Priority": value 300 of "huge" overflows conf_test.Priority`)
+}
+
+func TestRegisterEnumNonInteger(t *testing.T) {
+	assert.Panics(t, func() {
+		conf.RegisterEnum(reflect.TypeOf(""), map[string]int64{"a": 1})
+	})
+}
+
+func TestParsesFlagsByName(t *testing.T) {
+	os.Setenv("PERMS", "read, write")
+	os.Setenv("ADMIN_PERMS", "all,read")
+	os.Setenv("EXEC_PERMS", "exec")
+	defer os.Clearenv()
+
+	type config struct {
+		Perms      Perm  `env:"PERMS"`
+		AdminPerms *Perm `env:"ADMIN_PERMS"`
+		ExecPerms  Perm  `env:"EXEC_PERMS"`
+	}
+
+	var cfg config
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, PermRead|PermWrite, cfg.Perms)
+	assert.Equal(t, PermRead|PermWrite|PermExec, *cfg.AdminPerms)
+	assert.Equal(t, PermExec, cfg.ExecPerms)
+}
+
+func TestUnknownFlagName(t *testing.T) {
+	os.Setenv("PERMS", "read,delete")
+	defer os.Clearenv()
+
+	type config struct {
+		Perms Perm `env:"PERMS"`
+	}
+
+	var cfg config
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: parse error on field "Perms" of type "conf_test.Perm": unknown flag "delete", expected any of read, write, exec, all, huge`)
+}
+
+func TestFlagsValueOverflow(t *testing.T) {
+	os.Setenv("PERMS", "read,huge")
+	defer os.Clearenv()
+
+	type config struct {
+		Perms Perm `env:"PERMS"`
+	}
+
+	var cfg config
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: parse error on field "Perms" of type "conf_test.Perm": value 513 of "read,huge" overflows conf_test.Perm`)
+}
+
+func TestRegisterFlagsNonInteger(t *testing.T) {
+	assert.Panics(t, func() {
+		conf.RegisterFlags(reflect.TypeOf(""), map[string]int64{"a": 1})
+	})
+}
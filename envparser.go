@@ -0,0 +1,343 @@
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// valueParsers are the parsers which can be selected for a field with the
+// `envParser` tag. They replace the parser of the field's type, or of the
+// elements of a slice field.
+// nolint: gochecknoglobals
+var valueParsers = map[string]ParserFunc{
+	"iso8601": func(v string) (interface{}, error) {
+		d, err := parseISO8601Duration(v)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse ISO 8601 duration: %v", err)
+		}
+		return d, nil
+	},
+	"humantime": func(v string) (interface{}, error) {
+		d, err := parseHumanDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse duration: %v", err)
+		}
+		return d, nil
+	},
+	"url": func(v string) (interface{}, error) {
+		u, err := url.Parse(v)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse URL: %v", err)
+		}
+		// host:port also parses as a URL, with the host as the scheme
+		if u.Scheme == "" || !strings.HasPrefix(v[len(u.Scheme):], "://") {
+			return nil, fmt.Errorf("invalid URL %q, expected an absolute URL", v)
+		}
+		return *u, nil
+	},
+	"hostport": func(v string) (interface{}, error) {
+		host, port, err := net.SplitHostPort(v)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse host and port: %v", err)
+		}
+		if _, err := strconv.ParseUint(port, 10, 16); err != nil || host == "" {
+			return nil, fmt.Errorf("invalid host and port %q", v)
+		}
+		return v, nil
+	},
+	"path": func(v string) (interface{}, error) {
+		if v == "" || strings.Contains(v, "://") {
+			return nil, fmt.Errorf("invalid path %q", v)
+		}
+		return filepath.Clean(v), nil
+	},
+}
+
+// sliceParsers are the parsers which can be selected for a slice field with
+// the `envParser` tag. They split a value into the elements of the slice,
+// which are then parsed as usual.
+// nolint: gochecknoglobals
+var sliceParsers = map[string]func(value, separator string) ([]string, error){
+	"intrange":        splitIntRanges,
+	"glob":            expandGlobs,
+	"iso8601interval": splitRepeatingInterval,
+	"shlex":           splitShellWords,
+}
+
+// splitShellWords splits value into words the way a POSIX shell does, on
+// unquoted whitespace, e.g. `--flag "value with space" other` is split into
+// --flag, value with space and other. Single quotes keep everything up to
+// the closing quote, double quotes keep everything but the backslash escapes
+// of \, ", $ and `, and an unquoted backslash escapes any character. An
+// empty quoted word such as "" is kept.
+func splitShellWords(value, _ string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	runes := []rune(value)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+			continue
+		case r == '\\':
+			i++
+			if i == len(runes) {
+				return nil, errors.New("trailing backslash")
+			}
+			word.WriteRune(runes[i])
+		case r == '\'':
+			end := i + 1
+			for end < len(runes) && runes[end] != '\'' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, errors.New("unterminated single quote")
+			}
+			word.WriteString(string(runes[i+1 : end]))
+			i = end
+		case r == '"':
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) && strings.ContainsRune(`\"$`+"`", runes[i+1]) {
+					i++
+				}
+				word.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				return nil, errors.New("unterminated double quote")
+			}
+		default:
+			word.WriteRune(r)
+		}
+		inWord = true
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// expandGlobs expands a list of glob patterns into the paths which match
+// them, in the order of the patterns. A pattern which matches no paths adds
+// no elements, so use `envMinItems` to require a match.
+func expandGlobs(value, separator string) ([]string, error) {
+	var paths []string
+	for _, pattern := range strings.Split(value, separator) {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob pattern %q: %v", pattern, err)
+		}
+		paths = append(paths, matches...)
+	}
+	return paths, nil
+}
+
+// maxExpandedElements bounds the number of elements a value of the slice
+// parsers which expand it, `intrange` and `iso8601interval`, expands to, so a
+// range such as 1-2000000000 fails to parse rather than exhausting memory.
+const maxExpandedElements = 100000
+
+// splitIntRanges expands a list of integers and inclusive ranges, e.g.
+// "8000-8002,9000" is split into 8000, 8001, 8002 and 9000, into at most
+// maxExpandedElements integers.
+func splitIntRanges(value, separator string) ([]string, error) {
+	var parts []string
+	for _, token := range strings.Split(value, separator) {
+		token = strings.TrimSpace(token)
+		// a leading - is the sign of the first number rather than a range
+		i := -1
+		if len(token) > 1 {
+			if j := strings.Index(token[1:], "-"); j >= 0 {
+				i = j + 1
+			}
+		}
+		if i < 0 {
+			if _, err := strconv.ParseInt(token, 10, 64); err != nil {
+				return nil, fmt.Errorf("invalid integer %q", token)
+			}
+			if len(parts) == maxExpandedElements {
+				return nil, fmt.Errorf("value expands to more than %d integers", maxExpandedElements)
+			}
+			parts = append(parts, token)
+			continue
+		}
+
+		from, err := strconv.ParseInt(token[:i], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid range %q", token)
+		}
+		to, err := strconv.ParseInt(token[i+1:], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid range %q", token)
+		}
+		if from > to {
+			return nil, fmt.Errorf("descending range %q", token)
+		}
+		// subtracting as unsigned cannot overflow, as to is at least from
+		if n := uint64(to) - uint64(from); n >= maxExpandedElements || len(parts)+int(n)+1 > maxExpandedElements {
+			return nil, fmt.Errorf("range %q expands to more than %d integers", token, maxExpandedElements)
+		}
+		for n := from; ; n++ {
+			parts = append(parts, strconv.FormatInt(n, 10))
+			if n == to {
+				break
+			}
+		}
+	}
+	return parts, nil
+}
+
+// withEnvParser returns the parser for the field sf, which uses the parser
+// selected by its `envParser` tag, or the first of the parsers listed by its
+// `envTry` tag to succeed, for the type of the field. Durations are parsed in
+// the unit of its `envDurationUnit` tag when they have none.
+func (p *parser) withEnvParser(sf reflect.StructField) (*parser, error) {
+	typee := sf.Type
+	if typee.Kind() == reflect.Slice {
+		typee = typee.Elem()
+	}
+	if typee.Kind() == reflect.Ptr {
+		typee = typee.Elem()
+	}
+
+	var parserFunc ParserFunc
+	if names, ok := sf.Tag.Lookup("envTry"); ok {
+		if _, ok := sf.Tag.Lookup("envParser"); ok {
+			return nil, newError(`field "%s" has both envParser and envTry`, sf.Name)
+		}
+		var err error
+		if parserFunc, err = p.tryParsers(names, typee); err != nil {
+			return nil, newParseError(sf, err)
+		}
+	} else if name, ok := sf.Tag.Lookup("envParser"); ok {
+		_, isSliceParser := sliceParsers[name]
+		if parserFunc, ok = valueParsers[name]; !ok && !(isSliceParser && sf.Type.Kind() == reflect.Slice) {
+			return nil, newParseError(sf, fmt.Errorf("envParser %q not supported", name))
+		}
+	}
+	if unit, ok := sf.Tag.Lookup("envDurationUnit"); ok {
+		var err error
+		if parserFunc, err = p.withDurationUnit(parserFunc, typee, unit, sf); err != nil {
+			return nil, err
+		}
+	}
+	if parserFunc == nil {
+		return p, nil
+	}
+
+	funcMap := make(map[reflect.Type]ParserFunc, len(p.funcMap)+1)
+	for t, f := range p.funcMap {
+		funcMap[t] = f
+	}
+	funcMap[typee] = parserFunc
+
+	nested := *p
+	nested.funcMap = funcMap
+	return &nested, nil
+}
+
+// tryParsers returns a parser which tries the comma separated list of named
+// parsers in order and returns the first value of type typee parsed, or the
+// errors of all of them. A name is one of the parsers of `envParser` or the
+// name of a type with a parser, such as "netip.Addr" or a registered type.
+func (p *parser) tryParsers(names string, typee reflect.Type) (ParserFunc, error) {
+	var parsers []ParserFunc
+	var tried []string
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		parserFunc, ok := valueParsers[name]
+		if !ok {
+			parserFunc, ok = p.namedTypeParser(name)
+		}
+		if !ok {
+			return nil, fmt.Errorf("envTry parser %q not supported", name)
+		}
+		parsers = append(parsers, parserFunc)
+		tried = append(tried, name)
+	}
+
+	return func(v string) (interface{}, error) {
+		errs := make([]string, len(parsers))
+		for i, parserFunc := range parsers {
+			r, err := parserFunc(v)
+			if err == nil {
+				var value reflect.Value
+				if value, err = convertParsed(r, typee); err == nil {
+					return value.Interface(), nil
+				}
+			}
+			errs[i] = tried[i] + ": " + err.Error()
+		}
+		return nil, fmt.Errorf("no parser of envTry matched: %s", strings.Join(errs, "; "))
+	}, nil
+}
+
+// namedTypeParser returns the parser typeParser uses for the type named name,
+// as printed by reflect.Type, looking the name up in the custom parsers, the
+// registered types, the default parsers and then the registered enums, units
+// and flags, the order in which typeParser tries them.
+func (p *parser) namedTypeParser(name string) (ParserFunc, bool) {
+	if typee, ok := p.namedType(name); ok {
+		return p.typeParser(typee)
+	}
+	return nil, false
+}
+
+func (p *parser) namedType(name string) (reflect.Type, bool) {
+	if typee, ok := typeNamed(name, p.funcMap); ok {
+		return typee, true
+	}
+	registeredTypesMu.RLock()
+	typee, ok := typeNamed(name, registeredTypes)
+	registeredTypesMu.RUnlock()
+	if ok {
+		return typee, true
+	}
+	if typee, ok := typeNamed(name, defaultTypeParsers); ok {
+		return typee, true
+	}
+	if timeType.String() == name {
+		return timeType, true
+	}
+	enumsMu.RLock()
+	typee, ok = typeNamed(name, enums)
+	enumsMu.RUnlock()
+	if ok {
+		return typee, true
+	}
+	unitsMu.RLock()
+	typee, ok = typeNamed(name, units)
+	unitsMu.RUnlock()
+	if ok {
+		return typee, true
+	}
+	enumsMu.RLock()
+	defer enumsMu.RUnlock()
+	return typeNamed(name, flags)
+}
+
+// typeNamed returns the key of registry named name.
+func typeNamed[V any](name string, registry map[reflect.Type]V) (reflect.Type, bool) {
+	for typee := range registry {
+		if typee.String() == name {
+			return typee, true
+		}
+	}
+	return nil, false
+}
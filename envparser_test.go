@@ -0,0 +1,289 @@
+package conf_test
+
+import (
+	"net/netip"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntRangeParser(t *testing.T) {
+	type config struct {
+		Ports []int    `env:"PORTS" envParser:"intrange"`
+		CPUs  []uint8  `env:"CPUS" envParser:"intrange" envSeparator:";"`
+		Temps []int64  `env:"TEMPS" envParser:"intrange"`
+		Plain []string `env:"PORTS"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("PORTS", "8000-8002,9000")
+	os.Setenv("CPUS", "0-1;4")
+	os.Setenv("TEMPS", "-2-1, 5")
+
+	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, []int{8000, 8001, 8002, 9000}, cfg.Ports)
+	assert.Equal(t, []uint8{0, 1, 4}, cfg.CPUs)
+	assert.Equal(t, []int64{-2, -1, 0, 1, 5}, cfg.Temps)
+	assert.Equal(t, []string{"8000-8002", "9000"}, cfg.Plain)
+}
+
+func TestIntRangeParserSingletons(t *testing.T) {
+	type config struct {
+		Ports []int `env:"PORTS" envParser:"intrange"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("PORTS", "80,443,8080-8080")
+
+	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, []int{80, 443, 8080}, cfg.Ports)
+}
+
+func TestIntRangeParserInvalid(t *testing.T) {
+	tests := []struct {
+		value string
+		err   string
+	}{
+		{"8002-8000", `descending range "8002-8000"`},
+		{"80,http", `invalid integer "http"`},
+		{"80-", `invalid range "80-"`},
+		{"a-b", `invalid range "a-b"`},
+		{"80,,81", `invalid integer ""`},
+		{"1-2000000000", `range "1-2000000000" expands to more than 100000 integers`},
+		{"-9223372036854775808-9223372036854775807", `range "-9223372036854775808-9223372036854775807" expands to more than 100000 integers`},
+		{"1-60000,70000-130000", `range "70000-130000" expands to more than 100000 integers`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			type config struct {
+				Ports []int `env:"PORTS" envParser:"intrange"`
+			}
+			defer os.Clearenv()
+			os.Setenv("PORTS", tt.value)
+
+			cfg := config{}
+			assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: parse error on field "Ports" of type "[]int": `+tt.err)
+		})
+	}
+}
+
+func TestIntRangeParserLimit(t *testing.T) {
+	type config struct {
+		Ports []int `env:"PORTS" envParser:"intrange"`
+	}
+
+	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, conf.NewMapProvider(map[string]string{"PORTS": "1-100000"})))
+	assert.Len(t, cfg.Ports, 100000)
+
+	err := conf.Parse(&config{}, conf.NewMapProvider(map[string]string{"PORTS": "1-100000,5"}))
+	assert.EqualError(t, err, `env: parse error on field "Ports" of type "[]int": value expands to more than 100000 integers`)
+}
+
+func TestIntRangeParserOverflow(t *testing.T) {
+	type config struct {
+		CPUs []uint8 `env:"CPUS" envParser:"intrange"`
+	}
+	defer os.Clearenv()
+	os.Setenv("CPUS", "254-256")
+
+	cfg := config{}
+	assert.Error(t, conf.Parse(&cfg, conf.EnvProvider))
+}
+
+func TestGlobParser(t *testing.T) {
+	type config struct {
+		Configs []string `env:"CONFIGS" envParser:"glob"`
+	}
+	defer os.Clearenv()
+
+	dir := t.TempDir()
+	for _, name := range []string{"b.conf", "a.conf", "c.yaml", "notes.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0o600))
+	}
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "d.conf.d"), 0o700))
+
+	os.Setenv("CONFIGS", filepath.Join(dir, "*.conf")+", "+filepath.Join(dir, "*.yaml"))
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, []string{
+		filepath.Join(dir, "a.conf"),
+		filepath.Join(dir, "b.conf"),
+		filepath.Join(dir, "c.yaml"),
+	}, cfg.Configs)
+}
+
+func TestGlobParserNoMatches(t *testing.T) {
+	defer os.Clearenv()
+	os.Setenv("CONFIGS", filepath.Join(t.TempDir(), "*.conf"))
+
+	type optional struct {
+		Configs []string `env:"CONFIGS" envParser:"glob"`
+	}
+	o := optional{}
+	require.NoError(t, conf.Parse(&o, conf.EnvProvider))
+	assert.Empty(t, o.Configs)
+
+	type required struct {
+		Configs []string `env:"CONFIGS" envParser:"glob" envMinItems:"1"`
+	}
+	assert.EqualError(t, conf.Parse(&required{}, conf.EnvProvider), `env: field "Configs" should have at least 1 items, got 0`)
+}
+
+func TestGlobParserBadPattern(t *testing.T) {
+	type config struct {
+		Configs []string `env:"CONFIGS" envParser:"glob"`
+	}
+	defer os.Clearenv()
+	os.Setenv("CONFIGS", "/etc/app/[.conf")
+
+	cfg := config{}
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: parse error on field "Configs" of type "[]string": invalid glob pattern "/etc/app/[.conf": syntax error in pattern`)
+}
+
+func TestShellWordsParser(t *testing.T) {
+	type config struct {
+		Args    []string `env:"ARGS" envParser:"shlex"`
+		Escaped []string `env:"ESCAPED" envParser:"shlex"`
+		Ports   []int    `env:"PORTS" envParser:"shlex"`
+	}
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.NewMapProvider(map[string]string{
+		"ARGS":    `--flag "value with space" other  'single, quoted' ""`,
+		"ESCAPED": `a\ b "say \"hi\" \n" 'it\s' mixed"quo"'tes'`,
+		"PORTS":   "80\t443\n8080",
+	})))
+	assert.Equal(t, []string{"--flag", "value with space", "other", "single, quoted", ""}, cfg.Args)
+	assert.Equal(t, []string{"a b", `say "hi" \n`, `it\s`, "mixedquotes"}, cfg.Escaped)
+	assert.Equal(t, []int{80, 443, 8080}, cfg.Ports)
+}
+
+func TestShellWordsParserInvalid(t *testing.T) {
+	tests := []struct {
+		value string
+		err   string
+	}{
+		{`--flag "value`, "unterminated double quote"},
+		{`--flag 'value`, "unterminated single quote"},
+		{`"a\"`, "unterminated double quote"},
+		{`value\`, "trailing backslash"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			type config struct {
+				Args []string `env:"ARGS" envParser:"shlex"`
+			}
+
+			cfg := config{}
+			err := conf.Parse(&cfg, conf.NewMapProvider(map[string]string{"ARGS": tt.value}))
+			assert.EqualError(t, err, `env: parse error on field "Args" of type "[]string": `+tt.err)
+		})
+	}
+}
+
+func TestTryParsers(t *testing.T) {
+	type config struct {
+		URL      interface{}   `env:"URL" envTry:"url,hostport,path"`
+		HostPort interface{}   `env:"HOST_PORT" envTry:"url,hostport,path"`
+		Socket   interface{}   `env:"SOCKET" envTry:"url, hostport, path"`
+		Address  string        `env:"HOST_PORT" envTry:"url,hostport"`
+		Timeouts []interface{} `env:"TIMEOUTS" envTry:"iso8601,url"`
+		Ptr      *url.URL      `env:"URL" envTry:"hostport,url"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("URL", "https://api.example.com/v1")
+	os.Setenv("HOST_PORT", "localhost:8080")
+	os.Setenv("SOCKET", "/var/run/app//app.sock")
+	os.Setenv("TIMEOUTS", "PT1M,unix:///tmp/app.sock")
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, url.URL{Scheme: "https", Host: "api.example.com", Path: "/v1"}, cfg.URL)
+	assert.Equal(t, "localhost:8080", cfg.HostPort)
+	assert.Equal(t, "/var/run/app/app.sock", cfg.Socket)
+	assert.Equal(t, "localhost:8080", cfg.Address)
+	assert.Equal(t, []interface{}{time.Minute, url.URL{Scheme: "unix", Path: "/tmp/app.sock"}}, cfg.Timeouts)
+	assert.Equal(t, "api.example.com", cfg.Ptr.Host)
+}
+
+func TestTryParsersNoMatch(t *testing.T) {
+	type config struct {
+		Endpoint string `env:"ENDPOINT" envTry:"url,hostport"`
+	}
+	defer os.Clearenv()
+	os.Setenv("ENDPOINT", "localhost")
+
+	cfg := config{}
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: parse error on field "Endpoint" of type "string": no parser of envTry matched: `+
+		`url: invalid URL "localhost", expected an absolute URL; hostport: unable to parse host and port: address localhost: missing port in address`)
+
+	os.Setenv("ENDPOINT", "https://api.example.com")
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: parse error on field "Endpoint" of type "string": no parser of envTry matched: `+
+		`url: parser returned url.URL, expected string; hostport: invalid host and port "https://api.example.com"`)
+}
+
+func TestTryParsersInvalid(t *testing.T) {
+	defer os.Clearenv()
+	os.Setenv("ENDPOINT", "localhost:8080")
+
+	type unsupported struct {
+		Endpoint string `env:"ENDPOINT" envTry:"hostport,socket"`
+	}
+	assert.EqualError(t, conf.Parse(&unsupported{}, conf.EnvProvider), `env: parse error on field "Endpoint" of type "string": envTry parser "socket" not supported`)
+
+	type both struct {
+		Endpoint string `env:"ENDPOINT" envTry:"hostport" envParser:"hostport"`
+	}
+	assert.EqualError(t, conf.Parse(&both{}, conf.EnvProvider), `env: field "Endpoint" has both envParser and envTry`)
+}
+
+func TestTryParsersTypes(t *testing.T) {
+	type config struct {
+		Origin interface{} `env:"ORIGIN" envTry:"conf_test.coordinate,netip.Addr,hostport"`
+		Peer   interface{} `env:"PEER" envTry:"conf_test.coordinate,netip.Addr,hostport"`
+		Host   interface{} `env:"HOST" envTry:"conf_test.coordinate,netip.Addr,hostport"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("ORIGIN", "51.5:-0.12")
+	os.Setenv("PEER", "10.0.0.1")
+	os.Setenv("HOST", "localhost:8080")
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, coordinate{Lat: 51.5, Lng: -0.12}, cfg.Origin)
+	assert.Equal(t, netip.MustParseAddr("10.0.0.1"), cfg.Peer)
+	assert.Equal(t, "localhost:8080", cfg.Host)
+
+	// custom parsers take precedence over the default parser of the type
+	loopback := func(v string) (interface{}, error) {
+		return netip.MustParseAddr("127.0.0.1"), nil
+	}
+	cfg = config{}
+	funcs := map[reflect.Type]conf.ParserFunc{reflect.TypeOf(netip.Addr{}): loopback}
+	require.NoError(t, conf.ParseWithFuncs(&cfg, funcs, conf.EnvProvider))
+	assert.Equal(t, netip.MustParseAddr("127.0.0.1"), cfg.Peer)
+}
+
+func TestUnsupportedEnvParser(t *testing.T) {
+	type config struct {
+		Ports []int `env:"PORTS" envParser:"unknown"`
+	}
+	defer os.Clearenv()
+	os.Setenv("PORTS", "80")
+
+	cfg := config{}
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: parse error on field "Ports" of type "[]int": envParser "unknown" not supported`)
+}
@@ -0,0 +1,119 @@
+// Package etcdprovider provides a conf provider for keys stored in etcd.
+//
+// It is a separate module so that the etcd client is only required by
+// programs which read their config from etcd. `env` tags are resolved as keys
+// under a prefix, with the same tags and options as conf.EnvProvider:
+//
+//	type config struct {
+//		Host string `env:"db/host,required"`
+//	}
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+//	defer cancel()
+//	var cfg config
+//	err := conf.Parse(&cfg, etcdprovider.New(ctx, client, "/services/api/"))
+package etcdprovider
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/steinfletcher/conf"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// Provider resolves fields from the keys under a prefix in etcd.
+type Provider struct {
+	ctx    context.Context
+	kv     clientv3.KV
+	prefix string
+
+	once   sync.Once
+	values conf.Provider
+	err    error
+}
+
+// New returns a provider which reads the keys under prefix from kv, such as a
+// *clientv3.Client or a namespaced clientv3.KV, with ctx, which bounds the
+// request. The key of a field is appended to prefix and missing keys are
+// treated as not set.
+//
+// All of the keys under prefix are read with a single request when the first
+// field is resolved, so a Parse makes one round trip to etcd. The values are
+// kept by the provider, so create a provider for each Parse to read updates.
+// An error reading from etcd is returned for the field being resolved.
+func New(ctx context.Context, kv clientv3.KV, prefix string) *Provider {
+	return &Provider{ctx: ctx, kv: kv, prefix: prefix}
+}
+
+// Provide implements conf.Provider.
+func (p *Provider) Provide(field reflect.StructField) (string, error) {
+	result, err := p.ProvideResult(field)
+	return result.Value, err
+}
+
+// ProvideResult implements conf.ResultProvider.
+func (p *Provider) ProvideResult(field reflect.StructField) (conf.Result, error) {
+	return p.ProvideContext(context.Background(), field)
+}
+
+// ProvideContext implements conf.ContextProvider. The request to etcd is
+// aborted when either ctx, the context of the parse, or the context given to
+// New is done.
+func (p *Provider) ProvideContext(ctx context.Context, field reflect.StructField) (conf.Result, error) {
+	p.once.Do(func() { p.load(ctx) })
+	if p.err != nil {
+		return conf.Result{}, fmt.Errorf(`env: unable to read field "%s" from etcd: %v`, field.Name, p.err)
+	}
+	result, err := p.values.(conf.ResultProvider).ProvideResult(field)
+	if result.Source != "" {
+		result.Source = "etcd"
+	}
+	return result, err
+}
+
+func (p *Provider) load(ctx context.Context) {
+	ctx, cancel := withContext(ctx, p.ctx)
+	defer cancel()
+	resp, err := p.kv.Get(ctx, p.prefix, clientv3.WithPrefix())
+	if err != nil {
+		if p.ctx.Err() != nil {
+			err = p.ctx.Err()
+		}
+		p.err = err
+		return
+	}
+	values := make(map[string]string, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		values[strings.TrimPrefix(string(kv.Key), p.prefix)] = string(kv.Value)
+	}
+	p.values = conf.NewMapProvider(values)
+}
+
+// withContext returns a context derived from ctx which is also canceled when
+// other is done.
+func withContext(ctx, other context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	if other.Done() == nil {
+		return ctx, cancel
+	}
+	if other.Err() != nil {
+		cancel()
+		return ctx, cancel
+	}
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-other.Done():
+			cancel()
+		case <-stop:
+		}
+	}()
+	return ctx, func() {
+		close(stop)
+		cancel()
+	}
+}
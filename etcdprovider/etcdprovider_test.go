@@ -0,0 +1,144 @@
+package etcdprovider_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steinfletcher/conf"
+	"github.com/steinfletcher/conf/etcdprovider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// fakeKV serves Get requests for a prefix from a map.
+type fakeKV struct {
+	clientv3.KV
+	values map[string]string
+	err    error
+	gets   int
+}
+
+func (f *fakeKV) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	f.gets++
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	resp := &clientv3.GetResponse{}
+	for k, v := range f.values {
+		if strings.HasPrefix(k, key) {
+			resp.Kvs = append(resp.Kvs, &mvccpb.KeyValue{Key: []byte(k), Value: []byte(v)})
+		}
+	}
+	return resp, nil
+}
+
+func TestProvider(t *testing.T) {
+	type config struct {
+		Host    string        `env:"db/host,required"`
+		Port    int           `env:"db/port"`
+		Timeout time.Duration `env:"db/timeout" envDefault:"5s"`
+		Peers   []string      `env:"peers"`
+		Missing string        `env:"missing"`
+		Other   string        `env:"other"`
+	}
+	kv := &fakeKV{values: map[string]string{
+		"/services/api/db/host": "db.local",
+		"/services/api/db/port": "5432",
+		"/services/api/peers":   "a,b",
+		"/services/web/other":   "web",
+	}}
+
+	var cfg config
+	require.NoError(t, conf.Parse(&cfg, etcdprovider.New(context.Background(), kv, "/services/api/")))
+	assert.Equal(t, config{Host: "db.local", Port: 5432, Timeout: 5 * time.Second, Peers: []string{"a", "b"}}, cfg)
+	assert.Equal(t, 1, kv.gets, "keys should be read with a single request")
+}
+
+func TestProviderSource(t *testing.T) {
+	type config struct {
+		Host string `env:"host"`
+		Port int    `env:"port" envDefault:"80"`
+	}
+	kv := &fakeKV{values: map[string]string{"app/host": "localhost"}}
+
+	var events []conf.AuditEvent
+	var cfg config
+	err := conf.ParseWithOptions(&cfg, []conf.Option{conf.WithAuditSink(func(e conf.AuditEvent) {
+		events = append(events, e)
+	})}, etcdprovider.New(context.Background(), kv, "app/"))
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "etcd", events[0].Source)
+	assert.True(t, events[1].Default)
+}
+
+func TestProviderRequired(t *testing.T) {
+	type config struct {
+		Host string `env:"host,required"`
+	}
+	kv := &fakeKV{values: map[string]string{}}
+
+	var cfg config
+	err := conf.Parse(&cfg, etcdprovider.New(context.Background(), kv, "app/"))
+	assert.EqualError(t, err, `env: required environment variable "host" is not set`)
+}
+
+func TestProviderClientError(t *testing.T) {
+	type config struct {
+		Host string `env:"host"`
+		Port int    `env:"port"`
+	}
+	kv := &fakeKV{err: errors.New("etcdserver: request timed out")}
+
+	var cfg config
+	err := conf.Parse(&cfg, etcdprovider.New(context.Background(), kv, "app/"))
+	assert.EqualError(t, err, `env: unable to read field "Host" from etcd: etcdserver: request timed out`)
+	assert.Equal(t, 1, kv.gets)
+}
+
+func TestProviderContext(t *testing.T) {
+	type config struct {
+		Host string `env:"host"`
+	}
+	kv := &fakeKV{values: map[string]string{"app/host": "localhost"}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var cfg config
+	err := conf.Parse(&cfg, etcdprovider.New(ctx, kv, "app/"))
+	assert.EqualError(t, err, `env: unable to read field "Host" from etcd: context canceled`)
+}
+
+// blockingKV blocks Get requests until their context is done.
+type blockingKV struct {
+	clientv3.KV
+}
+
+func (blockingKV) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestProviderParseContext(t *testing.T) {
+	type config struct {
+		Host string `env:"host"`
+	}
+
+	var cfg config
+	err := conf.ParseWithTimeout(10*time.Millisecond, &cfg, etcdprovider.New(context.Background(), blockingKV{}, "app/"))
+	assert.EqualError(t, err, `env: unable to resolve field "Host": context deadline exceeded`)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = conf.ParseWithTimeout(time.Minute, &cfg, etcdprovider.New(ctx, blockingKV{}, "app/"))
+	assert.EqualError(t, err, `env: unable to read field "Host" from etcd: context deadline exceeded`)
+}
@@ -0,0 +1,71 @@
+package conf
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultFetchTimeout  = 10 * time.Second
+	defaultFetchMaxBytes = 1 << 20
+)
+
+// fetchValue returns the body of the document at rawURL, the value of key,
+// for the `fetch` tag option. The request is bounded by the `envFetchTimeout`
+// tag of field, 10s by default, and bodies larger than its `envFetchMaxBytes`,
+// 1MiB by default, are an error, and it is aborted when ctx is done. The URL
+// may hold credentials, so errors never include it.
+func fetchValue(ctx context.Context, key, rawURL string, field reflect.StructField) (string, error) {
+	timeout := defaultFetchTimeout
+	if tag, ok := field.Tag.Lookup("envFetchTimeout"); ok {
+		d, err := time.ParseDuration(tag)
+		if err != nil || d <= 0 {
+			return "", newError(`field "%s" has invalid envFetchTimeout %q`, field.Name, tag)
+		}
+		timeout = d
+	}
+	maxBytes := int64(defaultFetchMaxBytes)
+	if tag, ok := field.Tag.Lookup("envFetchMaxBytes"); ok {
+		n, err := strconv.ParseInt(tag, 10, 64)
+		if err != nil || n <= 0 {
+			return "", newError(`field "%s" has invalid envFetchMaxBytes %q`, field.Name, tag)
+		}
+		maxBytes = n
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", newError(`unable to fetch environment variable %q: expected an http or https URL`, key)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", newError(`unable to fetch environment variable %q: expected an http or https URL`, key)
+	}
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return "", newError(`unable to fetch environment variable %q: %v`, key, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", newError(`unable to fetch environment variable %q: unexpected status %q`, key, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return "", newError(`unable to fetch environment variable %q: %v`, key, err)
+	}
+	if int64(len(body)) > maxBytes {
+		return "", newError(`unable to fetch environment variable %q: response is larger than %d bytes`, key, maxBytes)
+	}
+	return string(body), nil
+}
@@ -0,0 +1,153 @@
+package conf_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type remoteConfig struct {
+	Endpoint string        `json:"endpoint"`
+	Timeout  time.Duration `json:"timeout"`
+}
+
+func TestFetch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/config.json":
+			fmt.Fprint(w, `{"endpoint": "https://api.example.com", "timeout": "5s"}`)
+		case "/motd.txt":
+			fmt.Fprint(w, "hello")
+		}
+	}))
+	defer srv.Close()
+
+	type config struct {
+		Remote    remoteConfig  `env:"CONFIG_URL,fetch"`
+		RemotePtr *remoteConfig `env:"CONFIG_URL,fetch"`
+		MOTD      string        `env:"MOTD_URL,fetch"`
+		Unset     remoteConfig  `env:"UNSET_URL,fetch"`
+	}
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.NewMapProvider(map[string]string{
+		"CONFIG_URL": srv.URL + "/config.json",
+		"MOTD_URL":   srv.URL + "/motd.txt",
+	})))
+	want := remoteConfig{Endpoint: "https://api.example.com", Timeout: 5 * time.Second}
+	assert.Equal(t, want, cfg.Remote)
+	assert.Equal(t, &want, cfg.RemotePtr)
+	assert.Equal(t, "hello", cfg.MOTD)
+	assert.Equal(t, remoteConfig{}, cfg.Unset)
+}
+
+func TestFetchErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+		case "/large":
+			fmt.Fprint(w, strings.Repeat("x", 11))
+		case "/slow":
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+	defer srv.Close()
+
+	for name, tc := range map[string]struct {
+		url  string
+		err  string
+		cfg  interface{}
+		part bool
+	}{
+		"status": {
+			url: srv.URL + "/missing",
+			cfg: &struct {
+				Remote remoteConfig `env:"CONFIG_URL,fetch"`
+			}{},
+			err: `env: unable to fetch environment variable "CONFIG_URL": unexpected status "404 Not Found"`,
+		},
+		"too large": {
+			url: srv.URL + "/large",
+			cfg: &struct {
+				Remote string `env:"CONFIG_URL,fetch" envFetchMaxBytes:"10"`
+			}{},
+			err: `env: unable to fetch environment variable "CONFIG_URL": response is larger than 10 bytes`,
+		},
+		"timeout": {
+			url: srv.URL + "/slow",
+			cfg: &struct {
+				Remote string `env:"CONFIG_URL,fetch" envFetchTimeout:"10ms"`
+			}{},
+			err:  `env: unable to fetch environment variable "CONFIG_URL": `,
+			part: true,
+		},
+		"scheme": {
+			url: "file:///etc/passwd",
+			cfg: &struct {
+				Remote string `env:"CONFIG_URL,fetch"`
+			}{},
+			err: `env: unable to fetch environment variable "CONFIG_URL": expected an http or https URL`,
+		},
+		"invalid timeout": {
+			url: srv.URL,
+			cfg: &struct {
+				Remote string `env:"CONFIG_URL,fetch" envFetchTimeout:"soon"`
+			}{},
+			err: `env: field "Remote" has invalid envFetchTimeout "soon"`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := conf.Parse(tc.cfg, conf.NewMapProvider(map[string]string{"CONFIG_URL": tc.url}))
+			require.Error(t, err)
+			if tc.part {
+				assert.True(t, strings.HasPrefix(err.Error(), tc.err), err.Error())
+				assert.NotContains(t, err.Error(), srv.URL)
+				return
+			}
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestFetchChain(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `{"endpoint": "https://api.example.com", "timeout": "5s"}`)
+	}))
+	defer srv.Close()
+
+	type config struct {
+		Remote remoteConfig `env:"CONFIG_URL,fetch"`
+	}
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.NewChainProvider(
+		conf.NewMapProvider(map[string]string{}),
+		conf.NewMapProvider(map[string]string{"CONFIG_URL": srv.URL + "/config.json"}),
+		conf.NewMapProvider(map[string]string{"CONFIG_URL": "https://unused.example.com"}),
+	)))
+	assert.Equal(t, remoteConfig{Endpoint: "https://api.example.com", Timeout: 5 * time.Second}, cfg.Remote)
+	assert.Equal(t, 1, requests)
+}
+
+func TestFetchChainError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	type config struct {
+		Remote string `env:"CONFIG_URL,fetch"`
+	}
+
+	cfg := config{}
+	err := conf.Parse(&cfg, conf.NewChainProvider(conf.NewMapProvider(map[string]string{"CONFIG_URL": srv.URL})))
+	assert.EqualError(t, err, `env: unable to fetch environment variable "CONFIG_URL": unexpected status "404 Not Found"`)
+}
@@ -0,0 +1,231 @@
+package conf
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// OutputFile is an io.Writer parsed from "stdout", "stderr", "discard" or the
+// path of a file, which is created if needed and opened for appending.
+//
+// Parsing opens the file, so the caller owns it from then on and should call
+// Close when done with it. Close does nothing for the special names, so
+// os.Stdout and os.Stderr are never closed. A file opened for a value which a
+// later provider replaces, or by a Parse which fails, is closed by Parse.
+type OutputFile struct {
+	io.Writer
+	// Name is the value the writer was parsed from.
+	Name   string
+	closer io.Closer
+}
+
+// Close closes the file opened for f, if any.
+func (f OutputFile) Close() error {
+	if f.closer == nil {
+		return nil
+	}
+	return f.closer.Close()
+}
+
+func (f OutputFile) String() string {
+	return f.Name
+}
+
+// InputFile is an io.Reader parsed from "stdin" or the path of a file, which
+// is opened for reading. As with OutputFile, the caller should call Close when
+// done with it, which does nothing for stdin.
+type InputFile struct {
+	io.Reader
+	// Name is the value the reader was parsed from.
+	Name   string
+	closer io.Closer
+}
+
+// Close closes the file opened for f, if any.
+func (f InputFile) Close() error {
+	if f.closer == nil {
+		return nil
+	}
+	return f.closer.Close()
+}
+
+func (f InputFile) String() string {
+	return f.Name
+}
+
+func parseOutputFile(v string) (OutputFile, error) {
+	switch v {
+	case "stdout":
+		return OutputFile{Writer: os.Stdout, Name: v}, nil
+	case "stderr":
+		return OutputFile{Writer: os.Stderr, Name: v}, nil
+	case "discard":
+		return OutputFile{Writer: io.Discard, Name: v}, nil
+	}
+	f, err := os.OpenFile(v, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return OutputFile{}, err
+	}
+	return OutputFile{Writer: f, Name: v, closer: f}, nil
+}
+
+func parseInputFile(v string) (InputFile, error) {
+	if v == "stdin" {
+		return InputFile{Reader: os.Stdin, Name: v}, nil
+	}
+	f, err := os.Open(v)
+	if err != nil {
+		return InputFile{}, err
+	}
+	return InputFile{Reader: f, Name: v, closer: f}, nil
+}
+
+// nolint: gochecknoglobals
+var (
+	outputFileType = reflect.TypeOf(OutputFile{})
+	inputFileType  = reflect.TypeOf(InputFile{})
+)
+
+// openedFiles records the files opened for OutputFile and InputFile values
+// during a single Parse, so those which do not end up in the struct are
+// closed rather than leaked.
+type openedFiles map[io.Closer]bool
+
+// track returns parserFunc, the parser for typee, recording the files it
+// opens when typee is OutputFile or InputFile.
+func (o openedFiles) track(typee reflect.Type, parserFunc ParserFunc) ParserFunc {
+	if o == nil || (typee != outputFileType && typee != inputFileType) {
+		return parserFunc
+	}
+	return func(v string) (interface{}, error) {
+		val, err := parserFunc(v)
+		if closer := fileCloser(reflect.ValueOf(val)); closer != nil {
+			o[closer] = true
+		}
+		return val, err
+	}
+}
+
+// held returns the files opened by this Parse which field holds.
+func (o openedFiles) held(field reflect.Value) []io.Closer {
+	if len(o) == 0 {
+		return nil
+	}
+	var held []io.Closer
+	for _, closer := range heldFiles(field) {
+		if o[closer] {
+			held = append(held, closer)
+		}
+	}
+	return held
+}
+
+// closeReplaced closes the files of previous, held by field before it was
+// set, which it no longer holds.
+func (o openedFiles) closeReplaced(previous []io.Closer, field reflect.Value) {
+	if len(previous) == 0 {
+		return
+	}
+	current := heldFiles(field)
+	for _, closer := range previous {
+		if !containsCloser(current, closer) {
+			_ = closer.Close()
+			delete(o, closer)
+		}
+	}
+}
+
+// closeAll closes every file opened by this Parse, when it fails.
+func (o openedFiles) closeAll() {
+	for closer := range o {
+		_ = closer.Close()
+		delete(o, closer)
+	}
+}
+
+// heldFiles returns the closers of the OutputFile and InputFile values held by
+// field, directly or through pointers, slices and maps.
+func heldFiles(field reflect.Value) []io.Closer {
+	if closer := fileCloser(field); closer != nil {
+		return []io.Closer{closer}
+	}
+	var held []io.Closer
+	switch field.Kind() {
+	case reflect.Ptr:
+		if !field.IsNil() {
+			held = heldFiles(field.Elem())
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < field.Len(); i++ {
+			held = append(held, heldFiles(field.Index(i))...)
+		}
+	case reflect.Map:
+		iter := field.MapRange()
+		for iter.Next() {
+			held = append(held, heldFiles(iter.Value())...)
+		}
+	}
+	return held
+}
+
+// fileCloser returns the closer of v when it is an OutputFile or an InputFile
+// with an opened file.
+func fileCloser(v reflect.Value) io.Closer {
+	if !v.IsValid() {
+		return nil
+	}
+	switch v.Type() {
+	case outputFileType:
+		return v.Interface().(OutputFile).closer
+	case inputFileType:
+		return v.Interface().(InputFile).closer
+	}
+	return nil
+}
+
+func containsCloser(closers []io.Closer, closer io.Closer) bool {
+	for _, c := range closers {
+		if c == closer {
+			return true
+		}
+	}
+	return false
+}
+
+// readValueFile returns the content of the file at path for a key with the
+// `file` tag option. A single trailing newline, which most tools write after a
+// secret, is trimmed unless the field is tagged with `envKeepNewline:"true"`.
+// The path is the value of the key, so errors never include it.
+func readValueFile(key, path string, field reflect.StructField) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", newError(`unable to read file of environment variable %q: %s`, key, fileErrorKind(err))
+	}
+	value := string(content)
+	if strings.ToLower(field.Tag.Get("envKeepNewline")) != "true" {
+		if value = strings.TrimSuffix(value, "\n"); len(value) < len(content) {
+			value = strings.TrimSuffix(value, "\r")
+		}
+	}
+	return value, nil
+}
+
+// fileErrorKind describes err, returned by reading a file, without the path
+// held by an *fs.PathError.
+func fileErrorKind(err error) string {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return "file not found"
+	case errors.Is(err, fs.ErrPermission):
+		return "permission denied"
+	}
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return pathErr.Err.Error()
+	}
+	return "unable to read file"
+}
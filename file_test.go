@@ -0,0 +1,148 @@
+package conf_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutputFileSpecialNames(t *testing.T) {
+	type config struct {
+		Stdout  conf.OutputFile  `env:"STDOUT"`
+		Stderr  *conf.OutputFile `env:"STDERR"`
+		Discard conf.OutputFile  `env:"DISCARD"`
+		Stdin   conf.InputFile   `env:"STDIN"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("STDOUT", "stdout")
+	os.Setenv("STDERR", "stderr")
+	os.Setenv("DISCARD", "discard")
+	os.Setenv("STDIN", "stdin")
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, os.Stdout, cfg.Stdout.Writer)
+	require.NotNil(t, cfg.Stderr)
+	assert.Equal(t, os.Stderr, cfg.Stderr.Writer)
+	assert.Equal(t, io.Discard, cfg.Discard.Writer)
+	assert.Equal(t, os.Stdin, cfg.Stdin.Reader)
+	assert.Equal(t, "stdout", cfg.Stdout.String())
+
+	assert.NoError(t, cfg.Stdout.Close())
+	assert.NoError(t, cfg.Stdin.Close())
+	_, err := os.Stdout.Stat()
+	assert.NoError(t, err, "stdout should not be closed")
+}
+
+func TestOutputFilePath(t *testing.T) {
+	type config struct {
+		Log   conf.OutputFile `env:"LOG"`
+		Input conf.InputFile  `env:"INPUT"`
+	}
+	defer os.Clearenv()
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	require.NoError(t, os.WriteFile(path, []byte("first\n"), 0o600))
+	os.Setenv("LOG", path)
+	os.Setenv("INPUT", path)
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	_, err := io.WriteString(cfg.Log, "second\n")
+	assert.NoError(t, err)
+	assert.NoError(t, cfg.Log.Close())
+
+	content, err := io.ReadAll(cfg.Input)
+	assert.NoError(t, err)
+	assert.Equal(t, "first\nsecond\n", string(content))
+	assert.NoError(t, cfg.Input.Close())
+	assert.Equal(t, path, cfg.Input.Name)
+}
+
+func TestOutputFileError(t *testing.T) {
+	type config struct {
+		Log conf.OutputFile `env:"LOG"`
+	}
+	defer os.Clearenv()
+
+	path := filepath.Join(t.TempDir(), "missing", "app.log")
+	os.Setenv("LOG", path)
+
+	cfg := config{}
+	err := conf.Parse(&cfg, conf.EnvProvider)
+	assert.EqualError(t, err, `env: parse error on field "Log" of type "conf.OutputFile": unable to open output file: open `+path+`: no such file or directory`)
+}
+
+func TestInputFileError(t *testing.T) {
+	type config struct {
+		Input conf.InputFile `env:"INPUT"`
+	}
+	defer os.Clearenv()
+
+	path := filepath.Join(t.TempDir(), "missing.txt")
+	os.Setenv("INPUT", path)
+
+	cfg := config{}
+	err := conf.Parse(&cfg, conf.EnvProvider)
+	assert.EqualError(t, err, `env: parse error on field "Input" of type "conf.InputFile": unable to open input file: open `+path+`: no such file or directory`)
+}
+
+func TestOutputFileReplacedIsClosed(t *testing.T) {
+	type config struct {
+		Log conf.OutputFile `env:"LOG"`
+	}
+	defer os.Clearenv()
+
+	dir := t.TempDir()
+	os.Setenv("LOG", filepath.Join(dir, "first.log"))
+	dotenv, err := conf.NewDotenvProvider(strings.NewReader("LOG=" + filepath.Join(dir, "second.log")))
+	require.NoError(t, err)
+
+	before := openFiles(t)
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.EnvProvider, dotenv))
+	assert.Equal(t, filepath.Join(dir, "second.log"), cfg.Log.Name)
+	assert.Equal(t, before+1, openFiles(t))
+	assert.NoError(t, cfg.Log.Close())
+	assert.Equal(t, before, openFiles(t))
+}
+
+func TestOutputFileClosedOnError(t *testing.T) {
+	type config struct {
+		Log   conf.OutputFile   `env:"LOG"`
+		Input *conf.InputFile   `env:"INPUT"`
+		Logs  []conf.OutputFile `env:"LOGS"`
+		Port  int               `env:"PORT"`
+	}
+	defer os.Clearenv()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	require.NoError(t, os.WriteFile(path, nil, 0o600))
+	os.Setenv("LOG", path)
+	os.Setenv("INPUT", path)
+	os.Setenv("LOGS", path+","+filepath.Join(dir, "other.log"))
+	os.Setenv("PORT", "http")
+
+	before := openFiles(t)
+	cfg := config{}
+	assert.Error(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, before, openFiles(t))
+}
+
+// openFiles returns the number of file descriptors the process has open.
+func openFiles(t *testing.T) int {
+	t.Helper()
+	fds, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Skip("open file descriptors are not listed in /proc/self/fd")
+	}
+	return len(fds)
+}
@@ -0,0 +1,62 @@
+// Package glob provides a conf parser for glob patterns, validated when the
+// config is parsed rather than when they are first matched.
+//
+// Patterns use the syntax of path/filepath.Match:
+//
+//	pattern   matches
+//	*         any sequence of characters except the path separator
+//	?         any single character except the path separator
+//	[abc]     one of the characters, [^abc] any other character
+//	[a-z]     a character in the range
+//	\c        the character c
+//
+// Register the parsers with conf.ParseWithFuncs:
+//
+//	type config struct {
+//		Include []glob.Pattern `env:"INCLUDE" envDefault:"*.go,*.md"`
+//	}
+//
+//	var cfg config
+//	err := conf.ParseWithFuncs(&cfg, glob.Parsers(), conf.EnvProvider)
+package glob
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+
+	"github.com/steinfletcher/conf"
+)
+
+// Pattern is a validated glob pattern.
+type Pattern struct {
+	pattern string
+}
+
+// Parsers returns the custom parsers for Pattern fields, including slices of
+// Pattern.
+func Parsers() map[reflect.Type]conf.ParserFunc {
+	return map[reflect.Type]conf.ParserFunc{
+		reflect.TypeOf(Pattern{}): Parse,
+	}
+}
+
+// Parse parses v as a glob pattern, failing if it is malformed.
+func Parse(v string) (interface{}, error) {
+	if _, err := filepath.Match(v, ""); err != nil {
+		return nil, fmt.Errorf("invalid glob pattern %q: %v", v, err)
+	}
+	return Pattern{pattern: v}, nil
+}
+
+// Match reports whether name matches the pattern.
+func (p Pattern) Match(name string) bool {
+	// the pattern was validated when it was parsed, so Match cannot fail
+	ok, _ := filepath.Match(p.pattern, name)
+	return ok
+}
+
+// String returns the pattern.
+func (p Pattern) String() string {
+	return p.pattern
+}
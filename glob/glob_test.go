@@ -0,0 +1,48 @@
+package glob_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/steinfletcher/conf"
+	"github.com/steinfletcher/conf/glob"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsesPatterns(t *testing.T) {
+	os.Setenv("INCLUDE", "*.go,docs/*.md,file?.txt")
+	os.Setenv("EXCLUDE", "[a-c]*")
+	defer os.Clearenv()
+
+	type config struct {
+		Include []glob.Pattern `env:"INCLUDE"`
+		Exclude glob.Pattern   `env:"EXCLUDE"`
+		Ptr     *glob.Pattern  `env:"EXCLUDE"`
+	}
+
+	var cfg config
+	require.NoError(t, conf.ParseWithFuncs(&cfg, glob.Parsers(), conf.EnvProvider))
+	require.Len(t, cfg.Include, 3)
+	assert.True(t, cfg.Include[0].Match("conf.go"))
+	assert.False(t, cfg.Include[0].Match("README.md"))
+	assert.True(t, cfg.Include[1].Match("docs/usage.md"))
+	assert.False(t, cfg.Include[1].Match("docs/api/usage.md"))
+	assert.True(t, cfg.Include[2].Match("file1.txt"))
+	assert.True(t, cfg.Exclude.Match("build"))
+	assert.False(t, cfg.Exclude.Match("dist"))
+	assert.Equal(t, "[a-c]*", cfg.Ptr.String())
+}
+
+func TestInvalidPattern(t *testing.T) {
+	os.Setenv("INCLUDE", "*.go,[a-")
+	defer os.Clearenv()
+
+	type config struct {
+		Include []glob.Pattern `env:"INCLUDE"`
+	}
+
+	var cfg config
+	err := conf.ParseWithFuncs(&cfg, glob.Parsers(), conf.EnvProvider)
+	assert.EqualError(t, err, `env: parse error on field "Include" of type "[]glob.Pattern": invalid glob pattern "[a-": syntax error in pattern`)
+}
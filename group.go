@@ -0,0 +1,53 @@
+package conf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// checkOneOfGroups enforces `envOneOfGroup` tags, which name a group of
+// sibling fields of which exactly one must be set, such as alternative
+// backends held by pointers to structs. It runs once every provider has been
+// applied. A field is set when it is not its zero value, so a nil pointer or
+// an empty slice is not set.
+func checkOneOfGroups(ref reflect.Value, opts options) error {
+	return walkFields(ref, opts, checkOneOfGroupsOf)
+}
+
+func checkOneOfGroupsOf(s walkedStruct) error {
+	var groups []string
+	members := map[string][]string{}
+	set := map[string][]string{}
+	for _, i := range s.fields {
+		refTypeField := s.ref.Type().Field(i)
+		group := refTypeField.Tag.Get("envOneOfGroup")
+		if group == "" {
+			continue
+		}
+		if _, ok := members[group]; !ok {
+			groups = append(groups, group)
+		}
+		members[group] = append(members[group], refTypeField.Name)
+		if !s.ref.Field(i).IsZero() {
+			set[group] = append(set[group], refTypeField.Name)
+		}
+	}
+
+	for _, group := range groups {
+		if n := len(set[group]); n == 0 {
+			return newError(`exactly one of the fields %s of envOneOfGroup %q should be set, got none`, quoteNames(members[group]), group)
+		} else if n > 1 {
+			return newError(`exactly one of the fields %s of envOneOfGroup %q should be set, got %s`, quoteNames(members[group]), group, quoteNames(set[group]))
+		}
+	}
+	return nil
+}
+
+func quoteNames(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = fmt.Sprintf("%q", name)
+	}
+	return strings.Join(quoted, ", ")
+}
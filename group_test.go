@@ -0,0 +1,69 @@
+package conf_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type s3Backend struct {
+	Bucket string `json:"bucket"`
+}
+
+type gcsBackend struct {
+	Bucket string `json:"bucket"`
+}
+
+type storageBackends struct {
+	S3    *s3Backend  `env:"S3" envOneOfGroup:"backend"`
+	GCS   *gcsBackend `env:"GCS" envOneOfGroup:"backend"`
+	Local string      `env:"LOCAL_DIR" envOneOfGroup:"backend"`
+}
+
+func TestOneOfGroup(t *testing.T) {
+	type config struct {
+		Storage storageBackends
+		Cache   string `env:"REDIS" envOneOfGroup:"cache"`
+		Memory  bool   `env:"IN_MEMORY" envOneOfGroup:"cache"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("GCS", `{"bucket": "logs"}`)
+	os.Setenv("IN_MEMORY", "true")
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Nil(t, cfg.Storage.S3)
+	assert.Equal(t, &gcsBackend{Bucket: "logs"}, cfg.Storage.GCS)
+	assert.True(t, cfg.Memory)
+}
+
+func TestOneOfGroupNone(t *testing.T) {
+	defer os.Clearenv()
+
+	assert.EqualError(t, conf.Parse(&storageBackends{}, conf.EnvProvider), `env: exactly one of the fields "S3", "GCS", "Local" of envOneOfGroup "backend" should be set, got none`)
+}
+
+func TestOneOfGroupMultiple(t *testing.T) {
+	defer os.Clearenv()
+
+	os.Setenv("S3", `{"bucket": "logs"}`)
+	os.Setenv("GCS", `{"bucket": "logs"}`)
+	os.Setenv("LOCAL_DIR", "/var/lib/app")
+
+	assert.EqualError(t, conf.Parse(&storageBackends{}, conf.EnvProvider), `env: exactly one of the fields "S3", "GCS", "Local" of envOneOfGroup "backend" should be set, got "S3", "GCS", "Local"`)
+}
+
+func TestOneOfGroupAcrossProviders(t *testing.T) {
+	defer os.Clearenv()
+
+	os.Setenv("S3", `{"bucket": "logs"}`)
+	dotenv, err := conf.NewDotenvProvider(strings.NewReader("LOCAL_DIR=/var/lib/app"))
+	require.NoError(t, err)
+
+	assert.EqualError(t, conf.Parse(&storageBackends{}, conf.EnvProvider, dotenv), `env: exactly one of the fields "S3", "GCS", "Local" of envOneOfGroup "backend" should be set, got "S3", "Local"`)
+}
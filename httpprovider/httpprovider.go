@@ -0,0 +1,57 @@
+// Package httpprovider provides a conf provider for a JSON document served
+// over HTTP, such as by a central config service.
+//
+// The document is fetched once, when the provider is created, and `env` tags
+// are resolved as dotted paths into it as with conf.NewJSONProvider:
+//
+//	type config struct {
+//		Host string `env:"db.host,required"`
+//	}
+//
+//	provider, err := httpprovider.NewHTTPProvider("https://config.internal/api.json", nil)
+//	if err != nil {
+//		return err
+//	}
+//	var cfg config
+//	err = conf.Parse(&cfg, provider)
+package httpprovider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/steinfletcher/conf"
+)
+
+// NewHTTPProvider fetches the JSON document at url with client, or
+// http.DefaultClient when client is nil. Responses without a 2xx status are
+// an error. Set a timeout on the client, or use NewHTTPProviderWithContext,
+// to bound the request.
+func NewHTTPProvider(url string, client *http.Client) (conf.Provider, error) {
+	return NewHTTPProviderWithContext(context.Background(), url, client)
+}
+
+// NewHTTPProviderWithContext is the same as NewHTTPProvider, making the
+// request with ctx.
+func NewHTTPProviderWithContext(ctx context.Context, url string, client *http.Client) (conf.Provider, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("env: unable to fetch config: %v", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("env: unable to fetch config: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("env: unable to fetch config from %s: unexpected status %q", url, resp.Status)
+	}
+	return conf.NewJSONProvider(resp.Body)
+}
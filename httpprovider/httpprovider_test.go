@@ -0,0 +1,78 @@
+package httpprovider_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steinfletcher/conf"
+	"github.com/steinfletcher/conf/httpprovider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type config struct {
+	Host string `env:"db.host,required"`
+	Port int    `env:"db.port" envDefault:"5432"`
+}
+
+func TestHTTPProvider(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "application/json", r.Header.Get("Accept"))
+		fmt.Fprint(w, `{"db": {"host": "db.internal"}}`)
+	}))
+	defer srv.Close()
+
+	provider, err := httpprovider.NewHTTPProvider(srv.URL, srv.Client())
+	require.NoError(t, err)
+
+	var cfg config
+	assert.NoError(t, conf.Parse(&cfg, provider))
+	assert.Equal(t, config{Host: "db.internal", Port: 5432}, cfg)
+
+	var again config
+	assert.NoError(t, conf.Parse(&again, provider))
+	assert.Equal(t, 1, requests)
+}
+
+func TestHTTPProviderStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	_, err := httpprovider.NewHTTPProvider(srv.URL, nil)
+	assert.EqualError(t, err, fmt.Sprintf(`env: unable to fetch config from %s: unexpected status "503 Service Unavailable"`, srv.URL))
+}
+
+func TestHTTPProviderInvalidJSON(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `not json`)
+	}))
+	defer srv.Close()
+
+	_, err := httpprovider.NewHTTPProvider(srv.URL, nil)
+	assert.EqualError(t, err, "env: unable to decode JSON: invalid character 'o' in literal null (expecting 'u')")
+}
+
+func TestHTTPProviderContext(t *testing.T) {
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer srv.Close()
+	defer close(done)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := httpprovider.NewHTTPProviderWithContext(ctx, srv.URL, nil)
+	require.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "context deadline exceeded"), err.Error())
+}
@@ -0,0 +1,115 @@
+// Package iniprovider provides a conf provider for INI files.
+//
+// `env` tags are resolved as `section.key`, and keys before the first section
+// header, in the default section, by their name alone. Missing keys are
+// treated as not set:
+//
+//	; app.ini
+//	name = api
+//
+//	[db]
+//	host = db.local
+//	port = 5432
+//
+//	type config struct {
+//		Name string `env:"name"`
+//		Host string `env:"db.host,required"`
+//		Port int    `env:"db.port" envDefault:"5432"`
+//	}
+//
+//	provider, err := iniprovider.NewFile("app.ini")
+//	if err != nil {
+//		return err
+//	}
+//	var cfg config
+//	err = conf.Parse(&cfg, provider)
+package iniprovider
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/steinfletcher/conf"
+)
+
+// New parses the INI document read from r. Lines are `key = value` pairs,
+// `[section]` headers or comments starting with `;` or `#`. Keys and values
+// are trimmed and a value may be quoted with matching single or double
+// quotes. A key which is repeated in a section takes its last value.
+func New(r io.Reader) (conf.Provider, error) {
+	values := map[string]string{}
+	var section string
+	var n int
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		n++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+		if line[0] == '[' {
+			if line[len(line)-1] != ']' {
+				return nil, fmt.Errorf("env: ini line %d: expected ] to close the section", n)
+			}
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if section == "" {
+				return nil, fmt.Errorf("env: ini line %d: empty section name", n)
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("env: ini line %d: expected key = value", n)
+		}
+		if section != "" {
+			key = section + "." + key
+		}
+		values[key] = unquote(strings.TrimSpace(value))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("env: unable to read ini: %v", err)
+	}
+	return provider{values: conf.NewMapProvider(values)}, nil
+}
+
+// NewFile parses the INI file at path.
+func NewFile(path string) (conf.Provider, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("env: unable to read ini file: %v", err)
+	}
+	defer f.Close()
+	return New(f)
+}
+
+func unquote(v string) string {
+	if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+		return v[1 : len(v)-1]
+	}
+	return v
+}
+
+// provider reports "ini" as the source of the values it resolves.
+type provider struct {
+	values conf.Provider
+}
+
+// Provide implements conf.Provider.
+func (p provider) Provide(field reflect.StructField) (string, error) {
+	result, err := p.ProvideResult(field)
+	return result.Value, err
+}
+
+// ProvideResult implements conf.ResultProvider.
+func (p provider) ProvideResult(field reflect.StructField) (conf.Result, error) {
+	result, err := p.values.(conf.ResultProvider).ProvideResult(field)
+	if result.Source != "" {
+		result.Source = "ini"
+	}
+	return result, err
+}
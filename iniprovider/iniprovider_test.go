@@ -0,0 +1,128 @@
+package iniprovider_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steinfletcher/conf"
+	"github.com/steinfletcher/conf/iniprovider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const document = `
+; the default section
+name = api
+debug=true
+
+# the database
+[db]
+host = db.local
+port = 5432
+password = "s3cret ; not a comment"
+timeout = 5s
+
+[db.replica]
+host = 'replica.local'
+
+[cache]
+hosts = a.local,b.local
+host = first
+host = last
+`
+
+type config struct {
+	Name        string        `env:"name"`
+	Debug       bool          `env:"debug"`
+	Host        string        `env:"db.host,required"`
+	Port        int           `env:"db.port"`
+	Password    string        `env:"db.password"`
+	Timeout     time.Duration `env:"db.timeout"`
+	ReplicaHost string        `env:"db.replica.host"`
+	CacheHosts  []string      `env:"cache.hosts"`
+	CacheHost   string        `env:"cache.host"`
+	User        string        `env:"db.user" envDefault:"app"`
+	Missing     string        `env:"missing"`
+}
+
+func TestProvider(t *testing.T) {
+	provider, err := iniprovider.New(strings.NewReader(document))
+	require.NoError(t, err)
+
+	var cfg config
+	require.NoError(t, conf.Parse(&cfg, provider))
+	assert.Equal(t, config{
+		Name:        "api",
+		Debug:       true,
+		Host:        "db.local",
+		Port:        5432,
+		Password:    "s3cret ; not a comment",
+		Timeout:     5 * time.Second,
+		ReplicaHost: "replica.local",
+		CacheHosts:  []string{"a.local", "b.local"},
+		CacheHost:   "last",
+		User:        "app",
+	}, cfg)
+}
+
+func TestProviderSource(t *testing.T) {
+	provider, err := iniprovider.New(strings.NewReader(document))
+	require.NoError(t, err)
+
+	var events []conf.AuditEvent
+	var cfg struct {
+		Host string `env:"db.host"`
+		User string `env:"db.user" envDefault:"app"`
+	}
+	require.NoError(t, conf.ParseWithOptions(&cfg, []conf.Option{
+		conf.WithAuditSink(func(e conf.AuditEvent) { events = append(events, e) }),
+	}, provider))
+	require.Len(t, events, 2)
+	assert.Equal(t, "ini", events[0].Source)
+	assert.Equal(t, "db.host", events[0].Key)
+	assert.Equal(t, "", events[1].Source)
+	assert.True(t, events[1].Default)
+}
+
+func TestProviderRequired(t *testing.T) {
+	provider, err := iniprovider.New(strings.NewReader("[db]\nport = 5432\n"))
+	require.NoError(t, err)
+
+	var cfg config
+	assert.EqualError(t, conf.Parse(&cfg, provider), `env: required environment variable "db.host" is not set`)
+}
+
+func TestProviderMalformed(t *testing.T) {
+	tests := []struct {
+		document string
+		err      string
+	}{
+		{"name = api\n[db\nhost = x", "env: ini line 2: expected ] to close the section"},
+		{"[ ]", "env: ini line 1: empty section name"},
+		{"[db]\nhost", "env: ini line 2: expected key = value"},
+		{" = value", "env: ini line 1: expected key = value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.err, func(t *testing.T) {
+			_, err := iniprovider.New(strings.NewReader(tt.document))
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestNewFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.ini")
+	require.NoError(t, os.WriteFile(path, []byte(document), 0o600))
+
+	provider, err := iniprovider.NewFile(path)
+	require.NoError(t, err)
+	var cfg config
+	require.NoError(t, conf.Parse(&cfg, provider))
+	assert.Equal(t, "db.local", cfg.Host)
+
+	_, err = iniprovider.NewFile(filepath.Join(t.TempDir(), "missing.ini"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,54 @@
+package conf
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// checkItems enforces the `envMinItems` and `envMaxItems` tags of slice
+// fields. It runs once every provider has been applied, so a slice which no
+// provider sets is counted as empty.
+func checkItems(ref reflect.Value, opts options) error {
+	return walkFields(ref, opts, func(s walkedStruct) error {
+		for _, i := range s.fields {
+			if reflect.Slice != s.ref.Field(i).Kind() {
+				continue
+			}
+			if err := checkItemCount(s.ref.Field(i).Len(), s.ref.Type().Field(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func checkItemCount(n int, sf reflect.StructField) error {
+	minItems, err := itemsTag(sf, "envMinItems")
+	if err != nil {
+		return err
+	}
+	maxItems, err := itemsTag(sf, "envMaxItems")
+	if err != nil {
+		return err
+	}
+	if minItems >= 0 && n < minItems {
+		return newError(`field "%s" should have at least %d items, got %d`, sf.Name, minItems, n)
+	}
+	if maxItems >= 0 && n > maxItems {
+		return newError(`field "%s" should have at most %d items, got %d`, sf.Name, maxItems, n)
+	}
+	return nil
+}
+
+// itemsTag returns the count of an items tag, or -1 when it is not set.
+func itemsTag(sf reflect.StructField, name string) (int, error) {
+	tag, ok := sf.Tag.Lookup(name)
+	if !ok {
+		return -1, nil
+	}
+	n, err := strconv.Atoi(tag)
+	if err != nil || n < 0 {
+		return 0, newError(`field "%s" has invalid %s %q`, sf.Name, name, tag)
+	}
+	return n, nil
+}
@@ -0,0 +1,80 @@
+package conf_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+)
+
+type replicaConfig struct {
+	Replicas []string      `env:"REPLICAS" envMinItems:"1" envMaxItems:"5"`
+	Timeouts []unmarshaler `env:"TIMEOUTS" envMaxItems:"2"`
+}
+
+func TestItemsWithinBounds(t *testing.T) {
+	tests := []struct {
+		name     string
+		replicas string
+	}{
+		{"min", "a"},
+		{"max", "a,b,c,d,e"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer os.Clearenv()
+			os.Setenv("REPLICAS", tt.replicas)
+			os.Setenv("TIMEOUTS", "1s,2s")
+
+			cfg := replicaConfig{}
+			assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+		})
+	}
+}
+
+func TestItemsOutOfBounds(t *testing.T) {
+	tests := []struct {
+		name     string
+		replicas string
+		timeouts string
+		err      string
+	}{
+		{"empty", "", "", `env: field "Replicas" should have at least 1 items, got 0`},
+		{"above max", "a,b,c,d,e,f", "", `env: field "Replicas" should have at most 5 items, got 6`},
+		{"unmarshalers above max", "a", "1s,2s,3s", `env: field "Timeouts" should have at most 2 items, got 3`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer os.Clearenv()
+			os.Setenv("REPLICAS", tt.replicas)
+			os.Setenv("TIMEOUTS", tt.timeouts)
+
+			cfg := replicaConfig{}
+			assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), tt.err)
+		})
+	}
+}
+
+func TestItemsCountedAfterAllProviders(t *testing.T) {
+	type config struct {
+		Keys []string `secret:"KEYS" envMinItems:"2"`
+	}
+	defer os.Clearenv()
+	os.Setenv("KEYS", "a,b")
+
+	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider, conf.SecretEnvProvider))
+	assert.Equal(t, []string{"a", "b"}, cfg.Keys)
+}
+
+func TestItemsInvalidTag(t *testing.T) {
+	type config struct {
+		Replicas []string `env:"REPLICAS" envMinItems:"one"`
+	}
+	defer os.Clearenv()
+	os.Setenv("REPLICAS", "a")
+
+	cfg := config{}
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: field "Replicas" has invalid envMinItems "one"`)
+}
@@ -0,0 +1,164 @@
+package conf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// nolint: gochecknoglobals
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// decodeJSON unmarshals data into the addressable value v like json.Unmarshal,
+// except that values whose type has a type parser, such as time.Duration or
+// url.URL, are parsed from JSON strings with that parser. This lets a JSON
+// document use the same representations as environment variables, e.g.
+// `{"timeout": "5s"}`.
+func (p *parser) decodeJSON(data []byte, v reflect.Value) error {
+	if parserFunc, ok := p.typeParser(v.Type()); ok {
+		var s string
+		if json.Unmarshal(data, &s) == nil {
+			return setParsedJSON(v, s, parserFunc)
+		}
+	}
+
+	if u, ok := lookupUnion(v.Type()); ok {
+		return p.decodeJSONUnion(data, v, u)
+	}
+
+	if v.Kind() == reflect.Ptr {
+		if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return p.decodeJSON(data, v.Elem())
+	}
+
+	if reflect.PtrTo(v.Type()).Implements(jsonUnmarshalerType) {
+		return json.Unmarshal(data, v.Addr().Interface())
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if reflect.PtrTo(v.Type()).Implements(textUnmarshalerType) {
+			break
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return err
+		}
+		return p.decodeJSONFields(fields, v)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return err
+		}
+		if items == nil {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := p.decodeJSON(item, slice.Index(i)); err != nil {
+				return fmt.Errorf("index %d: %v", i, err)
+			}
+		}
+		v.Set(slice)
+		return nil
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			break
+		}
+		var items map[string]json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return err
+		}
+		if items == nil {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		m := reflect.MakeMapWithSize(v.Type(), len(items))
+		for k, item := range items {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := p.decodeJSON(item, elem); err != nil {
+				return fmt.Errorf("key %q: %v", k, err)
+			}
+			m.SetMapIndex(reflect.ValueOf(k).Convert(v.Type().Key()), elem)
+		}
+		v.Set(m)
+		return nil
+	}
+
+	return json.Unmarshal(data, v.Addr().Interface())
+}
+
+func setParsedJSON(v reflect.Value, s string, parserFunc ParserFunc) error {
+	r, err := parserFunc(s)
+	if err != nil {
+		return err
+	}
+	val, err := convertParsed(r, v.Type())
+	if err != nil {
+		return err
+	}
+	v.Set(val)
+	return nil
+}
+
+// decodeJSONFields sets the fields of struct v from the members of a JSON
+// object, matching names the way encoding/json does.
+func (p *parser) decodeJSONFields(fields map[string]json.RawMessage, v reflect.Value) error {
+	var refType = v.Type()
+
+	for i := 0; i < refType.NumField(); i++ {
+		sf := refType.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		if sf.Anonymous && tag == "" && sf.Type.Kind() == reflect.Struct {
+			if err := p.decodeJSONFields(fields, v.Field(i)); err != nil {
+				return err
+			}
+			continue
+		}
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		name := strings.Split(tag, ",")[0]
+		if name == "" {
+			name = sf.Name
+		}
+		raw, ok := jsonMember(fields, name)
+		if !ok {
+			continue
+		}
+		if err := p.decodeJSON(raw, v.Field(i)); err != nil {
+			return fmt.Errorf("json field %q: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// jsonMember looks up name, preferring an exact match and otherwise matching
+// case insensitively like encoding/json.
+func jsonMember(fields map[string]json.RawMessage, name string) (json.RawMessage, bool) {
+	if raw, ok := fields[name]; ok {
+		return raw, true
+	}
+	for k, raw := range fields {
+		if strings.EqualFold(k, name) {
+			return raw, true
+		}
+	}
+	return nil, false
+}
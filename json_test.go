@@ -0,0 +1,110 @@
+package conf_test
+
+import (
+	"net/netip"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsesJSONWithConfParsers(t *testing.T) {
+	type retry struct {
+		Backoff []time.Duration `json:"backoff"`
+	}
+	type server struct {
+		Timeout  time.Duration            `json:"timeout"`
+		Idle     *time.Duration           `json:"idle"`
+		Legacy   time.Duration            `json:"legacy"`
+		Endpoint url.URL                  `json:"endpoint"`
+		Addr     netip.Addr               `json:"addr"`
+		Mode     Mode                     `json:"mode"`
+		Limits   map[string]time.Duration `json:"limits"`
+		Retry    retry                    `json:"retry"`
+		Name     string
+	}
+	type config struct {
+		Server server `env:"SERVER"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("SERVER", `{
+		"timeout": "5s",
+		"idle": "1m",
+		"legacy": 1000,
+		"endpoint": "https://example.com/api",
+		"addr": "10.0.0.1",
+		"mode": "turbo",
+		"limits": {"read": "2s"},
+		"retry": {"backoff": ["1s", "2s"]},
+		"name": "api"
+	}`)
+
+	var cfg config
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, 5*time.Second, cfg.Server.Timeout)
+	assert.Equal(t, time.Minute, *cfg.Server.Idle)
+	assert.Equal(t, time.Microsecond, cfg.Server.Legacy)
+	assert.Equal(t, "https://example.com/api", cfg.Server.Endpoint.String())
+	assert.Equal(t, netip.MustParseAddr("10.0.0.1"), cfg.Server.Addr)
+	assert.Equal(t, ModeTurbo, cfg.Server.Mode)
+	assert.Equal(t, map[string]time.Duration{"read": 2 * time.Second}, cfg.Server.Limits)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, cfg.Server.Retry.Backoff)
+	assert.Equal(t, "api", cfg.Server.Name)
+}
+
+func TestParsesJSONInvalidConfValue(t *testing.T) {
+	type server struct {
+		Retry struct {
+			Backoff []time.Duration `json:"backoff"`
+		} `json:"retry"`
+	}
+	type config struct {
+		Server server `env:"SERVER"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("SERVER", `{"retry": {"backoff": ["1s", "soon"]}}`)
+
+	var cfg config
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: parse error on field "Server" of type "conf_test.server": json field "retry": json field "backoff": index 1: unable to parser duration: time: invalid duration "soon"`)
+}
+
+func TestParsesSliceOfMaps(t *testing.T) {
+	type config struct {
+		Routes  []map[string]string  `env:"ROUTES"`
+		Weights []map[string]int     `env:"WEIGHTS"`
+		Ptrs    []*map[string]string `env:"ROUTES"`
+		Empty   []map[string]string  `env:"EMPTY"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("ROUTES", `[{"path": "/api", "backend": "api:8080"}, {"path": "/"}]`)
+	os.Setenv("WEIGHTS", `[{"a": 1, "b": 2}, {}]`)
+	os.Setenv("EMPTY", `[]`)
+
+	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, []map[string]string{{"path": "/api", "backend": "api:8080"}, {"path": "/"}}, cfg.Routes)
+	assert.Equal(t, []map[string]int{{"a": 1, "b": 2}, {}}, cfg.Weights)
+	assert.Equal(t, []*map[string]string{{"path": "/api", "backend": "api:8080"}, {"path": "/"}}, cfg.Ptrs)
+	assert.Equal(t, []map[string]string{}, cfg.Empty)
+}
+
+func TestParsesSliceOfMapsInvalid(t *testing.T) {
+	type config struct {
+		Weights []map[string]int `env:"WEIGHTS"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("WEIGHTS", `a=1,b=2`)
+	cfg := config{}
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: no parser found for field "Weights" of type "[]map[string]int"`)
+
+	os.Setenv("WEIGHTS", `[{"a": "one"}]`)
+	cfg = config{}
+	assert.Error(t, conf.Parse(&cfg, conf.EnvProvider))
+}
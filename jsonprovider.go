@@ -0,0 +1,107 @@
+package conf
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// NewJSONProvider decodes the JSON object read from r and returns a provider
+// which resolves `env` tags as dotted paths into it, e.g. `env:"db.host"`.
+// Array elements are addressed by their index, e.g. `env:"servers.0"`. It
+// supports the same tags and options as EnvProvider.
+//
+// Strings, numbers and booleans are provided as text and null as a missing
+// value. Objects and arrays are provided as JSON, so they can be parsed into
+// struct fields, and arrays of strings, numbers and booleans also fill slice
+// fields element by element.
+func NewJSONProvider(r io.Reader) (Provider, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	var doc map[string]interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, newError("unable to decode JSON: %v", err)
+	}
+	return newJSONDocProvider(doc), nil
+}
+
+// newJSONDocProvider returns the provider of a decoded JSON object.
+func newJSONDocProvider(doc map[string]interface{}) envProvider {
+	return envProvider{
+		tag:    "env",
+		source: "json",
+		lookup: func(key string) (string, bool) {
+			node, ok := jsonPath(doc, key)
+			if !ok {
+				return "", false
+			}
+			return jsonText(node)
+		},
+		lookupValues: func(key string) ([]string, bool) {
+			node, _ := jsonPath(doc, key)
+			array, ok := node.([]interface{})
+			if !ok {
+				return nil, false
+			}
+			values := make([]string, 0, len(array))
+			for _, elem := range array {
+				switch elem.(type) {
+				case map[string]interface{}, []interface{}, nil:
+					return nil, false
+				}
+				value, _ := jsonText(elem)
+				values = append(values, value)
+			}
+			return values, true
+		},
+	}
+}
+
+// jsonPath returns the node at the dotted path key.
+func jsonPath(doc map[string]interface{}, key string) (interface{}, bool) {
+	if key == "" {
+		return nil, false
+	}
+	var node interface{} = doc
+	for _, part := range strings.Split(key, ".") {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if node, ok = n[part]; !ok {
+				return nil, false
+			}
+		case []interface{}:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(n) {
+				return nil, false
+			}
+			node = n[i]
+		default:
+			return nil, false
+		}
+	}
+	return node, true
+}
+
+func jsonText(node interface{}) (string, bool) {
+	switch n := node.(type) {
+	case nil:
+		return "", false
+	case string:
+		return n, true
+	case json.Number:
+		return n.String(), true
+	case bool:
+		return strconv.FormatBool(n), true
+	default:
+		var b bytes.Buffer
+		enc := json.NewEncoder(&b)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(n); err != nil {
+			return "", false
+		}
+		return strings.TrimSuffix(b.String(), "\n"), true
+	}
+}
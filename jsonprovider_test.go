@@ -0,0 +1,73 @@
+package conf_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const jsonDocument = `{
+	"name": "api",
+	"debug": true,
+	"db": {"host": "localhost", "port": 5432, "timeout": "5s", "password": null},
+	"peers": ["a", "b,c"],
+	"ports": [80, 443],
+	"servers": [{"host": "one"}, {"host": "two"}],
+	"limits": {"rps": 10}
+}`
+
+func TestJSONProvider(t *testing.T) {
+	type limits struct {
+		RPS int `json:"rps"`
+	}
+	type config struct {
+		Name     string        `env:"name"`
+		Debug    bool          `env:"debug"`
+		Host     string        `env:"db.host,required"`
+		Port     int           `env:"db.port"`
+		Timeout  time.Duration `env:"db.timeout"`
+		Password string        `env:"db.password" envDefault:"secret"`
+		Peers    []string      `env:"peers"`
+		Ports    []int         `env:"ports"`
+		Server   string        `env:"servers.1.host"`
+		Limits   limits        `env:"limits"`
+		Missing  string        `env:"db.missing.key"`
+	}
+	provider, err := conf.NewJSONProvider(strings.NewReader(jsonDocument))
+	require.NoError(t, err)
+
+	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, provider))
+	assert.Equal(t, config{
+		Name:     "api",
+		Debug:    true,
+		Host:     "localhost",
+		Port:     5432,
+		Timeout:  5 * time.Second,
+		Password: "secret",
+		Peers:    []string{"a", "b,c"},
+		Ports:    []int{80, 443},
+		Server:   "two",
+		Limits:   limits{RPS: 10},
+	}, cfg)
+}
+
+func TestJSONProviderRequired(t *testing.T) {
+	type config struct {
+		User string `env:"db.user,required"`
+	}
+	provider, err := conf.NewJSONProvider(strings.NewReader(jsonDocument))
+	require.NoError(t, err)
+
+	cfg := config{}
+	assert.EqualError(t, conf.Parse(&cfg, provider), `env: required environment variable "db.user" is not set`)
+}
+
+func TestJSONProviderInvalid(t *testing.T) {
+	_, err := conf.NewJSONProvider(strings.NewReader(`["not", "an", "object"]`))
+	assert.EqualError(t, err, "env: unable to decode JSON: json: cannot unmarshal array into Go value of type map[string]interface {}")
+}
@@ -0,0 +1,17 @@
+module github.com/steinfletcher/conf/langtag
+
+go 1.18
+
+require (
+	github.com/steinfletcher/conf v0.0.0
+	github.com/stretchr/testify v1.4.0
+	golang.org/x/text v0.14.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v2 v2.2.2 // indirect
+)
+
+replace github.com/steinfletcher/conf => ../
@@ -0,0 +1,39 @@
+// Package langtag provides conf parsers for BCP 47 language tags.
+//
+// It is a separate module so that golang.org/x/text is only required by
+// programs which parse language tags. Register the parsers with
+// conf.ParseWithFuncs:
+//
+//	type config struct {
+//		DefaultLang language.Tag   `env:"DEFAULT_LANG" envDefault:"en-US"`
+//		Accepted    []language.Tag `env:"ACCEPTED_LANGS"`
+//	}
+//
+//	var cfg config
+//	err := conf.ParseWithFuncs(&cfg, langtag.Parsers(), conf.EnvProvider)
+package langtag
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/steinfletcher/conf"
+	"golang.org/x/text/language"
+)
+
+// Parsers returns the custom parsers for language.Tag fields, including
+// slices of language.Tag.
+func Parsers() map[reflect.Type]conf.ParserFunc {
+	return map[reflect.Type]conf.ParserFunc{
+		reflect.TypeOf(language.Tag{}): Parse,
+	}
+}
+
+// Parse parses v as a canonicalized BCP 47 language tag.
+func Parse(v string) (interface{}, error) {
+	tag, err := language.Parse(v)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse language tag: %v", err)
+	}
+	return tag, nil
+}
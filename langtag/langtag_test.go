@@ -0,0 +1,66 @@
+package langtag_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/steinfletcher/conf"
+	"github.com/steinfletcher/conf/langtag"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+)
+
+func TestParsesLanguageTags(t *testing.T) {
+	os.Setenv("DEFAULT_LANG", "en-US")
+	os.Setenv("ACCEPTED_LANGS", "en-GB,de,pt-BR")
+	defer os.Clearenv()
+
+	type config struct {
+		DefaultLang language.Tag    `env:"DEFAULT_LANG"`
+		LangPtr     *language.Tag   `env:"DEFAULT_LANG"`
+		Accepted    []language.Tag  `env:"ACCEPTED_LANGS"`
+		AcceptedPtr []*language.Tag `env:"ACCEPTED_LANGS"`
+	}
+
+	var cfg config
+	assert.NoError(t, conf.ParseWithFuncs(&cfg, langtag.Parsers(), conf.EnvProvider))
+	assert.Equal(t, language.AmericanEnglish, cfg.DefaultLang)
+	assert.Equal(t, language.AmericanEnglish, *cfg.LangPtr)
+	assert.Equal(t, []language.Tag{language.BritishEnglish, language.German, language.BrazilianPortuguese}, cfg.Accepted)
+	assert.Len(t, cfg.AcceptedPtr, 3)
+	assert.Equal(t, language.German, *cfg.AcceptedPtr[1])
+}
+
+func TestParseCanonicalizesTag(t *testing.T) {
+	tag, err := langtag.Parse("EN-us")
+	assert.NoError(t, err)
+	assert.Equal(t, language.AmericanEnglish, tag)
+}
+
+func TestInvalidLanguageTag(t *testing.T) {
+	os.Setenv("DEFAULT_LANG", "not a tag")
+	defer os.Clearenv()
+
+	type config struct {
+		DefaultLang language.Tag `env:"DEFAULT_LANG"`
+	}
+
+	var cfg config
+	err := conf.ParseWithFuncs(&cfg, langtag.Parsers(), conf.EnvProvider)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `env: parse error on field "DefaultLang" of type "language.Tag": unable to parse language tag`)
+}
+
+func TestInvalidLanguageTags(t *testing.T) {
+	os.Setenv("ACCEPTED_LANGS", "en,x")
+	defer os.Clearenv()
+
+	type config struct {
+		Accepted []language.Tag `env:"ACCEPTED_LANGS"`
+	}
+
+	var cfg config
+	err := conf.ParseWithFuncs(&cfg, langtag.Parsers(), conf.EnvProvider)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `env: parse error on field "Accepted" of type "[]language.Tag"`)
+}
@@ -0,0 +1,40 @@
+package conf
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+)
+
+// nolint: gochecknoglobals
+var mailAddressType = reflect.TypeOf(mail.Address{})
+
+// isAddressList reports whether a slice field is a list of mail addresses,
+// which is parsed with mail.ParseAddressList as display names may contain
+// commas. A field with an `envSeparator` is split as usual instead.
+func isAddressList(sf reflect.StructField) bool {
+	elem := sf.Type.Elem()
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	return elem == mailAddressType && sf.Tag.Get("envSeparator") == ""
+}
+
+// setAddressList sets the slice field to the addresses of value, e.g.
+// `"Doe, Jane" <jane@example.com>, bob@example.com`.
+func setAddressList(field reflect.Value, value string, sf reflect.StructField) error {
+	addresses, err := mail.ParseAddressList(value)
+	if err != nil {
+		return newParseError(sf, fmt.Errorf("unable to parse address list: %v", err))
+	}
+	result := reflect.MakeSlice(sf.Type, len(addresses), len(addresses))
+	for i, address := range addresses {
+		v := reflect.ValueOf(address)
+		if sf.Type.Elem().Kind() != reflect.Ptr {
+			v = v.Elem()
+		}
+		result.Index(i).Set(v)
+	}
+	field.Set(result)
+	return nil
+}
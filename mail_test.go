@@ -0,0 +1,77 @@
+package conf_test
+
+import (
+	"net/mail"
+	"os"
+	"testing"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMailAddressList(t *testing.T) {
+	type config struct {
+		Recipients    []*mail.Address `env:"RECIPIENTS"`
+		RecipientVals []mail.Address  `env:"RECIPIENTS"`
+		Split         []mail.Address  `env:"SPLIT" envSeparator:";"`
+		From          mail.Address    `env:"FROM"`
+		ReplyTo       *mail.Address   `env:"FROM"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("RECIPIENTS", `"Doe, Jane" <jane@example.com>, Bob <bob@example.com>, carol@example.com`)
+	os.Setenv("SPLIT", "a@example.com;B <b@example.com>")
+	os.Setenv("FROM", `"Ops, Team" <ops@example.com>`)
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	expected := []mail.Address{
+		{Name: "Doe, Jane", Address: "jane@example.com"},
+		{Name: "Bob", Address: "bob@example.com"},
+		{Address: "carol@example.com"},
+	}
+	require.Len(t, cfg.Recipients, 3)
+	for i, address := range cfg.Recipients {
+		assert.Equal(t, expected[i], *address)
+	}
+	assert.Equal(t, expected, cfg.RecipientVals)
+	assert.Equal(t, []mail.Address{{Address: "a@example.com"}, {Name: "B", Address: "b@example.com"}}, cfg.Split)
+	assert.Equal(t, mail.Address{Name: "Ops, Team", Address: "ops@example.com"}, cfg.From)
+	assert.Equal(t, &mail.Address{Name: "Ops, Team", Address: "ops@example.com"}, cfg.ReplyTo)
+}
+
+func TestMailAddressListNumbered(t *testing.T) {
+	type config struct {
+		Recipients []mail.Address `env:"RECIPIENT" envNumbered:"true"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("RECIPIENT_1", `"Doe, Jane" <jane@example.com>`)
+	os.Setenv("RECIPIENT_2", "bob@example.com")
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, []mail.Address{{Name: "Doe, Jane", Address: "jane@example.com"}, {Address: "bob@example.com"}}, cfg.Recipients)
+}
+
+func TestMailAddressErrors(t *testing.T) {
+	defer os.Clearenv()
+	os.Setenv("ADDRESS", "jane@example.com, not an address")
+
+	t.Run("list", func(t *testing.T) {
+		type config struct {
+			Recipients []mail.Address `env:"ADDRESS"`
+		}
+		cfg := config{}
+		assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: parse error on field "Recipients" of type "[]mail.Address": unable to parse address list: mail: no angle-addr`)
+	})
+
+	t.Run("single", func(t *testing.T) {
+		type config struct {
+			From mail.Address `env:"ADDRESS"`
+		}
+		cfg := config{}
+		assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: parse error on field "From" of type "mail.Address": unable to parse address: mail: expected single address, got ", not an address"`)
+	})
+}
@@ -0,0 +1,71 @@
+package conf
+
+import (
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// provideMapPrefix resolves a map field tagged with `envMapPrefix` from every
+// key of the source which starts with the prefix. The items are returned as
+// the Values of the result, each the lowercased rest of a key and its value
+// joined by the key value separator of the field, so values may contain the
+// item separator.
+func (o envProvider) provideMapPrefix(field reflect.StructField, prefix string) (Result, error) {
+	var result Result
+	if reflect.Map != field.Type.Kind() {
+		return result, newError(`field "%s" has envMapPrefix but is not a map`, field.Name)
+	}
+	prefix = field.Tag.Get(keyPrefixTag) + prefix
+	_, kvSeparator := mapSeparators(field)
+
+	for _, key := range o.listKeys() {
+		if !strings.HasPrefix(key, prefix) || key == prefix {
+			continue
+		}
+		value, _ := o.lookupEnv(key)
+		result.Values = append(result.Values, strings.ToLower(key[len(prefix):])+kvSeparator+value)
+	}
+	if result.Values != nil {
+		result.Key = prefix
+		result.Source = o.source
+		if result.Source == "" {
+			result.Source = o.tag
+		}
+		result.Value = strings.Join(result.Values, ",")
+		return result, nil
+	}
+	if val, ok := field.Tag.Lookup("envDefault"); ok {
+		result.Value, result.Default = val, true
+	}
+	return result, nil
+}
+
+// listKeys returns the keys of the source in order, the environment when the
+// provider has no lookup. Sources which cannot list their keys have none.
+func (o envProvider) listKeys() []string {
+	var keys []string
+	switch {
+	case o.keys != nil:
+		keys = o.keys()
+	case o.lookup == nil:
+		for _, kv := range os.Environ() {
+			key, _, _ := strings.Cut(kv, "=")
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// mapKeys returns the keys of values, for providers reading from a map.
+func mapKeys(values map[string]string) func() []string {
+	return func() []string {
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		return keys
+	}
+}
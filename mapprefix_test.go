@@ -0,0 +1,83 @@
+package conf_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapPrefix(t *testing.T) {
+	type metrics struct {
+		Tags map[string]string `envMapPrefix:"TAG_"`
+	}
+	type config struct {
+		Labels   map[string]string `envMapPrefix:"LABEL_"`
+		Limits   map[string]int    `envMapPrefix:"LIMIT_"`
+		Missing  map[string]string `envMapPrefix:"MISSING_"`
+		Defaults map[string]string `envMapPrefix:"DEFAULT_" envDefault:"a=1,b=2"`
+		Metrics  metrics           `envPrefix:"METRICS_"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("LABEL_TEAM", "payments")
+	os.Setenv("LABEL_COST_CENTRE", "a=b,c")
+	os.Setenv("LABEL_", "ignored")
+	os.Setenv("LABELS", "ignored")
+	os.Setenv("APP_LABEL_TIER", "ignored")
+	os.Setenv("LIMIT_CPU", "2")
+	os.Setenv("LIMIT_MEMORY", "512")
+	os.Setenv("METRICS_TAG_ENV", "prod")
+	os.Setenv("TAG_ENV", "ignored")
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, map[string]string{"team": "payments", "cost_centre": "a=b,c"}, cfg.Labels)
+	assert.Equal(t, map[string]int{"cpu": 2, "memory": 512}, cfg.Limits)
+	assert.Nil(t, cfg.Missing)
+	assert.Equal(t, map[string]string{"a": "1", "b": "2"}, cfg.Defaults)
+	assert.Equal(t, map[string]string{"env": "prod"}, cfg.Metrics.Tags)
+}
+
+func TestMapPrefixProviders(t *testing.T) {
+	type config struct {
+		Labels map[string]string `envMapPrefix:"LABEL_"`
+	}
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.NewMapProvider(map[string]string{
+		"LABEL_TEAM": "payments",
+		"HOST":       "localhost",
+	})))
+	assert.Equal(t, map[string]string{"team": "payments"}, cfg.Labels)
+
+	dotenv, err := conf.NewDotenvProvider(strings.NewReader("LABEL_TIER=web\nLABEL_ZONE=eu\n"))
+	require.NoError(t, err)
+	cfg = config{}
+	require.NoError(t, conf.Parse(&cfg, dotenv))
+	assert.Equal(t, map[string]string{"tier": "web", "zone": "eu"}, cfg.Labels)
+}
+
+func TestMapPrefixErrors(t *testing.T) {
+	defer os.Clearenv()
+
+	t.Run("not a map", func(t *testing.T) {
+		type config struct {
+			Labels []string `envMapPrefix:"LABEL_"`
+		}
+		cfg := config{}
+		assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: field "Labels" has envMapPrefix but is not a map`)
+	})
+
+	t.Run("value", func(t *testing.T) {
+		type config struct {
+			Limits map[string]int `envMapPrefix:"LIMIT_"`
+		}
+		os.Setenv("LIMIT_CPU", "two")
+		cfg := config{}
+		assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: parse error on field "Limits" of type "map[string]int": strconv.ParseInt: parsing "two": invalid syntax`)
+	})
+}
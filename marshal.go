@@ -0,0 +1,207 @@
+package conf
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maskedValue replaces the value of sensitive fields in output.
+const maskedValue = "***"
+
+// nolint: gochecknoglobals
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// MarshalEnv formats the fields of a struct as `KEY=value` lines, using the
+// same keys and separators that Parse reads them from. Fields without an `env`
+// or `secret` key are skipped and nested structs are flattened, with the
+// `envPrefix` of the struct fields prepended to their keys. The values of
+// fields with a `secret` key or a `mask:"true"` tag are replaced with "***",
+// so the output is safe to log.
+func MarshalEnv(v interface{}) ([]byte, error) {
+	ref := reflect.ValueOf(v)
+	if ref.Kind() == reflect.Ptr {
+		ref = ref.Elem()
+	}
+	if ref.Kind() != reflect.Struct {
+		return nil, ErrNotAStructPtr
+	}
+
+	var buf bytes.Buffer
+	err := marshalEnv(&buf, ref, "")
+	return buf.Bytes(), err
+}
+
+// marshalEnv writes the fields of ref, prepending prefix, from the `envPrefix`
+// tags of the struct fields ref is nested in, to their keys as Parse does.
+func marshalEnv(buf *bytes.Buffer, ref reflect.Value, prefix string) error {
+	var refType = ref.Type()
+
+	for i := 0; i < refType.NumField(); i++ {
+		refField := ref.Field(i)
+		refTypeField := refType.Field(i)
+		if refTypeField.PkgPath != "" {
+			continue
+		}
+
+		prefixedField := withKeyPrefix(refTypeField, prefix)
+		key := tagKey(prefixedField, "env")
+		if key == "" {
+			key = tagKey(prefixedField, "secret")
+		}
+		if key == "" {
+			if reflect.Ptr == refField.Kind() && !refField.IsNil() {
+				refField = refField.Elem()
+			}
+			if reflect.Struct == refField.Kind() {
+				if err := marshalEnv(buf, refField, prefix+refTypeField.Tag.Get("envPrefix")); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if reflect.Ptr == refField.Kind() && refField.IsNil() {
+			continue
+		}
+		if isCredentials(refField.Type()) {
+			creds := reflect.Indirect(refField).Interface().(Credentials)
+			username := creds.Username
+			if isMasked(refTypeField) {
+				username = maskedValue
+			}
+			writeEnvLine(buf, key+"_USERNAME", username)
+			writeEnvLine(buf, key+"_PASSWORD", maskedValue)
+			continue
+		}
+		value := maskedValue
+		if !isMasked(refTypeField) {
+			var err error
+			value, err = formatField(refField, refTypeField)
+			if err != nil {
+				return err
+			}
+		}
+		writeEnvLine(buf, key, value)
+	}
+	return nil
+}
+
+func writeEnvLine(buf *bytes.Buffer, key, value string) {
+	buf.WriteString(key)
+	buf.WriteByte('=')
+	buf.WriteString(quoteEnvValue(value))
+	buf.WriteByte('\n')
+}
+
+func newFormatError(sf reflect.StructField, err error) error {
+	return newError(`unable to format field "%s" of type "%s": %v`, sf.Name, sf.Type, err)
+}
+
+// isMasked reports whether the value of a field must not appear in output.
+func isMasked(sf reflect.StructField) bool {
+	if _, ok := sf.Tag.Lookup("secret"); ok {
+		return true
+	}
+	return strings.ToLower(sf.Tag.Get("mask")) == "true"
+}
+
+// formatField formats a field as the string Parse would read it from.
+func formatField(field reflect.Value, sf reflect.StructField) (string, error) {
+	if field.Type() == orderedPairsType {
+		return formatOrderedPairs(field.Interface().(OrderedPairs), sf), nil
+	}
+	switch field.Kind() {
+	case reflect.Slice:
+		var separator = sf.Tag.Get("envSeparator")
+		if _, ok := sf.Tag.Lookup("envWidth"); ok {
+			separator = ""
+		} else if separator == "" {
+			separator = ","
+		}
+		parts := make([]string, field.Len())
+		for i := range parts {
+			part, err := formatValue(field.Index(i))
+			if err != nil {
+				return "", newFormatError(sf, err)
+			}
+			parts[i] = part
+		}
+		return strings.Join(parts, separator), nil
+	case reflect.Map:
+		separator, kvSeparator := mapSeparators(sf)
+		pairs := make([]string, 0, field.Len())
+		iter := field.MapRange()
+		for iter.Next() {
+			k, err := formatValue(iter.Key())
+			if err != nil {
+				return "", newFormatError(sf, err)
+			}
+			v, err := formatValue(iter.Value())
+			if err != nil {
+				return "", newFormatError(sf, err)
+			}
+			pairs = append(pairs, k+kvSeparator+v)
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, separator), nil
+	}
+
+	value, err := formatValue(field)
+	if err != nil {
+		return "", newFormatError(sf, err)
+	}
+	return value, nil
+}
+
+// formatValue formats a single value with the codec registered for its type,
+// or else preferring encoding.TextMarshaler and fmt.Stringer so that types
+// such as time.Duration and url.URL are written in the form they are parsed
+// from.
+func formatValue(v reflect.Value) (string, error) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+
+	if format, ok := registeredFormat(v.Type()); ok {
+		return format(v.Interface()), nil
+	}
+
+	if ptr := addressable(v); ptr.Type().Implements(textMarshalerType) {
+		text, err := ptr.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	} else if s, ok := ptr.Interface().(fmt.Stringer); ok {
+		return s.String(), nil
+	}
+
+	if v.Kind() == reflect.Struct {
+		b, err := json.Marshal(v.Interface())
+		return string(b), err
+	}
+	return fmt.Sprint(v.Interface()), nil
+}
+
+// addressable returns a pointer to a copy of v, so methods with pointer
+// receivers can be called on it.
+func addressable(v reflect.Value) reflect.Value {
+	ptr := reflect.New(v.Type())
+	ptr.Elem().Set(v)
+	return ptr
+}
+
+// quoteEnvValue quotes values which would not be read back unchanged from a
+// KEY=value line.
+func quoteEnvValue(v string) string {
+	if strings.ContainsAny(v, " \t\r\n\"'#\\") {
+		return strconv.Quote(v)
+	}
+	return v
+}
@@ -0,0 +1,122 @@
+package conf_test
+
+import (
+	"bytes"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalEnv(t *testing.T) {
+	type database struct {
+		Host     string `env:"DB_HOST"`
+		Password string `env:"DB_PASSWORD" mask:"true"`
+	}
+	type config struct {
+		Port     int                      `env:"PORT"`
+		Timeout  time.Duration            `env:"TIMEOUT"`
+		Endpoint *url.URL                 `env:"ENDPOINT"`
+		Hosts    []string                 `env:"HOSTS" envSeparator:":"`
+		Limits   map[string]time.Duration `env:"LIMITS"`
+		Motd     string                   `env:"MOTD"`
+		Token    string                   `env:"TOKEN" mask:"true"`
+		APIKey   string                   `secret:"API_KEY"`
+		Missing  *int                     `env:"MISSING"`
+		Database database
+		NoKey    string
+	}
+
+	endpoint, err := url.Parse("https://example.com/api")
+	require.NoError(t, err)
+	cfg := config{
+		Port:     8080,
+		Timeout:  5 * time.Second,
+		Endpoint: endpoint,
+		Hosts:    []string{"a", "b"},
+		Limits:   map[string]time.Duration{"write": time.Minute, "read": time.Second},
+		Motd:     "hello world",
+		Token:    "t0k3n",
+		APIKey:   "k3y",
+		Database: database{Host: "db.local", Password: "hunter2"},
+		NoKey:    "ignored",
+	}
+
+	out, err := conf.MarshalEnv(&cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, `PORT=8080
+TIMEOUT=5s
+ENDPOINT=https://example.com/api
+HOSTS=a:b
+LIMITS=read=1s,write=1m0s
+MOTD="hello world"
+TOKEN=***
+API_KEY=***
+DB_HOST=db.local
+DB_PASSWORD=***
+`, string(out))
+}
+
+func TestMarshalEnvRoundTrip(t *testing.T) {
+	type config struct {
+		Port    int           `env:"PORT"`
+		Timeout time.Duration `env:"TIMEOUT"`
+		Hosts   []string      `env:"HOSTS"`
+		Mode    Mode          `env:"MODE"`
+	}
+	defer os.Clearenv()
+
+	in := config{Port: 80, Timeout: time.Minute, Hosts: []string{"a", "b"}, Mode: ModeTurbo}
+	out, err := conf.MarshalEnv(in)
+	require.NoError(t, err)
+	assert.Equal(t, "PORT=80\nTIMEOUT=1m0s\nHOSTS=a,b\nMODE=turbo\n", string(out))
+
+	os.Setenv("PORT", "80")
+	os.Setenv("TIMEOUT", "1m0s")
+	os.Setenv("HOSTS", "a,b")
+	os.Setenv("MODE", "turbo")
+	var parsed config
+	require.NoError(t, conf.Parse(&parsed, conf.EnvProvider))
+	assert.Equal(t, in, parsed)
+}
+
+func TestMarshalEnvPrefix(t *testing.T) {
+	type database struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT"`
+	}
+	type cluster struct {
+		Primary database  `envPrefix:"PRIMARY_"`
+		Replica *database `envPrefix:"REPLICA_"`
+	}
+	type config struct {
+		Name string  `env:"NAME"`
+		DB   cluster `envPrefix:"DB_"`
+	}
+
+	in := config{
+		Name: "api",
+		DB: cluster{
+			Primary: database{Host: "db-1", Port: 5432},
+			Replica: &database{Host: "db-2", Port: 5433},
+		},
+	}
+	out, err := conf.MarshalEnv(in)
+	require.NoError(t, err)
+	assert.Equal(t, "NAME=api\nDB_PRIMARY_HOST=db-1\nDB_PRIMARY_PORT=5432\nDB_REPLICA_HOST=db-2\nDB_REPLICA_PORT=5433\n", string(out))
+
+	provider, err := conf.NewDotenvProvider(bytes.NewReader(out))
+	require.NoError(t, err)
+	parsed := config{DB: cluster{Replica: &database{}}}
+	require.NoError(t, conf.Parse(&parsed, provider))
+	assert.Equal(t, in, parsed)
+}
+
+func TestMarshalEnvNotAStruct(t *testing.T) {
+	_, err := conf.MarshalEnv("nope")
+	assert.Equal(t, conf.ErrNotAStructPtr, err)
+}
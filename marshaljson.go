@@ -0,0 +1,165 @@
+package conf
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+)
+
+// nolint: gochecknoglobals
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// MarshalJSONMasked formats a struct as JSON like json.Marshal does, with the
+// same field names and `json` tags, but with the values of fields with a
+// `secret` key or a `mask:"true"` tag replaced with "***", so the output is
+// safe to expose, e.g. on a debug endpoint. Nested structs, and the structs
+// in slices and maps, are masked the same way.
+func MarshalJSONMasked(v interface{}) ([]byte, error) {
+	ref := reflect.ValueOf(v)
+	if ref.Kind() == reflect.Ptr {
+		ref = ref.Elem()
+	}
+	if ref.Kind() != reflect.Struct {
+		return nil, ErrNotAStructPtr
+	}
+
+	b, err := json.Marshal(maskedJSON(ref))
+	if err != nil {
+		return nil, newError("unable to marshal JSON: %v", err)
+	}
+	return b, nil
+}
+
+// maskedJSON returns v as a value json.Marshal formats like v, with the
+// masked fields of structs replaced and the values of registered codecs
+// formatted as strings.
+func maskedJSON(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Kind() == reflect.Ptr && implementsMarshaler(v.Type()) {
+			return v.Interface()
+		}
+		return maskedJSON(v.Elem())
+	}
+	if format, ok := registeredFormat(v.Type()); ok {
+		return format(v.Interface())
+	}
+	if implementsMarshaler(v.Type()) || implementsMarshaler(reflect.PtrTo(v.Type())) {
+		return addressable(v).Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		var object jsonObject
+		appendMaskedFields(&object, v)
+		return object
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		fallthrough
+	case reflect.Array:
+		elems := make([]interface{}, v.Len())
+		for i := range elems {
+			elems[i] = maskedJSON(v.Index(i))
+		}
+		return elems
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key, err := formatValue(iter.Key())
+			if err != nil {
+				key = ""
+			}
+			m[key] = maskedJSON(iter.Value())
+		}
+		return m
+	}
+	return v.Interface()
+}
+
+// appendMaskedFields appends the exported fields of the struct v to object,
+// flattening embedded structs as encoding/json does.
+func appendMaskedFields(object *jsonObject, v reflect.Value) {
+	var typee = v.Type()
+	for i := 0; i < typee.NumField(); i++ {
+		sf := typee.Field(i)
+		field := v.Field(i)
+		name, opts := parseKeyForOption(sf.Tag.Get("json"))
+		if name == "-" && len(opts) == 0 {
+			continue
+		}
+		if sf.Anonymous && name == "" {
+			embedded := field
+			if embedded.Kind() == reflect.Ptr {
+				if embedded.IsNil() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct && !implementsMarshaler(sf.Type) {
+				appendMaskedFields(object, embedded)
+				continue
+			}
+		}
+		if sf.PkgPath != "" {
+			continue
+		}
+		if hasOption(opts, "omitempty") && field.IsZero() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		var value interface{} = maskedValue
+		if !isMasked(sf) {
+			value = maskedJSON(field)
+		}
+		*object = append(*object, jsonField{key: name, value: value})
+	}
+}
+
+func implementsMarshaler(typee reflect.Type) bool {
+	return typee.Implements(jsonMarshalerType) || typee.Implements(textMarshalerType)
+}
+
+// jsonObject is a JSON object which keeps the order of its fields.
+type jsonObject []jsonField
+
+type jsonField struct {
+	key   string
+	value interface{}
+}
+
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(field.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(field.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
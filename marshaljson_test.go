@@ -0,0 +1,71 @@
+package conf_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type maskedDatabase struct {
+	Host     string `env:"DB_HOST" json:"host"`
+	Password string `env:"DB_PASSWORD" mask:"true" json:"password"`
+}
+
+type maskedServer struct {
+	Name  string `json:"name"`
+	Token string `secret:"TOKEN"`
+}
+
+type maskedEmbedded struct {
+	Region string `env:"REGION"`
+	APIKey string `env:"API_KEY" mask:"true"`
+}
+
+func TestMarshalJSONMasked(t *testing.T) {
+	type config struct {
+		maskedEmbedded
+		Port     int                       `env:"PORT" json:"port"`
+		Timeout  time.Duration             `env:"TIMEOUT"`
+		Started  time.Time                 `json:"started"`
+		DB       maskedDatabase            `json:"db"`
+		Replica  *maskedDatabase           `json:"replica"`
+		Missing  *maskedDatabase           `json:"missing,omitempty"`
+		Servers  []maskedServer            `json:"servers"`
+		ByName   map[string]maskedDatabase `json:"by_name"`
+		Tokens   []string                  `secret:"TOKENS" json:"tokens"`
+		Login    conf.Credentials          `env:"LOGIN" json:"login"`
+		Ignored  string                    `json:"-"`
+		internal string
+	}
+
+	cfg := config{
+		maskedEmbedded: maskedEmbedded{Region: "eu-west-1", APIKey: "key"},
+		Port:           8080,
+		Timeout:        time.Second,
+		Started:        time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		DB:             maskedDatabase{Host: "db.local", Password: "s3cret"},
+		Replica:        &maskedDatabase{Host: "replica.local", Password: "s3cret"},
+		Servers:        []maskedServer{{Name: "a", Token: "t1"}, {Name: "b", Token: "t2"}},
+		ByName:         map[string]maskedDatabase{"main": {Host: "main.local", Password: "s3cret"}},
+		Tokens:         []string{"t1", "t2"},
+		Login:          conf.Credentials{Username: "app", Password: "s3cret"},
+		Ignored:        "ignored",
+		internal:       "internal",
+	}
+
+	b, err := conf.MarshalJSONMasked(&cfg)
+	require.NoError(t, err)
+	assert.Equal(t, `{"Region":"eu-west-1","APIKey":"***","port":8080,"Timeout":1000000000,"started":"2024-01-02T03:04:05Z",`+
+		`"db":{"host":"db.local","password":"***"},"replica":{"host":"replica.local","password":"***"},`+
+		`"servers":[{"name":"a","Token":"***"},{"name":"b","Token":"***"}],"by_name":{"main":{"host":"main.local","password":"***"}},`+
+		`"tokens":"***","login":{"Username":"app","Password":"***"}}`, string(b))
+	assert.NotContains(t, string(b), "s3cret")
+}
+
+func TestMarshalJSONMaskedNotAStruct(t *testing.T) {
+	_, err := conf.MarshalJSONMasked("config")
+	assert.Equal(t, conf.ErrNotAStructPtr, err)
+}
@@ -0,0 +1,47 @@
+package conf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// MissingRequiredError is returned by `ParseWithOptions` with
+// `WithAllMissingRequired` when required variables are not set. It lists
+// every one of them rather than only the first, and is retrieved with
+// `errors.As`.
+type MissingRequiredError struct {
+	Missing []MissingVariable
+	prefix  string
+}
+
+// MissingVariable is a required variable which is not set.
+type MissingVariable struct {
+	// Key is the key the variable was looked up with.
+	Key string
+	// Field is the name of the struct field it is read into.
+	Field string
+}
+
+func (e *MissingRequiredError) Error() string {
+	vars := make([]string, len(e.Missing))
+	for i, m := range e.Missing {
+		vars[i] = fmt.Sprintf(`%q (field "%s")`, m.Key, m.Field)
+	}
+	return fmt.Sprintf(`%s: required environment variables are not set: %s`, errorPrefix(e.prefix), strings.Join(vars, ", "))
+}
+
+// add records key of sf once, whichever pass finds it missing.
+func (e *MissingRequiredError) add(sf reflect.StructField, key string) {
+	for _, m := range e.Missing {
+		if m.Key == key && m.Field == sf.Name {
+			return
+		}
+	}
+	e.Missing = append(e.Missing, MissingVariable{Key: key, Field: sf.Name})
+}
+
+// newMissingError returns the error of a required key which is not set.
+func newMissingError(key string) error {
+	return prefixedError{msg: fmt.Sprintf(`required environment variable %q is not set`, key), missing: key}
+}
@@ -0,0 +1,87 @@
+package conf_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllMissingRequired(t *testing.T) {
+	type database struct {
+		Host string `env:"HOST,required"`
+		Port int    `env:"PORT" envMissing:"error"`
+	}
+	type config struct {
+		Name     string   `env:"NAME,required"`
+		Level    string   `env:"LEVEL,required"`
+		Database database `envPrefix:"DB_"`
+	}
+
+	cfg := config{}
+	err := conf.ParseWithOptions(&cfg, []conf.Option{conf.WithAllMissingRequired()}, conf.NewMapProvider(map[string]string{
+		"LEVEL": "debug",
+	}))
+
+	var missing *conf.MissingRequiredError
+	require.True(t, errors.As(err, &missing))
+	assert.Equal(t, []conf.MissingVariable{
+		{Key: "NAME", Field: "Name"},
+		{Key: "DB_HOST", Field: "Host"},
+		{Key: "DB_PORT", Field: "Port"},
+	}, missing.Missing)
+	assert.EqualError(t, err, `env: required environment variables are not set: "NAME" (field "Name"), "DB_HOST" (field "Host"), "DB_PORT" (field "Port")`)
+	assert.Equal(t, "debug", cfg.Level)
+}
+
+func TestAllMissingRequiredSet(t *testing.T) {
+	type config struct {
+		Name string `env:"NAME,required"`
+	}
+
+	cfg := config{}
+	require.NoError(t, conf.ParseWithOptions(&cfg, []conf.Option{conf.WithAllMissingRequired()}, conf.NewMapProvider(map[string]string{
+		"NAME": "api",
+	})))
+	assert.Equal(t, "api", cfg.Name)
+}
+
+func TestAllMissingRequiredProviders(t *testing.T) {
+	type config struct {
+		Name string `env:"NAME,required"`
+	}
+
+	cfg := config{}
+	err := conf.ParseWithOptions(&cfg, []conf.Option{conf.WithAllMissingRequired(), conf.WithErrorPrefix("config")},
+		conf.NewMapProvider(map[string]string{}),
+		conf.NewMapProvider(map[string]string{}))
+	assert.EqualError(t, err, `config: required environment variables are not set: "NAME" (field "Name")`)
+}
+
+func TestAllMissingRequiredOtherErrors(t *testing.T) {
+	type config struct {
+		Name string `env:"NAME,required"`
+		Port int    `env:"PORT"`
+	}
+
+	cfg := config{}
+	err := conf.ParseWithOptions(&cfg, []conf.Option{conf.WithAllMissingRequired()}, conf.NewMapProvider(map[string]string{
+		"PORT": "http",
+	}))
+	var missing *conf.MissingRequiredError
+	assert.False(t, errors.As(err, &missing))
+	assert.Contains(t, err.Error(), `parse error on field "Port"`)
+}
+
+func TestMissingRequiredFailsFast(t *testing.T) {
+	type config struct {
+		Name  string `env:"NAME,required"`
+		Level string `env:"LEVEL,required"`
+	}
+
+	cfg := config{}
+	err := conf.ParseWithOptions(&cfg, nil, conf.NewMapProvider(map[string]string{}))
+	assert.EqualError(t, err, `env: required environment variable "NAME" is not set`)
+}
@@ -0,0 +1,27 @@
+package conf
+
+import "reflect"
+
+// checkMustProvide enforces the `mustProvide` tag option. It runs once every
+// provider has been applied, so any of them can supply the value, but a value
+// from `envDefault` or a defaults provider does not count.
+func checkMustProvide(ref reflect.Value, opts options, provided map[resolvedKey]bool) error {
+	return walkFields(ref, opts, func(s walkedStruct) error {
+		for _, i := range s.fields {
+			if hasTagOption(s.ref.Type().Field(i), "mustProvide") && !provided[newResolvedKey(s.ref.Field(i))] {
+				return newError(`environment variable %q is not provided by any source`, s.key(i))
+			}
+		}
+		return nil
+	})
+}
+
+// hasTagOption reports whether the `env` or `secret` tag of sf has option.
+func hasTagOption(sf reflect.StructField, option string) bool {
+	for _, tag := range []string{"env", "secret"} {
+		if _, opts := parseKeyForOption(sf.Tag.Get(tag)); hasOption(opts, option) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package conf_test
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMustProvideDefaultOnly(t *testing.T) {
+	type config struct {
+		Region string `env:"REGION,mustProvide" envDefault:"eu-west-1"`
+	}
+	defer os.Clearenv()
+
+	cfg := config{}
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: environment variable "REGION" is not provided by any source`)
+
+	os.Setenv("REGION", "us-east-1")
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, "us-east-1", cfg.Region)
+}
+
+func TestMustProvideAnyProvider(t *testing.T) {
+	type config struct {
+		Region string `env:"REGION,mustProvide"`
+		Token  string `secret:"TOKEN,mustProvide"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("TOKEN", "s3cr3t")
+	dotenv, err := conf.NewDotenvProvider(strings.NewReader("REGION=us-east-1"))
+	require.NoError(t, err)
+
+	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider, conf.SecretEnvProvider, dotenv))
+	assert.Equal(t, config{Region: "us-east-1", Token: "s3cr3t"}, cfg)
+}
+
+func TestMustProvideNested(t *testing.T) {
+	type database struct {
+		Host string `env:"HOST,mustProvide" envDefault:"localhost"`
+	}
+	type config struct {
+		Database *database `envPrefix:"DB_"`
+	}
+	defer os.Clearenv()
+
+	cfg := config{Database: &database{}}
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: environment variable "DB_HOST" is not provided by any source`)
+}
+
+func TestMustProvideIgnoresDefaultsProvider(t *testing.T) {
+	type config struct {
+		Region string `env:"REGION,mustProvide"`
+	}
+	defer os.Clearenv()
+
+	defaults := conf.NewMapProvider(map[string]string{"REGION": "eu-west-1"})
+
+	cfg := config{}
+	err := conf.ParseWithOptions(&cfg, []conf.Option{conf.WithDefaultsProvider(defaults)}, conf.EnvProvider)
+	assert.EqualError(t, err, `env: environment variable "REGION" is not provided by any source`)
+}
+
+func TestMustProvideChain(t *testing.T) {
+	type config struct {
+		Region string `env:"REGION,mustProvide" envDefault:"eu-west-1"`
+	}
+	defer os.Clearenv()
+
+	dotenv, err := conf.NewDotenvProvider(strings.NewReader(""))
+	require.NoError(t, err)
+
+	cfg := config{}
+	assert.EqualError(t, conf.Parse(&cfg, conf.NewChainProvider(conf.EnvProvider, dotenv)), `env: environment variable "REGION" is not provided by any source`)
+}
+
+func TestMustProvideCustomProvider(t *testing.T) {
+	type config struct {
+		Region string `env:"REGION,mustProvide" envDefault:"eu-west-1"`
+	}
+
+	cfg := config{}
+	err := conf.ParseWithFuncs(&cfg, nil, providerFunc(func(sf reflect.StructField) (string, error) {
+		return "us-east-1", nil
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, "us-east-1", cfg.Region)
+}
@@ -0,0 +1,119 @@
+package conf
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// KeyNamer derives the key of a field which has no key in its tag. prefix is
+// the `envPrefix` of the struct fields the field is nested in.
+type KeyNamer interface {
+	Name(field reflect.StructField, prefix string) string
+}
+
+// nolint: gochecknoglobals
+var (
+	// ScreamingSnakeNamer names fields like environment variables, e.g. the
+	// field MaxConns with the prefix "DB_" is DB_MAX_CONNS.
+	ScreamingSnakeNamer KeyNamer = screamingSnakeNamer{}
+	// DottedLowerNamer names fields like the paths of a document, e.g. the
+	// field MaxConns with the prefix "db." is db.max_conns.
+	DottedLowerNamer KeyNamer = dottedLowerNamer{}
+)
+
+// tagNamer is the KeyNamer of the providers of this package unless
+// WithKeyNamer is used. Fields are only read by the key in their tag.
+type tagNamer struct{}
+
+func (tagNamer) Name(reflect.StructField, string) string {
+	return ""
+}
+
+type screamingSnakeNamer struct{}
+
+func (screamingSnakeNamer) Name(field reflect.StructField, prefix string) string {
+	return prefix + strings.ToUpper(strings.Join(splitWords(field.Name), "_"))
+}
+
+type dottedLowerNamer struct{}
+
+func (dottedLowerNamer) Name(field reflect.StructField, prefix string) string {
+	return prefix + strings.ToLower(strings.Join(splitWords(field.Name), "_"))
+}
+
+// WithKeyNamer returns provider with its fields which have no key in their
+// tag named by namer. Explicit keys are still used as they are. The
+// providers of this package take the namer directly, other providers have the
+// derived key added to the `env` tag of the fields passed to them.
+func WithKeyNamer(provider Provider, namer KeyNamer) Provider {
+	if o, ok := provider.(envProvider); ok {
+		o.namer = namer
+		return o
+	}
+	return namedKeysProvider{inner: provider, namer: namer}
+}
+
+type namedKeysProvider struct {
+	inner Provider
+	namer KeyNamer
+}
+
+func (p namedKeysProvider) withOptions(opts options) Provider {
+	if c, ok := p.inner.(configurableProvider); ok {
+		p.inner = c.withOptions(opts)
+	}
+	return p
+}
+
+func (p namedKeysProvider) Provide(field reflect.StructField) (string, error) {
+	result, err := p.ProvideResult(field)
+	return result.Value, err
+}
+
+func (p namedKeysProvider) ProvideResult(field reflect.StructField) (Result, error) {
+	if key, _ := parseKeyForOption(field.Tag.Get("env")); key == "" {
+		if name := p.namer.Name(field, field.Tag.Get(keyPrefixTag)); name != "" {
+			tags := parseTag(field.Tag)
+			tags = append(tags, structTag{name: "env", value: name})
+			field.Tag = formatTag(tags)
+		}
+	}
+	if rp, ok := p.inner.(ResultProvider); ok {
+		return rp.ProvideResult(field)
+	}
+	value, err := p.inner.Provide(field)
+	return Result{Value: value}, err
+}
+
+// splitWords splits a Go identifier into its words, keeping initialisms
+// together, e.g. HTTPServerURL is HTTP, Server, URL.
+func splitWords(name string) []string {
+	runes := []rune(name)
+	var words []string
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		prev, cur := runes[i-1], runes[i]
+		var next rune
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+		switch {
+		case cur == '_':
+			if start < i {
+				words = append(words, string(runes[start:i]))
+			}
+			start = i + 1
+		case unicode.IsUpper(cur) && (unicode.IsLower(prev) || unicode.IsDigit(prev)),
+			unicode.IsUpper(cur) && unicode.IsUpper(prev) && unicode.IsLower(next):
+			if start < i {
+				words = append(words, string(runes[start:i]))
+			}
+			start = i
+		}
+	}
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
@@ -0,0 +1,108 @@
+package conf_test
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScreamingSnakeNamer(t *testing.T) {
+	type database struct {
+		MaxConns int
+		Host     string `env:"DATABASE_HOST"`
+	}
+	type config struct {
+		HTTPServerURL string
+		Database      database `envPrefix:"DB_"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("HTTP_SERVER_URL", "http://localhost")
+	os.Setenv("DB_MAX_CONNS", "10")
+	os.Setenv("DB_DATABASE_HOST", "db.local")
+
+	var cfg config
+	assert.NoError(t, conf.Parse(&cfg, conf.WithKeyNamer(conf.EnvProvider, conf.ScreamingSnakeNamer)))
+	assert.Equal(t, "http://localhost", cfg.HTTPServerURL)
+	assert.Equal(t, 10, cfg.Database.MaxConns)
+	assert.Equal(t, "db.local", cfg.Database.Host)
+}
+
+func TestKeyNamerKeepsTagOptions(t *testing.T) {
+	type config struct {
+		Port int `env:",required"`
+	}
+	defer os.Clearenv()
+
+	var cfg config
+	err := conf.Parse(&cfg, conf.WithKeyNamer(conf.EnvProvider, conf.ScreamingSnakeNamer))
+	assert.EqualError(t, err, `env: required environment variable "PORT" is not set`)
+}
+
+func TestWithoutKeyNamer(t *testing.T) {
+	type config struct {
+		MaxConns int
+	}
+	defer os.Clearenv()
+
+	os.Setenv("MAX_CONNS", "10")
+
+	var cfg config
+	assert.NoError(t, conf.Parse(&cfg))
+	assert.Equal(t, 0, cfg.MaxConns)
+}
+
+func TestDottedLowerNamer(t *testing.T) {
+	type database struct {
+		MaxConns int
+	}
+	type config struct {
+		LogLevel string
+		Database database `envPrefix:"db."`
+	}
+
+	provider := conf.WithKeyNamer(providerFunc(func(sf reflect.StructField) (string, error) {
+		return map[string]string{
+			"log_level":    "debug",
+			"db.max_conns": "5",
+		}[sf.Tag.Get("env")], nil
+	}), conf.DottedLowerNamer)
+
+	var cfg config
+	assert.NoError(t, conf.Parse(&cfg, provider))
+	assert.Equal(t, "debug", cfg.LogLevel)
+	assert.Equal(t, 5, cfg.Database.MaxConns)
+}
+
+func TestKeyNamerWithJSONProvider(t *testing.T) {
+	type config struct {
+		LogLevel string
+	}
+
+	provider, err := conf.NewJSONProvider(strings.NewReader(`{"log_level": "warn"}`))
+	assert.NoError(t, err)
+
+	var cfg config
+	assert.NoError(t, conf.Parse(&cfg, conf.WithKeyNamer(provider, conf.DottedLowerNamer)))
+	assert.Equal(t, "warn", cfg.LogLevel)
+}
+
+func TestKeyNamerWords(t *testing.T) {
+	for name, expected := range map[string]string{
+		"Port":          "PORT",
+		"MaxConns":      "MAX_CONNS",
+		"HTTPServerURL": "HTTP_SERVER_URL",
+		"APIKey":        "API_KEY",
+		"OAuth2Token":   "O_AUTH2_TOKEN",
+		"Retry_Count":   "RETRY_COUNT",
+		"ID":            "ID",
+	} {
+		field := reflect.StructField{Name: name}
+		assert.Equal(t, expected, conf.ScreamingSnakeNamer.Name(field, ""), name)
+		assert.Equal(t, "app."+strings.ToLower(expected), conf.DottedLowerNamer.Name(field, "app."), name)
+	}
+}
@@ -0,0 +1,78 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// isNested reports whether a struct field is tagged with `envNested:"true"`.
+func isNested(sf reflect.StructField) bool {
+	return strings.ToLower(sf.Tag.Get("envNested")) == "true"
+}
+
+// parseNested parses a struct field tagged with `envNested` from the JSON
+// object provided for it. The fields of the struct are read from the object
+// with the same providers, tags and parsers as everywhere else, so a
+// duration or URL inside the object is parsed like an environment variable.
+// Without a value the fields are read by the provider of the pass, as for any
+// other struct.
+func (p *parser) parseNested(field reflect.Value, sf reflect.StructField) error {
+	var typee = sf.Type
+	if typee.Kind() == reflect.Ptr {
+		typee = typee.Elem()
+	}
+	if typee.Kind() != reflect.Struct {
+		return newError(`field "%s" has envNested but is not a struct`, sf.Name)
+	}
+	if p.fallback && p.resolved[newResolvedKey(field)] {
+		return nil
+	}
+
+	result, err := p.provide(sf)
+	if err != nil {
+		return err
+	}
+	if result.Value == "" {
+		if field.Kind() == reflect.Ptr {
+			if field.IsNil() {
+				return nil
+			}
+			return p.withPrefix(sf).parsePtr(field.Interface())
+		}
+		return p.withPrefix(sf).parse(field)
+	}
+	if !result.Default && !p.fallback && p.provided != nil {
+		p.provided[newResolvedKey(field)] = true
+	}
+
+	dec := json.NewDecoder(strings.NewReader(result.Value))
+	dec.UseNumber()
+	var doc map[string]interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return newParseError(sf, fmt.Errorf("unable to decode JSON: %v", err))
+	}
+	if err := p.checkSchema(sf, []byte(result.Value)); err != nil {
+		return err
+	}
+	nested := *p
+	nested.provider = newJSONDocProvider(doc).withOptions(p.opts)
+	nested.prefix = ""
+
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			field.Set(reflect.New(typee))
+		}
+		err = nested.parsePtr(field.Interface())
+	} else {
+		err = nested.parse(field)
+	}
+	if err != nil {
+		return err
+	}
+	if p.resolved != nil {
+		p.resolved[newResolvedKey(field)] = true
+	}
+	return nil
+}
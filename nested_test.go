@@ -0,0 +1,82 @@
+package conf_test
+
+import (
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type nestedRetry struct {
+	Attempts int           `env:"attempts" envDefault:"3"`
+	Backoff  time.Duration `env:"backoff" envParser:"iso8601"`
+}
+
+type nestedUpstream struct {
+	Endpoint url.URL       `env:"endpoint,required"`
+	Timeout  time.Duration `env:"timeout"`
+	Tags     []string      `env:"tags"`
+	Retry    nestedRetry   `env:"retry" envNested:"true"`
+}
+
+func TestNested(t *testing.T) {
+	type config struct {
+		Upstream    nestedUpstream  `env:"UPSTREAM" envNested:"true"`
+		UpstreamPtr *nestedUpstream `env:"UPSTREAM" envNested:"true"`
+		Missing     *nestedUpstream `env:"MISSING" envNested:"true"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("UPSTREAM", `{"endpoint": "https://api.example.com/v1", "timeout": "1m30s", "tags": ["a", "b"], "retry": {"backoff": "PT2S"}}`)
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	expected := nestedUpstream{
+		Endpoint: url.URL{Scheme: "https", Host: "api.example.com", Path: "/v1"},
+		Timeout:  90 * time.Second,
+		Tags:     []string{"a", "b"},
+		Retry:    nestedRetry{Attempts: 3, Backoff: 2 * time.Second},
+	}
+	assert.Equal(t, expected, cfg.Upstream)
+	assert.Equal(t, &expected, cfg.UpstreamPtr)
+	assert.Nil(t, cfg.Missing)
+}
+
+func TestNestedWithoutValue(t *testing.T) {
+	type config struct {
+		Upstream nestedUpstream `envNested:"true" envPrefix:"UPSTREAM_"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("UPSTREAM_endpoint", "https://api.example.com")
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, "api.example.com", cfg.Upstream.Endpoint.Host)
+	assert.Equal(t, 3, cfg.Upstream.Retry.Attempts)
+}
+
+func TestNestedErrors(t *testing.T) {
+	type config struct {
+		Upstream nestedUpstream `env:"UPSTREAM" envNested:"true"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("UPSTREAM", `{"timeout": "5s"}`)
+	assert.EqualError(t, conf.Parse(&config{}, conf.EnvProvider), `env: required environment variable "endpoint" is not set`)
+
+	os.Setenv("UPSTREAM", `{"endpoint": "https://api.example.com", "timeout": "soon"}`)
+	assert.EqualError(t, conf.Parse(&config{}, conf.EnvProvider), `env: parse error on field "Timeout" of type "time.Duration": unable to parser duration: time: invalid duration "soon"`)
+
+	os.Setenv("UPSTREAM", `["https://api.example.com"]`)
+	assert.EqualError(t, conf.Parse(&config{}, conf.EnvProvider), `env: parse error on field "Upstream" of type "conf_test.nestedUpstream": unable to decode JSON: json: cannot unmarshal array into Go value of type map[string]interface {}`)
+
+	type notAStruct struct {
+		Upstream string `env:"UPSTREAM" envNested:"true"`
+	}
+	assert.EqualError(t, conf.Parse(&notAStruct{}, conf.EnvProvider), `env: field "Upstream" has envNested but is not a struct`)
+}
@@ -0,0 +1,137 @@
+package conf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// checkOneOf validates the value set on field, or each of its elements for a
+// slice, against the comma separated values of the `envOneOf` tag of sf or,
+// without the tag, against the values returned by a `Values() []T` or
+// `Values() []string` method of its type. When the type has a type parser,
+// such as time.Duration, the values of the tag are parsed and compared with
+// the value rather than compared as strings, so "60s" is one of "1m". With
+// `envIgnoreCase:"true"` strings are matched case insensitively and set to
+// the allowed value they match, so "DEBUG" is stored as "debug".
+func (p *parser) checkOneOf(field reflect.Value, sf reflect.StructField) error {
+	field = indirect(field)
+	if field.Kind() == reflect.Slice {
+		if _, ok := sf.Tag.Lookup("envOneOf"); !ok && !hasValuesMethod(field.Type().Elem()) {
+			// skip looking up the method of every element of long lists
+			return nil
+		}
+		for i := 0; i < field.Len(); i++ {
+			if err := p.checkOneOfValue(indirect(field.Index(i)), sf); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return p.checkOneOfValue(field, sf)
+}
+
+func (p *parser) checkOneOfValue(v reflect.Value, sf reflect.StructField) error {
+	if !v.IsValid() {
+		return nil
+	}
+	allowed := splitKeys(sf.Tag.Get("envOneOf"))
+	if allowed == nil {
+		var ok bool
+		if allowed, ok = valuesOf(v); !ok {
+			return nil
+		}
+	} else if parserFunc, ok := p.typeParser(v.Type()); ok {
+		return checkParsedOneOf(v, sf, allowed, parserFunc)
+	}
+	s := formatOneOf(v)
+	for _, a := range allowed {
+		if a == s {
+			return nil
+		}
+	}
+	if strings.ToLower(sf.Tag.Get("envIgnoreCase")) == "true" {
+		for _, a := range allowed {
+			if !strings.EqualFold(a, s) {
+				continue
+			}
+			if v.Kind() == reflect.String && v.CanSet() {
+				// the value is stored in the one canonical case
+				v.SetString(a)
+			}
+			return nil
+		}
+	}
+	return newParseError(sf, fmt.Errorf("unknown value %q, expected one of %s", s, strings.Join(allowed, ", ")))
+}
+
+// checkParsedOneOf compares v with the values of the `envOneOf` tag parsed by
+// parserFunc.
+func checkParsedOneOf(v reflect.Value, sf reflect.StructField, allowed []string, parserFunc ParserFunc) error {
+	for _, a := range allowed {
+		parsed, err := parserFunc(a)
+		if err != nil {
+			return newError(`field "%s" has invalid envOneOf value %q: %v`, sf.Name, a, err)
+		}
+		pv := reflect.ValueOf(parsed)
+		if pv.Kind() == reflect.Ptr && v.Kind() != reflect.Ptr {
+			pv = pv.Elem()
+		}
+		if pv.IsValid() && reflect.DeepEqual(pv.Interface(), v.Interface()) {
+			return nil
+		}
+	}
+	return newParseError(sf, fmt.Errorf("unknown value %q, expected one of %s", formatOneOf(v), strings.Join(allowed, ", ")))
+}
+
+// hasValuesMethod reports whether typee, the type it points to or its
+// pointer has a Values method.
+func hasValuesMethod(typee reflect.Type) bool {
+	if typee.Kind() == reflect.Ptr {
+		typee = typee.Elem()
+	}
+	_, ok := reflect.PtrTo(typee).MethodByName("Values")
+	return ok
+}
+
+// valuesOf returns the values of the Values method of the type of v, if it
+// has one which returns a slice of its type or of strings.
+func valuesOf(v reflect.Value) ([]string, bool) {
+	method := v.MethodByName("Values")
+	if !method.IsValid() && v.CanAddr() {
+		method = v.Addr().MethodByName("Values")
+	}
+	if !method.IsValid() {
+		return nil, false
+	}
+	t := method.Type()
+	if t.NumIn() != 0 || t.NumOut() != 1 || t.Out(0).Kind() != reflect.Slice {
+		return nil, false
+	}
+	if elem := t.Out(0).Elem(); elem != v.Type() && elem.Kind() != reflect.String {
+		return nil, false
+	}
+	values := method.Call(nil)[0]
+	allowed := make([]string, values.Len())
+	for i := range allowed {
+		allowed[i] = formatOneOf(values.Index(i))
+	}
+	return allowed, true
+}
+
+func formatOneOf(v reflect.Value) string {
+	if v.Kind() == reflect.String {
+		return v.String()
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
@@ -0,0 +1,182 @@
+package conf_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+)
+
+type logLevel string
+
+func (logLevel) Values() []logLevel {
+	return []logLevel{"debug", "info", "error"}
+}
+
+type region string
+
+func (*region) Values() []string {
+	return []string{"eu-west-1", "us-east-1"}
+}
+
+func TestValuesMethod(t *testing.T) {
+	type config struct {
+		Level    logLevel   `env:"LEVEL"`
+		LevelPtr *logLevel  `env:"LEVEL"`
+		Levels   []logLevel `env:"LEVELS"`
+		Region   region     `env:"REGION"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("LEVEL", "info")
+	os.Setenv("LEVELS", "debug,error")
+	os.Setenv("REGION", "us-east-1")
+
+	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, logLevel("info"), cfg.Level)
+	assert.Equal(t, logLevel("info"), *cfg.LevelPtr)
+	assert.Equal(t, []logLevel{"debug", "error"}, cfg.Levels)
+	assert.Equal(t, region("us-east-1"), cfg.Region)
+}
+
+func TestValuesMethodErrors(t *testing.T) {
+	type config struct {
+		Level  logLevel   `env:"LEVEL"`
+		Levels []logLevel `env:"LEVELS"`
+		Region region     `env:"REGION"`
+	}
+
+	for name, tc := range map[string]struct {
+		key, value, err string
+	}{
+		"value": {
+			key: "LEVEL", value: "trace",
+			err: `env: parse error on field "Level" of type "conf_test.logLevel": unknown value "trace", expected one of debug, info, error`,
+		},
+		"slice": {
+			key: "LEVELS", value: "debug,trace",
+			err: `env: parse error on field "Levels" of type "[]conf_test.logLevel": unknown value "trace", expected one of debug, info, error`,
+		},
+		"pointer receiver": {
+			key: "REGION", value: "mars-1",
+			err: `env: parse error on field "Region" of type "conf_test.region": unknown value "mars-1", expected one of eu-west-1, us-east-1`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer os.Clearenv()
+			os.Setenv(tc.key, tc.value)
+
+			cfg := config{}
+			assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), tc.err)
+		})
+	}
+}
+
+func TestOneOf(t *testing.T) {
+	type config struct {
+		Mode  string   `env:"MODE" envOneOf:"fast,safe"`
+		Ports []int    `env:"PORTS" envOneOf:"80,443"`
+		Level logLevel `env:"LEVEL" envOneOf:"debug,trace"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("MODE", "safe")
+	os.Setenv("PORTS", "443,80")
+	os.Setenv("LEVEL", "trace")
+
+	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, config{Mode: "safe", Ports: []int{443, 80}, Level: "trace"}, cfg)
+
+	os.Setenv("LEVEL", "info")
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: parse error on field "Level" of type "conf_test.logLevel": unknown value "info", expected one of debug, trace`)
+}
+
+func TestOneOfDefault(t *testing.T) {
+	type config struct {
+		Mode string `env:"MODE" envOneOf:"fast,safe" envDefault:"slow"`
+	}
+
+	cfg := config{}
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: parse error on field "Mode" of type "string": unknown value "slow", expected one of fast, safe`)
+}
+
+func TestOneOfIgnoreCase(t *testing.T) {
+	type config struct {
+		Level    string   `env:"LEVEL" envOneOf:"debug,info,Warn" envIgnoreCase:"true"`
+		Levels   []string `env:"LEVELS" envOneOf:"debug,info,Warn" envIgnoreCase:"true"`
+		LevelPtr *string  `env:"LEVEL" envOneOf:"debug,info" envIgnoreCase:"true"`
+		Values   logLevel `env:"LEVEL" envIgnoreCase:"true"`
+		Region   region   `env:"REGION" envIgnoreCase:"true"`
+		Exact    []string `env:"EXACT" envOneOf:"Info,info" envIgnoreCase:"true"`
+		Strict   string   `env:"STRICT" envOneOf:"debug,info"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("LEVEL", "DEBUG")
+	os.Setenv("LEVELS", "Info,WARN,warn,debug")
+	os.Setenv("REGION", "EU-West-1")
+	os.Setenv("EXACT", "info,INFO")
+	os.Setenv("STRICT", "info")
+
+	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, "debug", cfg.Level)
+	assert.Equal(t, []string{"info", "Warn", "Warn", "debug"}, cfg.Levels)
+	assert.Equal(t, "debug", *cfg.LevelPtr)
+	assert.Equal(t, logLevel("debug"), cfg.Values)
+	assert.Equal(t, region("eu-west-1"), cfg.Region)
+	assert.Equal(t, []string{"info", "Info"}, cfg.Exact)
+
+	os.Setenv("STRICT", "INFO")
+	assert.EqualError(t, conf.Parse(&config{}, conf.EnvProvider), `env: parse error on field "Strict" of type "string": unknown value "INFO", expected one of debug, info`)
+
+	os.Setenv("LEVEL", "verbose")
+	assert.EqualError(t, conf.Parse(&config{}, conf.EnvProvider), `env: parse error on field "Level" of type "string": unknown value "verbose", expected one of debug, info, Warn`)
+}
+
+func TestOneOfParsedValues(t *testing.T) {
+	type config struct {
+		Interval  time.Duration    `env:"INTERVAL" envOneOf:"10s,30s,1m"`
+		Intervals []time.Duration  `env:"INTERVALS" envOneOf:"10s,30s,1m"`
+		Retention conf.ExtDuration `env:"RETENTION" envOneOf:"1d,1w"`
+		Period    time.Duration    `env:"PERIOD" envParser:"iso8601" envOneOf:"PT1M,PT1H"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("INTERVAL", "60s")
+	os.Setenv("INTERVALS", "0.5m,10000ms")
+	os.Setenv("RETENTION", "24h")
+	os.Setenv("PERIOD", "PT60M")
+
+	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, time.Minute, cfg.Interval)
+	assert.Equal(t, []time.Duration{30 * time.Second, 10 * time.Second}, cfg.Intervals)
+	assert.Equal(t, conf.ExtDuration(24*time.Hour), cfg.Retention)
+	assert.Equal(t, time.Hour, cfg.Period)
+}
+
+func TestOneOfParsedValuesErrors(t *testing.T) {
+	defer os.Clearenv()
+	os.Setenv("INTERVAL", "45s")
+
+	t.Run("not allowed", func(t *testing.T) {
+		type config struct {
+			Interval time.Duration `env:"INTERVAL" envOneOf:"10s,30s,1m"`
+		}
+		cfg := config{}
+		assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: parse error on field "Interval" of type "time.Duration": unknown value "45s", expected one of 10s, 30s, 1m`)
+	})
+
+	t.Run("invalid allowed value", func(t *testing.T) {
+		type config struct {
+			Interval time.Duration `env:"INTERVAL" envOneOf:"10s,often"`
+		}
+		cfg := config{}
+		assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: field "Interval" has invalid envOneOf value "often": unable to parser duration: time: invalid duration "often"`)
+	})
+}
@@ -0,0 +1,189 @@
+package conf
+
+import (
+	"context"
+	"reflect"
+	"time"
+)
+
+// Option configures how `ParseWithOptions` parses a struct.
+type Option func(*options)
+
+type options struct {
+	warningHandler func(warning string)
+	fieldFilter    func(sf reflect.StructField) bool
+	errorPrefix    string
+	inCodeDefaults bool
+	looseBools     bool
+	strictRequired bool
+	auditSink      func(event AuditEvent)
+	defaults       Provider
+	neverEcho      bool
+	allMissing     bool
+	// schemaValidator validates the JSON values of fields with `envSchema`.
+	schemaValidator SchemaValidator
+	clock           func() time.Time
+	ctx             context.Context
+	validators      bool
+}
+
+// WithWarningHandler sets a function which is called with every non-fatal
+// warning raised while parsing, such as a value being read from a key
+// declared in `envDeprecated`.
+func WithWarningHandler(fn func(warning string)) Option {
+	return func(o *options) {
+		o.warningHandler = fn
+	}
+}
+
+// WithFieldFilter only parses the fields for which filter returns true. The
+// filter is applied to the fields of nested structs too, and a rejected struct
+// field is skipped along with all of its fields. Rejected fields are never
+// passed to the providers, so they cannot fail as required.
+func WithFieldFilter(filter func(sf reflect.StructField) bool) Option {
+	return func(o *options) {
+		o.fieldFilter = filter
+	}
+}
+
+// WithValidators calls the Validate method of the structs which implement
+// Validator once every provider has been applied, for the struct passed to
+// ParseWithOptions and every struct nested in it, innermost first. Structs
+// with a field rejected by the filter of WithFieldFilter are not validated, as
+// they are only partly parsed.
+func WithValidators() Option {
+	return func(o *options) {
+		o.validators = true
+	}
+}
+
+// WithErrorPrefix replaces the "env" prefix of the errors and warnings
+// reported while parsing, e.g. "cfg" reports `cfg: parse error on field ...`.
+// Three kinds of error keep "env". ErrNotAStructPtr keeps it because it is
+// compared with ==. The errors of provider constructors such as
+// NewEncodedProvider keep it because they are returned before any options
+// apply. Errors which other providers return as they are, such as etcdprovider
+// errors, keep it too.
+func WithErrorPrefix(prefix string) Option {
+	return func(o *options) {
+		o.errorPrefix = prefix
+	}
+}
+
+// WithInCodeDefaults treats the values a struct holds before parsing as its
+// defaults. Fields are only changed by values found in a provider's source and
+// `envDefault` tags are ignored. Only providers which implement ResultProvider,
+// such as EnvProvider, can report that a value is a default. A field which is
+// not zero satisfies `required` and `notEmpty`, so only fields which are zero
+// and missing from the source fail.
+func WithInCodeDefaults() Option {
+	return func(o *options) {
+		o.inCodeDefaults = true
+	}
+}
+
+// WithLooseBools accepts human friendly booleans such as yes/no and on/off,
+// ignoring case, for bool fields and the elements of bool slices and maps.
+func WithLooseBools() Option {
+	return func(o *options) {
+		o.looseBools = true
+	}
+}
+
+// WithStrictRequired makes the `required` tag option imply `notEmpty`. By
+// default a required variable only has to be set, so `FOO=` satisfies
+// `env:"FOO,required"`. With strict required values it must also not be empty.
+func WithStrictRequired() Option {
+	return func(o *options) {
+		o.strictRequired = true
+	}
+}
+
+// WithAuditSink sets a function which is called for every field a provider
+// supplies a value for, including defaults, with where the value came from.
+// Values are never passed to the sink.
+func WithAuditSink(sink func(event AuditEvent)) Option {
+	return func(o *options) {
+		o.auditSink = sink
+	}
+}
+
+// WithDefaultsProvider sets a provider which is only consulted for the fields
+// none of the providers passed to `ParseWithOptions` found a value for and
+// which have no `envDefault` tag. Values found in a source take precedence
+// over `envDefault` tags, which take precedence over the defaults provider.
+// Required fields must still be found by one of the providers.
+func WithDefaultsProvider(provider Provider) Option {
+	return func(o *options) {
+		o.defaults = provider
+	}
+}
+
+// WithNeverEchoValues guarantees that no value appears in the errors returned
+// by `ParseWithOptions`. Parse errors only name the field and its type, and
+// errors from providers outside this package, which may quote what they read,
+// are replaced with an error naming the field, as are the errors returned by
+// `Validator` implementations. Errors raised by the providers of this package
+// never include values, only keys and tags.
+func WithNeverEchoValues() Option {
+	return func(o *options) {
+		o.neverEcho = true
+	}
+}
+
+// WithAllMissingRequired keeps parsing when a required variable is not set and
+// then returns a `*MissingRequiredError` listing every one of them, so they can
+// all be fixed at once. Other errors still abort parsing.
+func WithAllMissingRequired() Option {
+	return func(o *options) {
+		o.allMissing = true
+	}
+}
+
+// WithSchemaValidator sets the function which validates the JSON objects read
+// into struct fields tagged with `envSchema` before they are decoded. A value
+// which does not conform fails to parse. Fields with `envSchema` fail without
+// a schema validator.
+func WithSchemaValidator(validate SchemaValidator) Option {
+	return func(o *options) {
+		o.schemaValidator = validate
+	}
+}
+
+// WithClock sets the function which returns the current time, from which
+// relative times such as `EXPIRES=+24h` are resolved. It defaults to
+// time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		o.clock = now
+	}
+}
+
+// WithContext sets the context fields are resolved with. ContextProviders
+// abort their lookups when it is done, and no field is resolved after that,
+// so the parse fails naming the field it was resolving.
+func WithContext(ctx context.Context) Option {
+	return func(o *options) {
+		o.ctx = ctx
+	}
+}
+
+func (o options) now() time.Time {
+	if o.clock == nil {
+		return time.Now()
+	}
+	return o.clock()
+}
+
+func (o options) context() context.Context {
+	if o.ctx == nil {
+		return context.Background()
+	}
+	return o.ctx
+}
+
+func (o options) warn(warning string) {
+	if o.warningHandler != nil {
+		o.warningHandler(warning)
+	}
+}
@@ -0,0 +1,98 @@
+package conf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// nolint: gochecknoglobals
+var orderedPairsType = reflect.TypeOf(OrderedPairs{})
+
+// Pair is a single key and value of OrderedPairs.
+type Pair struct {
+	Key   string
+	Value string
+}
+
+// OrderedPairs is a map of strings which keeps the order its items were
+// given in, e.g. `MIDDLEWARE=auth=jwt,ratelimit=100,gzip=6`. It is read with
+// the same `envSeparator` and `envKeyValSeparator` as a map. A repeated key
+// keeps the position it first appeared at and takes its last value, unless
+// the field has `envDuplicateKeys:"error"`.
+type OrderedPairs []Pair
+
+// Get returns the value of key.
+func (o OrderedPairs) Get(key string) (string, bool) {
+	for _, pair := range o {
+		if pair.Key == key {
+			return pair.Value, true
+		}
+	}
+	return "", false
+}
+
+// Keys returns the keys in order.
+func (o OrderedPairs) Keys() []string {
+	keys := make([]string, len(o))
+	for i, pair := range o {
+		keys[i] = pair.Key
+	}
+	return keys
+}
+
+// mapSeparators returns the item and key-value separators of a map field.
+func mapSeparators(sf reflect.StructField) (string, string) {
+	var separator = sf.Tag.Get("envSeparator")
+	if separator == "" {
+		separator = ","
+	}
+	var kvSeparator = sf.Tag.Get("envKeyValSeparator")
+	if kvSeparator == "" {
+		kvSeparator = "="
+	}
+	return separator, kvSeparator
+}
+
+func handleOrderedPairs(field reflect.Value, value string, sf reflect.StructField) error {
+	var keepLast bool
+	switch mode := sf.Tag.Get("envDuplicateKeys"); mode {
+	case "", "last":
+		keepLast = true
+	case "error":
+	default:
+		return newError(`field "%s" has invalid envDuplicateKeys %q, expected "last" or "error"`, sf.Name, mode)
+	}
+
+	separator, kvSeparator := mapSeparators(sf)
+	var items = strings.Split(value, separator)
+	var result = make(OrderedPairs, 0, len(items))
+	var index = make(map[string]int, len(items))
+	for _, item := range items {
+		k, v, ok := strings.Cut(item, kvSeparator)
+		if !ok {
+			return newParseError(sf, fmt.Errorf("invalid map item %q, expected key%svalue", item, kvSeparator))
+		}
+		if i, ok := index[k]; ok {
+			if !keepLast {
+				return newParseError(sf, fmt.Errorf("duplicate key %q", k))
+			}
+			result[i].Value = v
+			continue
+		}
+		index[k] = len(result)
+		result = append(result, Pair{Key: k, Value: v})
+	}
+	field.Set(reflect.ValueOf(result))
+	return nil
+}
+
+// formatOrderedPairs formats pairs as the string Parse would read them from.
+func formatOrderedPairs(pairs OrderedPairs, sf reflect.StructField) string {
+	separator, kvSeparator := mapSeparators(sf)
+	items := make([]string, len(pairs))
+	for i, pair := range pairs {
+		items[i] = pair.Key + kvSeparator + pair.Value
+	}
+	return strings.Join(items, separator)
+}
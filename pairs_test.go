@@ -0,0 +1,90 @@
+package conf_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderedPairs(t *testing.T) {
+	type config struct {
+		Middleware conf.OrderedPairs `env:"MIDDLEWARE"`
+		Headers    conf.OrderedPairs `env:"HEADERS" envSeparator:";" envKeyValSeparator:":"`
+		Empty      conf.OrderedPairs `env:"EMPTY"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("MIDDLEWARE", "recover=true,auth=jwt,ratelimit=100,gzip=6,cors=*")
+	os.Setenv("HEADERS", "X-Z:1;X-A:2;X-M:a=b")
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, conf.OrderedPairs{
+		{Key: "recover", Value: "true"},
+		{Key: "auth", Value: "jwt"},
+		{Key: "ratelimit", Value: "100"},
+		{Key: "gzip", Value: "6"},
+		{Key: "cors", Value: "*"},
+	}, cfg.Middleware)
+	assert.Equal(t, []string{"X-Z", "X-A", "X-M"}, cfg.Headers.Keys())
+	assert.Nil(t, cfg.Empty)
+
+	v, ok := cfg.Headers.Get("X-M")
+	assert.True(t, ok)
+	assert.Equal(t, "a=b", v)
+	_, ok = cfg.Headers.Get("X-B")
+	assert.False(t, ok)
+}
+
+func TestOrderedPairsDuplicateKeys(t *testing.T) {
+	defer os.Clearenv()
+	os.Setenv("PAIRS", "b=1,a=2,b=3")
+
+	type last struct {
+		Pairs conf.OrderedPairs `env:"PAIRS"`
+	}
+	l := last{}
+	require.NoError(t, conf.Parse(&l, conf.EnvProvider))
+	assert.Equal(t, conf.OrderedPairs{{Key: "b", Value: "3"}, {Key: "a", Value: "2"}}, l.Pairs)
+
+	type explicitLast struct {
+		Pairs conf.OrderedPairs `env:"PAIRS" envDuplicateKeys:"last"`
+	}
+	el := explicitLast{}
+	require.NoError(t, conf.Parse(&el, conf.EnvProvider))
+	assert.Equal(t, l.Pairs, el.Pairs)
+
+	type strict struct {
+		Pairs conf.OrderedPairs `env:"PAIRS" envDuplicateKeys:"error"`
+	}
+	assert.EqualError(t, conf.Parse(&strict{}, conf.EnvProvider), `env: parse error on field "Pairs" of type "conf.OrderedPairs": duplicate key "b"`)
+
+	type invalid struct {
+		Pairs conf.OrderedPairs `env:"PAIRS" envDuplicateKeys:"first"`
+	}
+	assert.EqualError(t, conf.Parse(&invalid{}, conf.EnvProvider), `env: field "Pairs" has invalid envDuplicateKeys "first", expected "last" or "error"`)
+}
+
+func TestOrderedPairsInvalidItem(t *testing.T) {
+	type config struct {
+		Pairs conf.OrderedPairs `env:"PAIRS"`
+	}
+	defer os.Clearenv()
+	os.Setenv("PAIRS", "a=1,b")
+
+	assert.EqualError(t, conf.Parse(&config{}, conf.EnvProvider), `env: parse error on field "Pairs" of type "conf.OrderedPairs": invalid map item "b", expected key=value`)
+}
+
+func TestMarshalOrderedPairs(t *testing.T) {
+	type config struct {
+		Pairs conf.OrderedPairs `env:"PAIRS" envSeparator:";"`
+	}
+	cfg := config{Pairs: conf.OrderedPairs{{Key: "z", Value: "1"}, {Key: "a", Value: "2"}}}
+
+	b, err := conf.MarshalEnv(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "PAIRS=z=1;a=2\n", string(b))
+}
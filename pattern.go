@@ -0,0 +1,65 @@
+package conf
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+)
+
+// compilePattern compiles the `envPattern` tag of sf, once per field when the
+// plan of its struct is built. The pattern is unanchored like
+// regexp.MatchString, so use ^ and $ to match the whole value.
+func compilePattern(sf reflect.StructField) (*regexp.Regexp, error) {
+	tag, ok := sf.Tag.Lookup("envPattern")
+	if !ok {
+		return nil, nil
+	}
+	if !isStringField(sf.Type) {
+		return nil, newError(`field "%s" has envPattern but is not a string or a slice of strings`, sf.Name)
+	}
+	pattern, err := regexp.Compile(tag)
+	if err != nil {
+		return nil, newError(`field "%s" has invalid envPattern %q: %v`, sf.Name, tag, err)
+	}
+	return pattern, nil
+}
+
+// isStringField reports whether typee is a string, a slice of strings or a
+// pointer to either.
+func isStringField(typee reflect.Type) bool {
+	if typee.Kind() == reflect.Ptr {
+		typee = typee.Elem()
+	}
+	if typee.Kind() == reflect.Slice {
+		typee = typee.Elem()
+		if typee.Kind() == reflect.Ptr {
+			typee = typee.Elem()
+		}
+	}
+	return typee.Kind() == reflect.String
+}
+
+// checkPattern validates the value set on field, or each of its elements for
+// a slice, against pattern, the compiled `envPattern` tag of sf.
+func checkPattern(field reflect.Value, sf reflect.StructField, pattern *regexp.Regexp) error {
+	if pattern == nil {
+		return nil
+	}
+	field = indirect(field)
+	if field.Kind() == reflect.Slice {
+		for i := 0; i < field.Len(); i++ {
+			if err := checkPatternValue(indirect(field.Index(i)), sf, pattern); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return checkPatternValue(field, sf, pattern)
+}
+
+func checkPatternValue(v reflect.Value, sf reflect.StructField, pattern *regexp.Regexp) error {
+	if !v.IsValid() || pattern.MatchString(v.String()) {
+		return nil
+	}
+	return newParseError(sf, fmt.Errorf("value %q does not match pattern %q", v.String(), pattern))
+}
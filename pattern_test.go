@@ -0,0 +1,111 @@
+package conf_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type patternConfig struct {
+	ID      string   `env:"ID" envPattern:"^[a-z0-9-]+$"`
+	Region  *string  `env:"REGION" envPattern:"^[a-z]{2}-[a-z]+-[0-9]$" envDefault:"eu-west-1"`
+	Tenants []string `env:"TENANTS" envPattern:"^t-[0-9]+$"`
+}
+
+func TestPattern(t *testing.T) {
+	cfg := patternConfig{}
+	require.NoError(t, conf.Parse(&cfg, conf.NewMapProvider(map[string]string{
+		"ID":      "order-service-2",
+		"TENANTS": "t-1,t-22",
+	})))
+	assert.Equal(t, "order-service-2", cfg.ID)
+	assert.Equal(t, "eu-west-1", *cfg.Region)
+	assert.Equal(t, []string{"t-1", "t-22"}, cfg.Tenants)
+}
+
+func TestPatternNotMatching(t *testing.T) {
+	for name, tc := range map[string]struct {
+		env map[string]string
+		err string
+	}{
+		"string": {
+			env: map[string]string{"ID": "Order Service"},
+			err: `env: parse error on field "ID" of type "string": value "Order Service" does not match pattern "^[a-z0-9-]+$"`,
+		},
+		"pointer": {
+			env: map[string]string{"REGION": "europe"},
+			err: `env: parse error on field "Region" of type "*string": value "europe" does not match pattern "^[a-z]{2}-[a-z]+-[0-9]$"`,
+		},
+		"element": {
+			env: map[string]string{"TENANTS": "t-1,acme"},
+			err: `env: parse error on field "Tenants" of type "[]string": value "acme" does not match pattern "^t-[0-9]+$"`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := patternConfig{}
+			assert.EqualError(t, conf.Parse(&cfg, conf.NewMapProvider(tc.env)), tc.err)
+		})
+	}
+}
+
+func TestPatternNeverEcho(t *testing.T) {
+	cfg := patternConfig{}
+	err := conf.ParseWithOptions(&cfg, []conf.Option{conf.WithNeverEchoValues()}, conf.NewMapProvider(map[string]string{
+		"ID": "Order Service",
+	}))
+	assert.EqualError(t, err, `env: parse error on field "ID" of type "string"`)
+}
+
+func TestPatternInvalid(t *testing.T) {
+	type config struct {
+		ID string `env:"ID" envPattern:"^[a-z+$"`
+	}
+
+	cfg := config{}
+	err := conf.Parse(&cfg, conf.NewMapProvider(map[string]string{}))
+	assert.EqualError(t, err, "env: field \"ID\" has invalid envPattern \"^[a-z+$\": error parsing regexp: missing closing ]: `[a-z+$`")
+}
+
+func TestPatternInvalidBeforeProviders(t *testing.T) {
+	type config struct {
+		Host    string `env:"HOST,required"`
+		Backend struct {
+			ID string `env:"ID" envPattern:"^[a-z+$"`
+		}
+	}
+
+	calls := 0
+	cfg := config{}
+	err := conf.Parse(&cfg, providerFunc(func(sf reflect.StructField) (string, error) {
+		calls++
+		return "", nil
+	}))
+	assert.EqualError(t, err, "env: field \"ID\" has invalid envPattern \"^[a-z+$\": error parsing regexp: missing closing ]: `[a-z+$`")
+	assert.Zero(t, calls)
+}
+
+func TestPatternInvalidFiltered(t *testing.T) {
+	type config struct {
+		Host string `env:"HOST"`
+		ID   string `env:"ID" envPattern:"^[a-z+$"`
+	}
+
+	cfg := config{}
+	err := conf.ParseWithOptions(&cfg, []conf.Option{conf.WithFieldFilter(func(sf reflect.StructField) bool {
+		return sf.Name == "Host"
+	})}, conf.NewMapProvider(map[string]string{"HOST": "localhost"}))
+	assert.EqualError(t, err, "env: field \"ID\" has invalid envPattern \"^[a-z+$\": error parsing regexp: missing closing ]: `[a-z+$`")
+}
+
+func TestPatternNotString(t *testing.T) {
+	type config struct {
+		Port int `env:"PORT" envPattern:"^[0-9]+$"`
+	}
+
+	cfg := config{}
+	err := conf.Parse(&cfg, conf.NewMapProvider(map[string]string{"PORT": "8080"}))
+	assert.EqualError(t, err, `env: field "Port" has envPattern but is not a string or a slice of strings`)
+}
@@ -0,0 +1,95 @@
+package conf
+
+import (
+	"reflect"
+	"regexp"
+	"sync"
+)
+
+// nolint: gochecknoglobals
+var (
+	// plans caches the plan of each struct type parsed, so repeated parses of
+	// the same type do not look up its fields and the tags in fieldPlan
+	// again. The keys of the fields are still resolved by the providers and
+	// their parsers looked up on each parse, as both depend on the options
+	// and the parsers registered at the time.
+	plans sync.Map // reflect.Type -> *structPlan
+	// prefixedTagCache caches the tags rewritten by withKeyPrefix.
+	prefixedTagCache sync.Map // prefixedTagKey -> reflect.StructTag
+)
+
+// structPlan holds the fields of a struct type and the tags parse checks
+// first for each of them, none of which depend on the values being parsed or
+// the options.
+type structPlan struct {
+	fields []fieldPlan
+}
+
+type fieldPlan struct {
+	index int
+	sf    reflect.StructField
+	// provider is the name in the `confProvider` tag, if hasProvider.
+	provider    string
+	hasProvider bool
+	// source is the name in the `source` tag, if hasSource.
+	source    string
+	hasSource bool
+	nested    bool
+	// pattern is the compiled `envPattern` tag, or patternErr the reason it
+	// is invalid, which checkPatterns reports before any field is parsed.
+	pattern    *regexp.Regexp
+	patternErr error
+}
+
+// planFor returns the plan of the struct type t, building it the first time.
+// Plans are immutable once built, so they are shared between goroutines.
+func planFor(t reflect.Type) *structPlan {
+	if plan, ok := plans.Load(t); ok {
+		return plan.(*structPlan)
+	}
+	plan := &structPlan{fields: make([]fieldPlan, t.NumField())}
+	for i := range plan.fields {
+		sf := t.Field(i)
+		provider, hasProvider := sf.Tag.Lookup("confProvider")
+		source, hasSource := sf.Tag.Lookup("source")
+		pattern, patternErr := compilePattern(sf)
+		plan.fields[i] = fieldPlan{
+			index:       i,
+			sf:          sf,
+			provider:    provider,
+			hasProvider: hasProvider,
+			source:      source,
+			hasSource:   hasSource,
+			nested:      isNested(sf),
+			pattern:     pattern,
+			patternErr:  patternErr,
+		}
+	}
+	actual, _ := plans.LoadOrStore(t, plan)
+	return actual.(*structPlan)
+}
+
+// checkPatterns returns the error of the first invalid `envPattern` tag of
+// the fields of the struct v points to and the structs nested in it. It runs
+// before any provider is consulted, so an invalid tag fails Parse whether or
+// not its field is set or accepted by the field filter.
+func checkPatterns(v interface{}) error {
+	ptrRef := reflect.ValueOf(v)
+	if ptrRef.Kind() != reflect.Ptr || ptrRef.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	return walkFields(ptrRef.Elem(), options{}, func(s walkedStruct) error {
+		plan := planFor(s.ref.Type())
+		for _, i := range s.fields {
+			if err := plan.fields[i].patternErr; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+type prefixedTagKey struct {
+	tag    reflect.StructTag
+	prefix string
+}
@@ -0,0 +1,37 @@
+package conf_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseConcurrently(t *testing.T) {
+	provider := conf.NewMapProvider(map[string]string{
+		"NAME":         "api",
+		"PRIMARY_HOST": "db.local",
+		"REPLICA_HOST": "replica.local",
+	})
+
+	var wg sync.WaitGroup
+	errs := make([]error, 16)
+	cfgs := make([]benchmarkConfig, 16)
+	for i := range cfgs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = conf.Parse(&cfgs[i], provider)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, cfg := range cfgs {
+		assert.NoError(t, errs[i])
+		assert.Equal(t, "db.local", cfg.Primary.Host)
+		assert.Equal(t, "replica.local", cfg.Replica.Host)
+		assert.Equal(t, "localhost", cfg.Analytics.Host)
+		assert.Equal(t, 5432, cfg.Analytics.Port)
+	}
+}
@@ -0,0 +1,33 @@
+package conf
+
+import (
+	"fmt"
+	"time"
+)
+
+// PoolConfig holds the settings of a connection pool, such as that of a
+// `database/sql` DB, with the defaults of a small service. Give the field an
+// `envPrefix` to read the settings of each pool, e.g. `DB_POOL_MAX_OPEN`.
+// Zero limits mean no limit, as they do for `database/sql`.
+type PoolConfig struct {
+	MaxOpen     int           `env:"MAX_OPEN" envDefault:"10"`
+	MaxIdle     int           `env:"MAX_IDLE" envDefault:"5"`
+	MaxLifetime time.Duration `env:"MAX_LIFETIME" envDefault:"30m"`
+}
+
+// Validate reports limits which are negative, and more idle connections than
+// open connections, which the pool could never keep. It is called when
+// parsing with WithValidators.
+func (c PoolConfig) Validate() error {
+	switch {
+	case c.MaxOpen < 0:
+		return fmt.Errorf("max open connections %d is negative", c.MaxOpen)
+	case c.MaxIdle < 0:
+		return fmt.Errorf("max idle connections %d is negative", c.MaxIdle)
+	case c.MaxLifetime < 0:
+		return fmt.Errorf("max connection lifetime %s is negative", c.MaxLifetime)
+	case c.MaxOpen > 0 && c.MaxIdle > c.MaxOpen:
+		return fmt.Errorf("max idle connections %d exceed max open connections %d", c.MaxIdle, c.MaxOpen)
+	}
+	return nil
+}
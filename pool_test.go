@@ -0,0 +1,68 @@
+package conf_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoolConfig(t *testing.T) {
+	type config struct {
+		Primary conf.PoolConfig  `envPrefix:"DB_POOL_"`
+		Replica *conf.PoolConfig `envPrefix:"REPLICA_POOL_"`
+	}
+
+	cfg := config{Replica: &conf.PoolConfig{}}
+	require.NoError(t, conf.Parse(&cfg, conf.NewMapProvider(map[string]string{
+		"DB_POOL_MAX_OPEN":          "50",
+		"DB_POOL_MAX_IDLE":          "50",
+		"REPLICA_POOL_MAX_LIFETIME": "1h",
+	})))
+	assert.Equal(t, conf.PoolConfig{MaxOpen: 50, MaxIdle: 50, MaxLifetime: 30 * time.Minute}, cfg.Primary)
+	assert.Equal(t, conf.PoolConfig{MaxOpen: 10, MaxIdle: 5, MaxLifetime: time.Hour}, *cfg.Replica)
+}
+
+func TestPoolConfigInvalid(t *testing.T) {
+	type config struct {
+		Pool conf.PoolConfig `envPrefix:"POOL_"`
+	}
+
+	for name, tc := range map[string]struct {
+		env map[string]string
+		err string
+	}{
+		"idle exceeds open": {
+			env: map[string]string{"POOL_MAX_OPEN": "10", "POOL_MAX_IDLE": "20"},
+			err: `env: validation failed on field "Pool": max idle connections 20 exceed max open connections 10`,
+		},
+		"negative open": {
+			env: map[string]string{"POOL_MAX_OPEN": "-1"},
+			err: `env: validation failed on field "Pool": max open connections -1 is negative`,
+		},
+		"negative lifetime": {
+			env: map[string]string{"POOL_MAX_LIFETIME": "-5m"},
+			err: `env: validation failed on field "Pool": max connection lifetime -5m0s is negative`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := config{}
+			assert.EqualError(t, conf.ParseWithOptions(&cfg, []conf.Option{conf.WithValidators()}, conf.NewMapProvider(tc.env)), tc.err)
+		})
+	}
+}
+
+func TestPoolConfigUnlimited(t *testing.T) {
+	type config struct {
+		Pool conf.PoolConfig `envPrefix:"POOL_"`
+	}
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.NewMapProvider(map[string]string{
+		"POOL_MAX_OPEN": "0",
+		"POOL_MAX_IDLE": "20",
+	})))
+	assert.Equal(t, 20, cfg.Pool.MaxIdle)
+}
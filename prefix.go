@@ -0,0 +1,116 @@
+package conf
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// keyPrefixTag is added to the fields passed to providers with the prefix
+// of their keys.
+const keyPrefixTag = "envKeyPrefix"
+
+// nolint: gochecknoglobals
+var (
+	// prefixedTags are the tags whose key is prefixed by the `envPrefix` of
+	// the struct fields a field is nested in.
+	prefixedTags = []string{"env", "secret"}
+	// prefixedListTags are the tags holding a comma separated list of keys
+	// which are each prefixed.
+	prefixedListTags = []string{"envDeprecated", "envAlias"}
+)
+
+// withKeyPrefix returns sf with prefix prepended to the keys in its tags, so
+// providers resolve the keys of a nested struct without knowing about the
+// prefix.
+func withKeyPrefix(sf reflect.StructField, prefix string) reflect.StructField {
+	if prefix == "" {
+		return sf
+	}
+	key := prefixedTagKey{tag: sf.Tag, prefix: prefix}
+	if tag, ok := prefixedTagCache.Load(key); ok {
+		sf.Tag = tag.(reflect.StructTag)
+		return sf
+	}
+	tags := parseTag(sf.Tag)
+	for i, tag := range tags {
+		switch {
+		case contains(prefixedTags, tag.name):
+			if key, _ := parseKeyForOption(tag.value); key != "" {
+				tags[i].value = prefix + tag.value
+			}
+		case contains(prefixedListTags, tag.name) && tag.value != "":
+			keys := strings.Split(tag.value, ",")
+			for j := range keys {
+				keys[j] = prefix + keys[j]
+			}
+			tags[i].value = strings.Join(keys, ",")
+		}
+	}
+	// fields without a key are named by a KeyNamer, which is given the prefix
+	tags = append(tags, structTag{name: keyPrefixTag, value: prefix})
+	sf.Tag = formatTag(tags)
+	prefixedTagCache.Store(key, sf.Tag)
+	return sf
+}
+
+type structTag struct {
+	name, value string
+}
+
+// parseTag splits a struct tag into its `name:"value"` pairs, following the
+// conventions of reflect.StructTag.Get.
+func parseTag(tag reflect.StructTag) []structTag {
+	var tags []structTag
+	s := string(tag)
+	for s != "" {
+		s = strings.TrimLeft(s, " ")
+		i := 0
+		for i < len(s) && s[i] > ' ' && s[i] != ':' && s[i] != '"' && s[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(s) || s[i] != ':' || s[i+1] != '"' {
+			break
+		}
+		name := s[:i]
+		s = s[i+1:]
+
+		i = 1
+		for i < len(s) && s[i] != '"' {
+			if s[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(s) {
+			break
+		}
+		value, err := strconv.Unquote(s[:i+1])
+		if err != nil {
+			break
+		}
+		s = s[i+1:]
+		tags = append(tags, structTag{name: name, value: value})
+	}
+	return tags
+}
+
+func formatTag(tags []structTag) reflect.StructTag {
+	var b strings.Builder
+	for _, tag := range tags {
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(tag.name + ":" + strconv.Quote(tag.value))
+	}
+	return reflect.StructTag(b.String())
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
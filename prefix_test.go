@@ -0,0 +1,82 @@
+package conf_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+)
+
+type tlsConfig struct {
+	Cert string `env:"CERT"`
+	Key  string `secret:"KEY"`
+}
+
+func TestEnvPrefix(t *testing.T) {
+	type config struct {
+		TLS      tlsConfig  `envPrefix:"TLS_"`
+		AdminTLS *tlsConfig `envPrefix:"ADMIN_TLS_"`
+		Cert     string     `env:"CERT"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("TLS_CERT", "server.crt")
+	os.Setenv("TLS_KEY", "server.key")
+	os.Setenv("ADMIN_TLS_CERT", "admin.crt")
+	os.Setenv("ADMIN_TLS_KEY", "admin.key")
+	os.Setenv("CERT", "root.crt")
+
+	cfg := config{AdminTLS: &tlsConfig{}}
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider, conf.SecretEnvProvider))
+	assert.Equal(t, tlsConfig{Cert: "server.crt", Key: "server.key"}, cfg.TLS)
+	assert.Equal(t, &tlsConfig{Cert: "admin.crt", Key: "admin.key"}, cfg.AdminTLS)
+	assert.Equal(t, "root.crt", cfg.Cert)
+}
+
+func TestEnvPrefixNested(t *testing.T) {
+	type server struct {
+		TLS  tlsConfig `envPrefix:"TLS_"`
+		Port int       `env:"PORT,required"`
+	}
+	type config struct {
+		Public server `envPrefix:"PUBLIC_"`
+		Admin  struct {
+			Port int `env:"PORT"`
+		} `envPrefix:"ADMIN_"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("PUBLIC_TLS_CERT", "public.crt")
+	os.Setenv("PUBLIC_PORT", "443")
+	os.Setenv("ADMIN_PORT", "8443")
+
+	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, "public.crt", cfg.Public.TLS.Cert)
+	assert.Equal(t, 443, cfg.Public.Port)
+	assert.Equal(t, 8443, cfg.Admin.Port)
+}
+
+func TestEnvPrefixTagOptions(t *testing.T) {
+	type database struct {
+		Host string `env:"HOST,required" envDeprecated:"HOSTNAME"`
+		Port int    `env:"PORT" envDefault:"5432"`
+	}
+	type config struct {
+		DB database `envPrefix:"DB_"`
+	}
+	defer os.Clearenv()
+
+	cfg := config{}
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: required environment variable "DB_HOST" is not set`)
+
+	os.Setenv("DB_HOSTNAME", "localhost")
+	var warnings []string
+	cfg = config{}
+	assert.NoError(t, conf.ParseWithOptions(&cfg, []conf.Option{
+		conf.WithWarningHandler(func(w string) { warnings = append(warnings, w) }),
+	}, conf.EnvProvider))
+	assert.Equal(t, database{Host: "localhost", Port: 5432}, cfg.DB)
+	assert.Equal(t, []string{`env: environment variable "DB_HOSTNAME" is deprecated, use "DB_HOST" instead`}, warnings)
+}
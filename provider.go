@@ -4,70 +4,429 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"strconv"
 	"strings"
+	"sync"
 )
 
 type Provider interface {
 	Provide(field reflect.StructField) (string, error)
 }
 
+// ResultProvider is implemented by providers which can report more about a
+// resolved value than `Provide` does. When a provider implements it, the
+// parser calls `ProvideResult` instead of `Provide`.
+type ResultProvider interface {
+	Provider
+	ProvideResult(field reflect.StructField) (Result, error)
+}
+
+// Result is the outcome of resolving a single field with a `ResultProvider`.
+type Result struct {
+	// Value is the resolved value, as it would be returned by `Provide`.
+	Value string
+	// Values are the elements of a slice field, or the key value pairs of a
+	// map field, when the source holds them separately. When set they are
+	// used instead of splitting Value.
+	Values []string
+	// Default reports whether Value is a default, such as from the
+	// `envDefault` tag, rather than a value found in the source.
+	Default bool
+	// Key is the key the value was found at, such as an alias of the key of
+	// the field's tag, and Source names where it was found, e.g. "env".
+	Key    string
+	Source string
+	// Warnings are non-fatal problems found while resolving the value. They
+	// are reported with the same prefix as errors.
+	Warnings []string
+}
+
 // nolint: gochecknoglobals
 var (
 	EnvProvider       = envProvider{tag: "env"}
 	SecretEnvProvider = envProvider{tag: "secret"}
+
+	// namedProviders are the providers which can be selected for a struct
+	// field with the `confProvider` tag or a field with the `source` tag.
+	namedProvidersMu sync.RWMutex
+	namedProviders   = map[string]Provider{
+		"env":    EnvProvider,
+		"secret": SecretEnvProvider,
+	}
 )
 
+// NewMapProvider returns a provider which resolves `env` tags from values
+// instead of the environment. It supports the same tags and options as
+// EnvProvider.
+func NewMapProvider(values map[string]string) Provider {
+	return envProvider{tag: "env", source: "map", keys: mapKeys(values), lookup: func(key string) (string, bool) {
+		value, ok := values[key]
+		return value, ok
+	}}
+}
+
+// NewEnvProviderWithDefaults returns a provider which reads the environment
+// like EnvProvider and falls back to defaults, keyed by the key of the field,
+// for the fields which are not set and have no `envDefault` tag. A value in the
+// environment takes precedence over the `envDefault` tag, which takes
+// precedence over defaults.
+func NewEnvProviderWithDefaults(defaults map[string]string) Provider {
+	return envProvider{tag: "env", defaults: defaults}
+}
+
 type envProvider struct {
-	tag string
+	tag  string
+	opts options
+	// lookup reads a key from the source, the environment when nil.
+	lookup func(key string) (string, bool)
+	// keys optionally lists the keys of the source, for `envMapPrefix`.
+	keys func() []string
+	// lookupValues optionally reads the elements of a list held by a key, for
+	// sources which hold them separately.
+	lookupValues func(key string) ([]string, bool)
+	// source names the source in results, the tag when empty.
+	source string
+	// namer names the fields which have no key in their tag, tagNamer when
+	// nil.
+	namer KeyNamer
+	// defaults holds the defaults of the fields without an `envDefault` tag,
+	// by key.
+	defaults map[string]string
+}
+
+// configurableProvider is implemented by the providers of this package whose
+// behaviour depends on the options passed to ParseWithOptions.
+type configurableProvider interface {
+	withOptions(opts options) Provider
+}
+
+func (o envProvider) withOptions(opts options) Provider {
+	o.opts = opts
+	return o
+}
+
+func (o envProvider) keyNamer() KeyNamer {
+	if o.namer == nil {
+		return tagNamer{}
+	}
+	return o.namer
+}
+
+func (o envProvider) lookupEnv(key string) (string, bool) {
+	if o.lookup == nil {
+		return os.LookupEnv(key)
+	}
+	return o.lookup(key)
 }
 
 func (o envProvider) Provide(field reflect.StructField) (string, error) {
-	var val string
+	result, err := o.ProvideResult(field)
+	return result.Value, err
+}
+
+func (o envProvider) ProvideResult(field reflect.StructField) (Result, error) {
+	var result Result
 	var err error
 
+	if prefix := field.Tag.Get("envMapPrefix"); prefix != "" {
+		if o.tag != EnvProvider.tag {
+			return result, nil
+		}
+		return o.provideMapPrefix(field, prefix)
+	}
+
 	key, opts := parseKeyForOption(field.Tag.Get(o.tag))
+	if key == "" {
+		key = o.keyNamer().Name(field, field.Tag.Get(keyPrefixTag))
+	}
 
-	defaultValue := field.Tag.Get("envDefault")
-	val = getOr(key, defaultValue)
+	var val string
+	var ok bool
+	if key != "" {
+		keys := append([]string{key}, splitKeys(field.Tag.Get("envAlias"))...)
+		numbered := strings.ToLower(field.Tag.Get("envNumbered"))
+		result.Key, result.Values, val, ok = o.lookupKeys(keys, numbered == "true")
+		if numbered == "merge" {
+			result.Key, result.Values, val, ok = o.mergeNumbered(field, key, result, val, ok)
+		}
+	}
+	if !ok && key != "" {
+		var deprecatedKey string
+		deprecatedKey, val, ok = o.lookupDeprecated(field.Tag.Get("envDeprecated"))
+		if ok {
+			result.Key = deprecatedKey
+			result.Warnings = append(result.Warnings, fmt.Sprintf(`environment variable %q is deprecated, use %q instead`, deprecatedKey, key))
+		}
+	}
+	if key != "" {
+		warning, err := checkMissing(field, key, ok)
+		if err != nil {
+			return result, err
+		}
+		if warning != "" {
+			result.Warnings = append(result.Warnings, warning)
+		}
+	}
+	if ok {
+		result.Source = o.source
+		if result.Source == "" {
+			result.Source = o.tag
+		}
+	} else {
+		val, result.Default = field.Tag.Lookup("envDefault")
+		if !result.Default && key != "" {
+			val, result.Default = o.defaults[key]
+		}
+	}
+	if strings.ToLower(field.Tag.Get("envPresence")) == "true" {
+		present, err := presence(field, ok)
+		if err != nil {
+			return result, err
+		}
+		if ok {
+			val = present
+		}
+	}
+	if strings.ToLower(field.Tag.Get("envNegate")) == "true" {
+		if err := checkBool(field, "envNegate"); err != nil {
+			return result, err
+		}
+		if ok && val != "" {
+			if val, err = o.negate(field, val); err != nil {
+				return result, err
+			}
+		}
+	}
 
 	expandVar := field.Tag.Get("envExpand")
 	if strings.ToLower(expandVar) == "true" {
-		val = os.ExpandEnv(val)
-	}
-
-	if len(opts) > 0 {
-		for _, opt := range opts {
-			// The only option supported is "required".
-			switch opt {
-			case "":
-				break
-			case "required":
-				val, err = getRequired(key)
-			default:
-				err = fmt.Errorf("env: tag option %q not supported", opt)
-			}
+		val = os.Expand(val, func(key string) string {
+			v, _ := o.lookupEnv(key)
+			return v
+		})
+	}
+
+	val, err = checkOptions(key, opts, val, ok, o.opts.strictRequired)
+	if err == nil && val != "" && hasOption(opts, "file") {
+		val, err = readValueFile(key, val, field)
+	}
+	if err == nil && val != "" && hasOption(opts, "fetch") {
+		val, err = fetchValue(o.opts.context(), key, val, field)
+	}
+	result.Value = val
+	return result, err
+}
+
+// presence returns the value of a bool field tagged with `envPresence`,
+// which is true when its key is set whatever the value.
+func presence(field reflect.StructField, ok bool) (string, error) {
+	if err := checkBool(field, "envPresence"); err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", nil
+	}
+	return "true", nil
+}
+
+// negate returns the negation of the value of a bool field tagged with
+// `envNegate`, so `NO_COLOR=true` sets a Color field to false.
+func (o envProvider) negate(field reflect.StructField, val string) (string, error) {
+	parse := defaultBuiltInParsers[reflect.Bool]
+	if o.opts.looseBools {
+		parse = parseLooseBool
+	}
+	b, err := parse(val)
+	if err != nil {
+		return "", newParseError(field, err)
+	}
+	return strconv.FormatBool(!b.(bool)), nil
+}
+
+// checkBool checks that a field with the tag is a bool or a pointer to one.
+func checkBool(field reflect.StructField, tag string) error {
+	typee := field.Type
+	if reflect.Ptr == typee.Kind() {
+		typee = typee.Elem()
+	}
+	if reflect.Bool != typee.Kind() {
+		return newError(`field "%s" has %s but is not a bool`, field.Name, tag)
+	}
+	return nil
+}
+
+// checkMissing applies the `envMissing` policy of a field to its key, where
+// ok reports whether the key was found in the source. With "error" a missing
+// key is an error as with the `required` option, with "warn" it returns a
+// warning and with "default", the policy of fields without the tag, the
+// default is used silently.
+func checkMissing(field reflect.StructField, key string, ok bool) (string, error) {
+	policy := field.Tag.Get("envMissing")
+	switch policy {
+	case "", "default":
+		return "", nil
+	case "error":
+		if !ok {
+			return "", newMissingError(key)
+		}
+		return "", nil
+	case "warn":
+		if !ok {
+			return fmt.Sprintf(`environment variable %q is not set`, key), nil
 		}
+		return "", nil
+	default:
+		return "", newError(`field "%s" has invalid envMissing %q, expected "error", "default" or "warn"`, field.Name, policy)
 	}
+}
 
+// lookupKeys returns the first of keys which is set and not empty, or the
+// first which is set when they are all empty, with its value. Numbered keys
+// are looked up with lookupNumbered.
+func (o envProvider) lookupKeys(keys []string, numbered bool) (string, []string, string, bool) {
+	var found bool
+	var foundKey, foundValue string
+	var foundValues []string
+	for _, key := range keys {
+		var values []string
+		var value string
+		var ok bool
+		if numbered {
+			values = o.lookupNumbered(key)
+			value, ok = strings.Join(values, ","), len(values) > 0
+		} else if value, ok = o.lookupEnv(key); ok && o.lookupValues != nil {
+			values, _ = o.lookupValues(key)
+		}
+		if ok && value != "" {
+			return key, values, value, true
+		}
+		if ok && !found {
+			found, foundKey, foundValues, foundValue = true, key, values, value
+		}
+	}
+	return foundKey, foundValues, foundValue, found
+}
+
+// checkOptions applies the tag options of key to its value, where ok reports
+// whether the value was found in the source.
+func checkOptions(key string, opts []string, val string, ok bool, strictRequired bool) (string, error) {
+	var err error
+	var notEmpty bool
+	for _, opt := range opts {
+		switch opt {
+		case "":
+			break
+		case "required":
+			if !ok {
+				val, err = "", newMissingError(key)
+			}
+			notEmpty = notEmpty || strictRequired
+		case "notEmpty":
+			notEmpty = true
+		case "mustProvide":
+			// checked once every provider has been applied
+		case "file":
+			// the value is the path of a file to read, once it is resolved
+		case "fetch":
+			// the value is the URL of a document to fetch, once it is resolved
+		default:
+			err = newError("tag option %q not supported", opt)
+		}
+	}
+	if err == nil && notEmpty && val == "" {
+		err = newError(`environment variable %q should not be empty`, key)
+	}
 	return val, err
 }
 
-func getOr(key, defaultValue string) string {
-	value, ok := os.LookupEnv(key)
-	if ok {
-		return value
+// hasOption reports whether opts has option.
+func hasOption(opts []string, option string) bool {
+	for _, opt := range opts {
+		if opt == option {
+			return true
+		}
 	}
-	return defaultValue
+	return false
 }
 
-func parseKeyForOption(key string) (string, []string) {
-	opts := strings.Split(key, ",")
-	return opts[0], opts[1:]
+// withoutRequired removes the `required` and `notEmpty` options and an
+// `envMissing:"error"` tag from field, for a field whose value in code
+// satisfies them with WithInCodeDefaults.
+func withoutRequired(field reflect.StructField) reflect.StructField {
+	var tags []structTag
+	for _, tag := range parseTag(field.Tag) {
+		if tag.name == "envMissing" && tag.value == "error" {
+			continue
+		}
+		if contains(prefixedTags, tag.name) {
+			key, opts := parseKeyForOption(tag.value)
+			tag.value = key
+			for _, opt := range opts {
+				if opt != "required" && opt != "notEmpty" {
+					tag.value += "," + opt
+				}
+			}
+		}
+		tags = append(tags, tag)
+	}
+	field.Tag = formatTag(tags)
+	return field
+}
+
+func splitKeys(keys string) []string {
+	if keys == "" {
+		return nil
+	}
+	return strings.Split(keys, ",")
+}
+
+// lookupNumbered returns the values of KEY_1, KEY_2 and so on, stopping at the
+// first index which is not set.
+func (o envProvider) lookupNumbered(key string) []string {
+	var values []string
+	for i := 1; ; i++ {
+		value, ok := o.lookupEnv(fmt.Sprintf("%s_%d", key, i))
+		if !ok {
+			return values
+		}
+		values = append(values, value)
+	}
 }
 
-func getRequired(key string) (string, error) {
-	if value, ok := os.LookupEnv(key); ok {
-		return value, nil
+// mergeNumbered appends the values of KEY_1, KEY_2 and so on to the elements
+// of the joined value of key, for slices tagged with `envNumbered:"merge"`.
+func (o envProvider) mergeNumbered(field reflect.StructField, key string, result Result, val string, ok bool) (string, []string, string, bool) {
+	numbered := o.lookupNumbered(key)
+	if len(numbered) == 0 {
+		return result.Key, result.Values, val, ok
+	}
+	values := result.Values
+	if values == nil && val != "" {
+		separator := field.Tag.Get("envSeparator")
+		if separator == "" {
+			separator = ","
+		}
+		values = strings.Split(val, separator)
+	}
+	values = append(values[:len(values):len(values)], numbered...)
+	if !ok {
+		result.Key = key
 	}
-	return "", fmt.Errorf(`env: required environment variable %q is not set`, key)
+	return result.Key, values, strings.Join(values, ","), true
+}
+
+// lookupDeprecated returns the first of the comma separated deprecated keys
+// which is set in the environment.
+func (o envProvider) lookupDeprecated(keys string) (string, string, bool) {
+	for _, key := range splitKeys(keys) {
+		if value, ok := o.lookupEnv(key); ok {
+			return key, value, true
+		}
+	}
+	return "", "", false
+}
+
+func parseKeyForOption(key string) (string, []string) {
+	opts := strings.Split(key, ",")
+	return opts[0], opts[1:]
 }
@@ -0,0 +1,19 @@
+module github.com/steinfletcher/conf/quantity
+
+go 1.18
+
+require (
+	github.com/steinfletcher/conf v0.0.0
+	github.com/stretchr/testify v1.11.1
+	k8s.io/apimachinery v0.28.15
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/gogo/protobuf v1.3.2 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/inf.v0 v0.9.1 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/steinfletcher/conf => ../
@@ -0,0 +1,41 @@
+// Package quantity provides conf parsers for Kubernetes resource quantities,
+// such as `CPU=500m` or `MEMORY=2Gi`.
+//
+// It is a separate module so that k8s.io/apimachinery is only required by
+// programs which parse quantities. Register the parsers with
+// conf.ParseWithFuncs:
+//
+//	type config struct {
+//		CPU    resource.Quantity   `env:"CPU" envDefault:"500m"`
+//		Memory resource.Quantity   `env:"MEMORY" envDefault:"2Gi"`
+//		Limits []resource.Quantity `env:"LIMITS"`
+//	}
+//
+//	var cfg config
+//	err := conf.ParseWithFuncs(&cfg, quantity.Parsers(), conf.EnvProvider)
+package quantity
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/steinfletcher/conf"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Parsers returns the custom parsers for resource.Quantity fields, including
+// slices of resource.Quantity.
+func Parsers() map[reflect.Type]conf.ParserFunc {
+	return map[reflect.Type]conf.ParserFunc{
+		reflect.TypeOf(resource.Quantity{}): Parse,
+	}
+}
+
+// Parse parses v with resource.ParseQuantity.
+func Parse(v string) (interface{}, error) {
+	q, err := resource.ParseQuantity(v)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse quantity: %v", err)
+	}
+	return q, nil
+}
@@ -0,0 +1,64 @@
+package quantity_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/steinfletcher/conf"
+	"github.com/steinfletcher/conf/quantity"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestParsesQuantities(t *testing.T) {
+	os.Setenv("CPU", "500m")
+	os.Setenv("MEMORY", "2Gi")
+	os.Setenv("STORAGE", "10G")
+	os.Setenv("LIMITS", "1,250m,1Mi")
+	defer os.Clearenv()
+
+	type config struct {
+		CPU     resource.Quantity    `env:"CPU"`
+		Memory  resource.Quantity    `env:"MEMORY"`
+		Storage *resource.Quantity   `env:"STORAGE"`
+		Limits  []resource.Quantity  `env:"LIMITS"`
+		LimPtrs []*resource.Quantity `env:"LIMITS"`
+	}
+
+	var cfg config
+	assert.NoError(t, conf.ParseWithFuncs(&cfg, quantity.Parsers(), conf.EnvProvider))
+	assert.Equal(t, int64(500), cfg.CPU.MilliValue())
+	assert.Equal(t, int64(2*1024*1024*1024), cfg.Memory.Value())
+	assert.Equal(t, int64(10*1000*1000*1000), cfg.Storage.Value())
+	assert.Equal(t, resource.BinarySI, cfg.Memory.Format)
+	assert.Equal(t, resource.DecimalSI, cfg.Storage.Format)
+	assert.Len(t, cfg.Limits, 3)
+	assert.Equal(t, "250m", cfg.Limits[1].String())
+	assert.Equal(t, int64(1024*1024), cfg.Limits[2].Value())
+	assert.Len(t, cfg.LimPtrs, 3)
+	assert.Equal(t, "1", cfg.LimPtrs[0].String())
+}
+
+func TestInvalidQuantity(t *testing.T) {
+	os.Setenv("MEMORY", "2GiB")
+	defer os.Clearenv()
+
+	type config struct {
+		Memory resource.Quantity `env:"MEMORY"`
+	}
+
+	var cfg config
+	assert.EqualError(t, conf.ParseWithFuncs(&cfg, quantity.Parsers(), conf.EnvProvider), `env: parse error on field "Memory" of type "resource.Quantity": unable to parse quantity: quantities must match the regular expression '^([+-]?[0-9.]+)([eEinumkKMGTP]*[-+]?[0-9]*)$'`)
+}
+
+func TestInvalidQuantities(t *testing.T) {
+	os.Setenv("LIMITS", "1,lots")
+	defer os.Clearenv()
+
+	type config struct {
+		Limits []resource.Quantity `env:"LIMITS"`
+	}
+
+	var cfg config
+	assert.Error(t, conf.ParseWithFuncs(&cfg, quantity.Parsers(), conf.EnvProvider))
+}
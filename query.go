@@ -0,0 +1,62 @@
+package conf
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// isQuery reports whether value, which is not JSON, should be decoded as the
+// query string of a struct, e.g. `host=db.local&port=5432`.
+func isQuery(value string) bool {
+	return strings.Contains(value, "=")
+}
+
+// decodeQuery sets the fields of the struct v from the parameters of the query
+// string value, which are percent decoded. A parameter is matched to a field
+// by the key of its `env` tag or, without one, by the field name ignoring
+// case. Slice fields take every value of a repeated parameter and other fields
+// the first. Parameters which match no field are ignored.
+func (p *parser) decodeQuery(value string, v reflect.Value, sf reflect.StructField) error {
+	params, err := url.ParseQuery(value)
+	if err != nil {
+		return newParseError(sf, err)
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		child := t.Field(i)
+		if child.PkgPath != "" {
+			continue
+		}
+		values, ok := queryValues(params, child)
+		if !ok {
+			continue
+		}
+		field := v.Field(i)
+		if field.Kind() == reflect.Slice {
+			err = p.setSlice(field, values, child)
+		} else {
+			err = p.set(field, child, values[0])
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func queryValues(params url.Values, sf reflect.StructField) ([]string, bool) {
+	if key := tagKey(sf, "env"); key != "" {
+		values, ok := params[key]
+		return values, ok
+	}
+	if values, ok := params[sf.Name]; ok {
+		return values, true
+	}
+	for name, values := range params {
+		if strings.EqualFold(name, sf.Name) {
+			return values, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,87 @@
+package conf_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type queryDatabase struct {
+	Host     string
+	Port     int
+	SSL      bool          `env:"ssl"`
+	Password string        `env:"pass"`
+	Timeout  time.Duration `env:"timeout"`
+	Replicas []string      `env:"replica"`
+	Ports    []int
+	Options  *queryOptions `env:"opts"`
+	ignored  string
+}
+
+type queryOptions struct {
+	Pool int `env:"pool"`
+}
+
+func TestQueryStruct(t *testing.T) {
+	type config struct {
+		DB    queryDatabase  `env:"DB"`
+		DBPtr *queryDatabase `env:"DB"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("DB", "host=db.local&port=5432&ssl=true&pass=p%40ss%26word%3D1&timeout=5s"+
+		"&replica=r1.local&replica=r2.local&ports=1&ports=2&opts=pool%3D10&unknown=x&ignored=y")
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	expected := queryDatabase{
+		Host:     "db.local",
+		Port:     5432,
+		SSL:      true,
+		Password: "p@ss&word=1",
+		Timeout:  5 * time.Second,
+		Replicas: []string{"r1.local", "r2.local"},
+		Ports:    []int{1, 2},
+		Options:  &queryOptions{Pool: 10},
+	}
+	assert.Equal(t, expected, cfg.DB)
+	assert.Equal(t, &expected, cfg.DBPtr)
+}
+
+func TestQueryStructFirstValue(t *testing.T) {
+	type config struct {
+		DB queryDatabase `env:"DB"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("DB", "host=a&host=b&Host=c")
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, "c", cfg.DB.Host, "the exact field name takes precedence")
+
+	os.Setenv("DB", "port=1&port=2")
+	require.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, 1, cfg.DB.Port)
+}
+
+func TestQueryStructErrors(t *testing.T) {
+	type config struct {
+		DB queryDatabase `env:"DB"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("DB", "host=db.local&port=fast")
+	cfg := config{}
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: parse error on field "Port" of type "int": strconv.ParseInt: parsing "fast": invalid syntax`)
+
+	os.Setenv("DB", "host=%zz")
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: parse error on field "DB" of type "conf_test.queryDatabase": invalid URL escape "%zz"`)
+
+	os.Setenv("DB", "db.local")
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: no parser found for field "DB" of type "conf_test.queryDatabase"`)
+}
@@ -0,0 +1,48 @@
+package conf
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// RedactedURL is a url.URL whose String method replaces the password of its
+// credentials with "***", so it can be logged or printed with MarshalEnv.
+// The URL, including the password, is available from the embedded URL.
+type RedactedURL struct {
+	url.URL
+}
+
+// Username returns the user name of the credentials of u, if any.
+func (u RedactedURL) Username() string {
+	return u.User.Username()
+}
+
+// Password returns the password of the credentials of u, if any.
+func (u RedactedURL) Password() (string, bool) {
+	return u.User.Password()
+}
+
+func (u RedactedURL) String() string {
+	if _, ok := u.User.Password(); !ok {
+		return u.URL.String()
+	}
+	// the mask is inserted after formatting, which would escape it
+	redacted := u.URL
+	redacted.User = url.User(u.User.Username())
+	return strings.Replace(redacted.String(), "@", ":"+maskedValue+"@", 1)
+}
+
+// parseRedactedURL parses a RedactedURL. The errors of url.Parse quote the
+// URL, so only their cause is returned to keep the password out of them.
+func parseRedactedURL(v string) (RedactedURL, error) {
+	u, err := url.Parse(v)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return RedactedURL{}, err
+	}
+	return RedactedURL{URL: *u}, nil
+}
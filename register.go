@@ -0,0 +1,107 @@
+package conf
+
+import (
+	"reflect"
+	"sync"
+)
+
+// nolint: gochecknoglobals
+var (
+	registeredTypesMu sync.RWMutex
+	registeredTypes   = map[reflect.Type]ParserFunc{}
+	registeredFormats = map[reflect.Type]func(interface{}) string{}
+	// registeredDefaults are the defaults registered with RegisterDefault.
+	registeredDefaults = map[reflect.Type]func() interface{}{}
+)
+
+// RegisterType registers parse as the parser of fields of type T, and of the
+// elements of slices and maps of T, for every parse. It is the type safe
+// equivalent of passing a ParserFunc for T to ParseWithFuncs. Custom parsers
+// passed to ParseWithFuncs take precedence over registered types, which take
+// precedence over the default parsers.
+func RegisterType[T any](parse func(string) (T, error)) {
+	typee := reflect.TypeOf((*T)(nil)).Elem()
+
+	registeredTypesMu.Lock()
+	defer registeredTypesMu.Unlock()
+	registeredTypes[typee] = func(v string) (interface{}, error) {
+		return parse(v)
+	}
+}
+
+func registeredParser(typee reflect.Type) (ParserFunc, bool) {
+	registeredTypesMu.RLock()
+	defer registeredTypesMu.RUnlock()
+	parserFunc, ok := registeredTypes[typee]
+	return parserFunc, ok
+}
+
+// RegisterCodec registers parse as the parser of fields of typee, as
+// RegisterType does, and format as the function which writes its values back
+// in `MarshalEnv` and the other output helpers. Values written with format
+// should parse back to the same value, so config round-trips unchanged.
+func RegisterCodec(typee reflect.Type, parse ParserFunc, format func(interface{}) string) {
+	registeredTypesMu.Lock()
+	defer registeredTypesMu.Unlock()
+	registeredTypes[typee] = parse
+	registeredFormats[typee] = format
+}
+
+func registeredFormat(typee reflect.Type) (func(interface{}) string, bool) {
+	registeredTypesMu.RLock()
+	defer registeredTypesMu.RUnlock()
+	format, ok := registeredFormats[typee]
+	return format, ok
+}
+
+// RegisterDefault registers fn as the default of fields of typee, and of
+// pointers to typee, for every parse. fn is called for each field of the type
+// which no provider sets, which has no `envDefault` tag and which is still
+// zero, so the tag default takes precedence over the registered default,
+// which takes precedence over the zero value. fn may return a value or a
+// pointer to a value of typee. A nil fn removes the default of typee.
+func RegisterDefault(typee reflect.Type, fn func() interface{}) {
+	registeredTypesMu.Lock()
+	defer registeredTypesMu.Unlock()
+	if fn == nil {
+		delete(registeredDefaults, typee)
+		return
+	}
+	registeredDefaults[typee] = fn
+}
+
+// setRegisteredDefault sets field, which no provider set, to the default
+// registered for its type.
+func setRegisteredDefault(field reflect.Value, sf reflect.StructField) error {
+	if _, ok := sf.Tag.Lookup("envDefault"); ok || !field.IsZero() {
+		return nil
+	}
+	typee := field.Type()
+	isPtr := typee.Kind() == reflect.Ptr
+	if isPtr {
+		typee = typee.Elem()
+	}
+
+	registeredTypesMu.RLock()
+	fn, ok := registeredDefaults[typee]
+	registeredTypesMu.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	def := fn()
+	v := reflect.ValueOf(def)
+	if v.IsValid() && v.Type() == reflect.PtrTo(typee) && !v.IsNil() {
+		v = v.Elem()
+	}
+	if !v.IsValid() || v.Type() != typee {
+		return newError(`field "%s" has registered default of type %T, expected %s`, sf.Name, def, typee)
+	}
+	if isPtr {
+		ptr := reflect.New(typee)
+		ptr.Elem().Set(v)
+		v = ptr
+	}
+	field.Set(v)
+	return nil
+}
@@ -0,0 +1,208 @@
+package conf_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type coordinate struct {
+	Lat, Lng float64
+}
+
+func parseCoordinate(v string) (coordinate, error) {
+	lat, lng, ok := strings.Cut(v, ":")
+	if !ok {
+		return coordinate{}, errors.New("expected lat:lng")
+	}
+	var c coordinate
+	var err error
+	if c.Lat, err = strconv.ParseFloat(lat, 64); err != nil {
+		return coordinate{}, err
+	}
+	if c.Lng, err = strconv.ParseFloat(lng, 64); err != nil {
+		return coordinate{}, err
+	}
+	return c, nil
+}
+
+func init() {
+	conf.RegisterType(parseCoordinate)
+}
+
+func TestRegisterType(t *testing.T) {
+	type config struct {
+		Origin    coordinate            `env:"ORIGIN"`
+		OriginPtr *coordinate           `env:"ORIGIN"`
+		Stops     []coordinate          `env:"STOPS"`
+		Named     map[string]coordinate `env:"NAMED"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("ORIGIN", "51.5:-0.12")
+	os.Setenv("STOPS", "1:2,3:4")
+	os.Setenv("NAMED", "home=5:6")
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, coordinate{Lat: 51.5, Lng: -0.12}, cfg.Origin)
+	assert.Equal(t, &coordinate{Lat: 51.5, Lng: -0.12}, cfg.OriginPtr)
+	assert.Equal(t, []coordinate{{1, 2}, {3, 4}}, cfg.Stops)
+	assert.Equal(t, map[string]coordinate{"home": {5, 6}}, cfg.Named)
+}
+
+func TestRegisterTypeError(t *testing.T) {
+	type config struct {
+		Origin coordinate `env:"ORIGIN"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("ORIGIN", "nowhere")
+
+	cfg := config{}
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: parse error on field "Origin" of type "conf_test.coordinate": expected lat:lng`)
+}
+
+func TestRegisterTypeFuncMapPrecedence(t *testing.T) {
+	type config struct {
+		Origin coordinate `env:"ORIGIN"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("ORIGIN", "51.5:-0.12")
+
+	cfg := config{}
+	err := conf.ParseWithFuncs(&cfg, map[reflect.Type]conf.ParserFunc{
+		reflect.TypeOf(coordinate{}): func(string) (interface{}, error) {
+			return coordinate{}, nil
+		},
+	}, conf.EnvProvider)
+	require.NoError(t, err)
+	assert.Equal(t, coordinate{}, cfg.Origin)
+}
+
+type rgb struct {
+	R, G, B uint8
+}
+
+func init() {
+	conf.RegisterCodec(reflect.TypeOf(rgb{}), func(v string) (interface{}, error) {
+		var c rgb
+		if _, err := fmt.Sscanf(v, "#%02x%02x%02x", &c.R, &c.G, &c.B); err != nil {
+			return nil, fmt.Errorf("expected #rrggbb: %v", err)
+		}
+		return c, nil
+	}, func(v interface{}) string {
+		c := v.(rgb)
+		return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+	})
+}
+
+func TestRegisterCodecRoundTrip(t *testing.T) {
+	type config struct {
+		Background rgb            `env:"BACKGROUND"`
+		Accent     *rgb           `env:"ACCENT"`
+		Palette    []rgb          `env:"PALETTE"`
+		Named      map[string]rgb `env:"NAMED"`
+	}
+
+	in := config{
+		Background: rgb{R: 255, G: 255, B: 255},
+		Accent:     &rgb{R: 0, G: 128, B: 255},
+		Palette:    []rgb{{R: 1, G: 2, B: 3}, {R: 4, G: 5, B: 6}},
+		Named:      map[string]rgb{"error": {R: 200}},
+	}
+	out, err := conf.MarshalEnv(in)
+	require.NoError(t, err)
+	assert.Equal(t, `BACKGROUND="#ffffff"
+ACCENT="#0080ff"
+PALETTE="#010203,#040506"
+NAMED="error=#c80000"
+`, string(out))
+
+	provider, err := conf.NewDotenvProvider(bytes.NewReader(out))
+	require.NoError(t, err)
+	var parsed config
+	require.NoError(t, conf.Parse(&parsed, provider))
+	assert.Equal(t, in, parsed)
+
+	again, err := conf.MarshalEnv(parsed)
+	require.NoError(t, err)
+	assert.Equal(t, string(out), string(again))
+
+	b, err := conf.MarshalJSONMasked(config{Background: rgb{R: 255}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"Background": "#ff0000", "Accent": null, "Palette": null, "Named": null}`, string(b))
+}
+
+func TestRegisterDefault(t *testing.T) {
+	urlType := reflect.TypeOf(url.URL{})
+	conf.RegisterDefault(urlType, func() interface{} {
+		return url.URL{Scheme: "https", Host: "api.example.com"}
+	})
+	defer conf.RegisterDefault(urlType, nil)
+
+	type config struct {
+		API      url.URL  `env:"API_URL"`
+		Fallback *url.URL `env:"FALLBACK_URL"`
+		Tagged   url.URL  `env:"TAGGED_URL" envDefault:"http://localhost"`
+		Empty    url.URL  `env:"EMPTY_URL" envDefault:""`
+		Set      url.URL  `env:"SET_URL"`
+	}
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.NewMapProvider(map[string]string{
+		"SET_URL": "https://set.example.com",
+	})))
+	assert.Equal(t, "https://api.example.com", cfg.API.String())
+	assert.Equal(t, "https://api.example.com", cfg.Fallback.String())
+	assert.Equal(t, "http://localhost", cfg.Tagged.String())
+	assert.Equal(t, url.URL{}, cfg.Empty)
+	assert.Equal(t, "https://set.example.com", cfg.Set.String())
+}
+
+func TestRegisterDefaultKeepsValue(t *testing.T) {
+	urlType := reflect.TypeOf(url.URL{})
+	conf.RegisterDefault(urlType, func() interface{} {
+		return &url.URL{Scheme: "https", Host: "api.example.com"}
+	})
+	defer conf.RegisterDefault(urlType, nil)
+
+	type config struct {
+		API url.URL `env:"API_URL"`
+	}
+
+	cfg := config{API: url.URL{Scheme: "http", Host: "in-code"}}
+	require.NoError(t, conf.Parse(&cfg, conf.NewMapProvider(map[string]string{})))
+	assert.Equal(t, "http://in-code", cfg.API.String())
+
+	cfg = config{}
+	require.NoError(t, conf.Parse(&cfg, conf.NewMapProvider(map[string]string{})))
+	assert.Equal(t, "https://api.example.com", cfg.API.String())
+}
+
+func TestRegisterDefaultInvalid(t *testing.T) {
+	urlType := reflect.TypeOf(url.URL{})
+	conf.RegisterDefault(urlType, func() interface{} {
+		return "https://api.example.com"
+	})
+	defer conf.RegisterDefault(urlType, nil)
+
+	type config struct {
+		API url.URL `env:"API_URL"`
+	}
+
+	cfg := config{}
+	err := conf.Parse(&cfg, conf.NewMapProvider(map[string]string{}))
+	assert.EqualError(t, err, `env: field "API" has registered default of type string, expected url.URL`)
+}
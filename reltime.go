@@ -0,0 +1,28 @@
+package conf
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// nolint: gochecknoglobals
+var timeType = reflect.TypeOf(time.Time{})
+
+// parseTime parses a time.Time from an RFC 3339 timestamp, or from a duration
+// relative to the time of parsing with a leading sign, such as "+24h" or
+// "-7d" in the units of ExtDuration.
+func (p *parser) parseTime(v string) (interface{}, error) {
+	if v != "" && (v[0] == '+' || v[0] == '-') {
+		d, err := parseExtDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse relative time: %v", err)
+		}
+		return p.opts.now().Add(d.Duration()), nil
+	}
+	var t time.Time
+	if err := t.UnmarshalText([]byte(v)); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
@@ -0,0 +1,62 @@
+package conf_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelativeTime(t *testing.T) {
+	type config struct {
+		Expires    time.Time   `env:"EXPIRES"`
+		ExpiresPtr *time.Time  `env:"EXPIRES"`
+		Since      time.Time   `env:"SINCE"`
+		Checks     []time.Time `env:"CHECKS"`
+		Started    time.Time   `env:"STARTED"`
+	}
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	cfg := config{}
+	require.NoError(t, conf.ParseWithOptions(&cfg, []conf.Option{conf.WithClock(func() time.Time { return now })}, conf.NewMapProvider(map[string]string{
+		"EXPIRES": "+24h",
+		"SINCE":   "-7d",
+		"CHECKS":  "+1h30m,2024-03-02T00:00:00Z",
+		"STARTED": "2024-01-01T09:30:00+01:00",
+	})))
+	assert.Equal(t, now.Add(24*time.Hour), cfg.Expires)
+	assert.Equal(t, now.Add(24*time.Hour), *cfg.ExpiresPtr)
+	assert.Equal(t, now.Add(-7*24*time.Hour), cfg.Since)
+	assert.Equal(t, []time.Time{now.Add(90 * time.Minute), time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)}, cfg.Checks)
+	assert.True(t, time.Date(2024, 1, 1, 8, 30, 0, 0, time.UTC).Equal(cfg.Started))
+}
+
+func TestRelativeTimeDefaultClock(t *testing.T) {
+	type config struct {
+		Expires time.Time `env:"EXPIRES"`
+	}
+
+	before := time.Now()
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.NewMapProvider(map[string]string{"EXPIRES": "+1h"})))
+	assert.WithinDuration(t, before.Add(time.Hour), cfg.Expires, time.Minute)
+}
+
+func TestRelativeTimeInvalid(t *testing.T) {
+	type config struct {
+		Expires time.Time `env:"EXPIRES"`
+	}
+
+	for value, want := range map[string]string{
+		"+soon":      `env: parse error on field "Expires" of type "time.Time": unable to parse relative time: invalid duration "+soon"`,
+		"+1y":        `env: parse error on field "Expires" of type "time.Time": unable to parse relative time: unknown unit "y" in duration "+1y"`,
+		"2024-13-01": `env: parse error on field "Expires" of type "time.Time": parsing time "2024-13-01": month out of range`,
+	} {
+		t.Run(value, func(t *testing.T) {
+			cfg := config{}
+			assert.EqualError(t, conf.Parse(&cfg, conf.NewMapProvider(map[string]string{"EXPIRES": value})), want)
+		})
+	}
+}
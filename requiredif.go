@@ -0,0 +1,76 @@
+package conf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// checkRequiredIf enforces `envRequiredIf` tags. It runs once every provider
+// has been applied because the condition depends on the values of other
+// fields. The condition names a sibling field by its field name or its `env`
+// or `secret` key, either as `KEY` (the field is set) or `KEY=value` (the
+// field is set to value).
+func checkRequiredIf(ref reflect.Value, opts options) error {
+	return walkFields(ref, opts, func(s walkedStruct) error {
+		for _, i := range s.fields {
+			refTypeField := s.ref.Type().Field(i)
+			cond := refTypeField.Tag.Get("envRequiredIf")
+			if cond == "" {
+				continue
+			}
+			key, want, hasWant := strings.Cut(cond, "=")
+			other, ok := siblingByKey(s.ref, key)
+			if !ok {
+				return newError(`field "%s" has envRequiredIf on unknown key %q`, refTypeField.Name, key)
+			}
+			if conditionHolds(other, want, hasWant) && s.ref.Field(i).IsZero() {
+				return newError(`required environment variable %q is not set when %s`, s.key(i), cond)
+			}
+		}
+		return nil
+	})
+}
+
+func siblingByKey(ref reflect.Value, key string) (reflect.Value, bool) {
+	var refType = ref.Type()
+	for i := 0; i < refType.NumField(); i++ {
+		sf := refType.Field(i)
+		if sf.Name == key || tagKey(sf, "env") == key || tagKey(sf, "secret") == key {
+			return ref.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func conditionHolds(field reflect.Value, want string, hasWant bool) bool {
+	if reflect.Ptr == field.Kind() {
+		if field.IsNil() {
+			return false
+		}
+		if !hasWant {
+			return true
+		}
+		field = field.Elem()
+	}
+	if !hasWant {
+		return !field.IsZero()
+	}
+	return fmt.Sprint(field.Interface()) == want
+}
+
+// fieldKey returns the key a field is read from, for use in error messages.
+func fieldKey(sf reflect.StructField) string {
+	if key := tagKey(sf, "env"); key != "" {
+		return key
+	}
+	if key := tagKey(sf, "secret"); key != "" {
+		return key
+	}
+	return sf.Name
+}
+
+func tagKey(sf reflect.StructField, tag string) string {
+	key, _ := parseKeyForOption(sf.Tag.Get(tag))
+	return key
+}
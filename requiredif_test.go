@@ -0,0 +1,97 @@
+package conf_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+)
+
+type backendConfig struct {
+	Backend   string `env:"AWS_BACKEND"`
+	Secret    string `secret:"AWS_SECRET" envRequiredIf:"AWS_BACKEND=s3"`
+	Bucket    string `env:"AWS_BUCKET" envRequiredIf:"AWS_BACKEND=s3"`
+	TLSCert   string `env:"TLS_CERT"`
+	TLSKey    string `env:"TLS_KEY" envRequiredIf:"TLS_CERT"`
+	ProxyPort *int   `env:"PROXY_PORT"`
+	ProxyHost string `env:"PROXY_HOST" envRequiredIf:"ProxyPort"`
+}
+
+func TestRequiredIfConditionMet(t *testing.T) {
+	os.Setenv("AWS_BACKEND", "s3")
+	os.Setenv("AWS_BUCKET", "my-bucket")
+	defer os.Clearenv()
+
+	var cfg backendConfig
+	err := conf.Parse(&cfg, conf.EnvProvider, conf.SecretEnvProvider)
+	assert.EqualError(t, err, `env: required environment variable "AWS_SECRET" is not set when AWS_BACKEND=s3`)
+
+	os.Setenv("AWS_SECRET", "s3cr3t")
+	cfg = backendConfig{}
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider, conf.SecretEnvProvider))
+	assert.Equal(t, "s3cr3t", cfg.Secret)
+}
+
+func TestRequiredIfConditionUnmet(t *testing.T) {
+	os.Setenv("AWS_BACKEND", "gcs")
+	defer os.Clearenv()
+
+	var cfg backendConfig
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider, conf.SecretEnvProvider))
+
+	os.Clearenv()
+	cfg = backendConfig{}
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider, conf.SecretEnvProvider))
+	assert.Empty(t, cfg.Backend)
+}
+
+func TestRequiredIfFieldSet(t *testing.T) {
+	defer os.Clearenv()
+
+	os.Setenv("TLS_CERT", "cert.pem")
+	var cfg backendConfig
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: required environment variable "TLS_KEY" is not set when TLS_CERT`)
+
+	os.Clearenv()
+	os.Setenv("PROXY_PORT", "0")
+	cfg = backendConfig{}
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: required environment variable "PROXY_HOST" is not set when ProxyPort`)
+}
+
+func TestRequiredIfNested(t *testing.T) {
+	type config struct {
+		Storage struct {
+			Backend string `env:"BACKEND"`
+			Bucket  string `env:"BUCKET" envRequiredIf:"BACKEND=s3"`
+		}
+	}
+	os.Setenv("BACKEND", "s3")
+	defer os.Clearenv()
+
+	var cfg config
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: required environment variable "BUCKET" is not set when BACKEND=s3`)
+}
+
+func TestRequiredIfUnknownKey(t *testing.T) {
+	type config struct {
+		Bucket string `env:"BUCKET" envRequiredIf:"NOPE=s3"`
+	}
+
+	var cfg config
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: field "Bucket" has envRequiredIf on unknown key "NOPE"`)
+}
+
+func TestRequiredIfPrefixedKey(t *testing.T) {
+	type config struct {
+		DB struct {
+			Host string `env:"HOST" envRequiredIf:"PORT"`
+			Port int    `env:"PORT"`
+		} `envPrefix:"DB_"`
+	}
+	os.Setenv("DB_PORT", "5432")
+	defer os.Clearenv()
+
+	var cfg config
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: required environment variable "DB_HOST" is not set when PORT`)
+}
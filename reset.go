@@ -0,0 +1,76 @@
+package conf
+
+import "reflect"
+
+// Reset sets every settable field of the struct v points to back to its zero
+// value, so it can be parsed again. Nested structs are reset field by field
+// and pointers to structs keep their allocation, since Parse only fills in
+// pointers to structs which are not nil. Structs which are parsed from a
+// single value, such as time.Time, netip.Addr and url.URL, are zeroed as a
+// whole instead.
+func Reset(v interface{}) error {
+	ptrRef := reflect.ValueOf(v)
+	if ptrRef.Kind() != reflect.Ptr {
+		return ErrNotAStructPtr
+	}
+	ref := ptrRef.Elem()
+	if ref.Kind() != reflect.Struct {
+		return ErrNotAStructPtr
+	}
+	reset(ref)
+	return nil
+}
+
+// ResetToDefaults resets v like Reset and then sets every field with an
+// `envDefault` tag to its default.
+func ResetToDefaults(v interface{}) error {
+	if err := Reset(v); err != nil {
+		return err
+	}
+	if err := checkPatterns(v); err != nil {
+		return err
+	}
+	p := &parser{provider: defaultsProvider{}, subtrees: true}
+	return p.parsePtr(v)
+}
+
+func reset(ref reflect.Value) {
+	var refType = ref.Type()
+
+	for i := 0; i < refType.NumField(); i++ {
+		refField := ref.Field(i)
+		if !refField.CanSet() {
+			continue
+		}
+		if reflect.Ptr == refField.Kind() && !refField.IsNil() && reflect.Struct == refField.Elem().Kind() && !isValueStruct(refField.Elem().Type()) {
+			reset(refField.Elem())
+			continue
+		}
+		if reflect.Struct == refField.Kind() && !isValueStruct(refField.Type()) {
+			reset(refField)
+			continue
+		}
+		refField.Set(reflect.Zero(refField.Type()))
+	}
+}
+
+// isValueStruct reports whether the struct type t is parsed from a single
+// value, with a parser or as a TextUnmarshaler, or has only unexported fields,
+// so reset can only zero it as a whole.
+func isValueStruct(t reflect.Type) bool {
+	if isOpaque(t) || reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+	if _, ok := defaultTypeParsers[t]; ok {
+		return true
+	}
+	_, ok := registeredParser(t)
+	return ok
+}
+
+// defaultsProvider provides the `envDefault` tag of every field.
+type defaultsProvider struct{}
+
+func (defaultsProvider) Provide(field reflect.StructField) (string, error) {
+	return field.Tag.Get("envDefault"), nil
+}
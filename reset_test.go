@@ -0,0 +1,108 @@
+package conf_test
+
+import (
+	"net/netip"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type resetInner struct {
+	Name string `env:"INNER_NAME" envDefault:"inner"`
+	Tags []string
+}
+
+type resetConfig struct {
+	Port    int               `env:"PORT" envDefault:"3000"`
+	Hosts   []string          `env:"HOSTS" envDefault:"a,b"`
+	Labels  map[string]string `env:"LABELS"`
+	Timeout *time.Duration    `env:"TIMEOUT" envDefault:"5s"`
+	Inner   resetInner
+	Ptr     *resetInner
+	private string
+}
+
+func TestReset(t *testing.T) {
+	timeout := time.Minute
+	inner := &resetInner{Name: "ptr", Tags: []string{"x"}}
+	cfg := resetConfig{
+		Port:    8080,
+		Hosts:   []string{"c"},
+		Labels:  map[string]string{"a": "b"},
+		Timeout: &timeout,
+		Inner:   resetInner{Name: "value", Tags: []string{"y"}},
+		Ptr:     inner,
+		private: "kept",
+	}
+
+	require.NoError(t, conf.Reset(&cfg))
+	assert.Equal(t, resetConfig{Ptr: &resetInner{}, private: "kept"}, cfg)
+	assert.True(t, inner == cfg.Ptr, "pointers to structs should keep their allocation")
+}
+
+func TestResetToDefaults(t *testing.T) {
+	cfg := resetConfig{
+		Port:   8080,
+		Labels: map[string]string{"a": "b"},
+		Inner:  resetInner{Name: "value"},
+		Ptr:    &resetInner{Name: "ptr"},
+	}
+
+	require.NoError(t, conf.ResetToDefaults(&cfg))
+	assert.Equal(t, 3000, cfg.Port)
+	assert.Equal(t, []string{"a", "b"}, cfg.Hosts)
+	assert.Nil(t, cfg.Labels)
+	assert.Equal(t, 5*time.Second, *cfg.Timeout)
+	assert.Equal(t, "inner", cfg.Inner.Name)
+	assert.Equal(t, "inner", cfg.Ptr.Name)
+}
+
+func TestResetAndReparse(t *testing.T) {
+	defer os.Clearenv()
+
+	os.Setenv("HOSTS", "x,y,z")
+	var cfg resetConfig
+	require.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, []string{"x", "y", "z"}, cfg.Hosts)
+
+	os.Unsetenv("HOSTS")
+	require.NoError(t, conf.Reset(&cfg))
+	require.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, []string{"a", "b"}, cfg.Hosts)
+}
+
+func TestResetNotAStructPtr(t *testing.T) {
+	assert.Equal(t, conf.ErrNotAStructPtr, conf.Reset(resetConfig{}))
+	assert.Equal(t, conf.ErrNotAStructPtr, conf.ResetToDefaults(new(int)))
+}
+
+func TestResetValueStructs(t *testing.T) {
+	type config struct {
+		Expires time.Time    `env:"EXPIRES"`
+		Addr    netip.Addr   `env:"ADDR"`
+		URL     url.URL      `env:"URL"`
+		URLPtr  *url.URL     `env:"URL_PTR"`
+		Prefix  netip.Prefix `env:"PREFIX"`
+	}
+
+	cfg := config{
+		Expires: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		Addr:    netip.MustParseAddr("10.0.0.1"),
+		URL:     url.URL{Scheme: "https", User: url.UserPassword("u", "p"), Host: "example.com"},
+		URLPtr:  &url.URL{Scheme: "https", User: url.User("u"), Host: "example.com"},
+		Prefix:  netip.MustParsePrefix("10.0.0.0/8"),
+	}
+
+	require.NoError(t, conf.Reset(&cfg))
+	assert.True(t, cfg.Expires.IsZero())
+	assert.Equal(t, netip.Addr{}, cfg.Addr)
+	assert.Equal(t, url.URL{}, cfg.URL)
+	assert.Nil(t, cfg.URLPtr)
+	assert.Equal(t, netip.Prefix{}, cfg.Prefix)
+	assert.Equal(t, config{}, cfg)
+}
@@ -0,0 +1,35 @@
+package conf
+
+import (
+	"fmt"
+	"time"
+)
+
+// RetryPolicy holds the settings of retries with exponential backoff, with the
+// defaults of a call to another service. Give the field an `envPrefix` to read
+// the settings of each policy, e.g. `PAYMENTS_RETRY_ATTEMPTS`. Attempts
+// includes the first try, so 1 means no retries, and Jitter is the fraction of
+// each delay which is randomised, from 0 for none to 1.
+type RetryPolicy struct {
+	Attempts  int           `env:"ATTEMPTS" envDefault:"3"`
+	BaseDelay time.Duration `env:"BASE_DELAY" envDefault:"100ms"`
+	MaxDelay  time.Duration `env:"MAX_DELAY" envDefault:"5s"`
+	Jitter    float64       `env:"JITTER" envDefault:"0.2"`
+}
+
+// Validate reports policies which never try, negative delays, a base delay
+// above the max delay, which no delay could be kept under, and jitter outside
+// 0 to 1. It is called when parsing with WithValidators.
+func (r RetryPolicy) Validate() error {
+	switch {
+	case r.Attempts < 1:
+		return fmt.Errorf("retry attempts %d is less than 1", r.Attempts)
+	case r.BaseDelay < 0:
+		return fmt.Errorf("retry base delay %s is negative", r.BaseDelay)
+	case r.BaseDelay > r.MaxDelay:
+		return fmt.Errorf("retry base delay %s exceeds max delay %s", r.BaseDelay, r.MaxDelay)
+	case r.Jitter < 0 || r.Jitter > 1:
+		return fmt.Errorf("retry jitter %g is not between 0 and 1", r.Jitter)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package conf_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	type config struct {
+		Payments conf.RetryPolicy  `envPrefix:"PAYMENTS_RETRY_"`
+		Search   *conf.RetryPolicy `envPrefix:"SEARCH_RETRY_"`
+	}
+
+	cfg := config{Search: &conf.RetryPolicy{}}
+	require.NoError(t, conf.Parse(&cfg, conf.NewMapProvider(map[string]string{
+		"PAYMENTS_RETRY_ATTEMPTS":   "5",
+		"PAYMENTS_RETRY_BASE_DELAY": "1s",
+		"PAYMENTS_RETRY_MAX_DELAY":  "1m",
+		"PAYMENTS_RETRY_JITTER":     "0.5",
+		"SEARCH_RETRY_ATTEMPTS":     "1",
+	})))
+	assert.Equal(t, conf.RetryPolicy{Attempts: 5, BaseDelay: time.Second, MaxDelay: time.Minute, Jitter: 0.5}, cfg.Payments)
+	assert.Equal(t, conf.RetryPolicy{Attempts: 1, BaseDelay: 100 * time.Millisecond, MaxDelay: 5 * time.Second, Jitter: 0.2}, *cfg.Search)
+}
+
+func TestRetryPolicyDefaults(t *testing.T) {
+	type config struct {
+		Retry conf.RetryPolicy `envPrefix:"RETRY_"`
+	}
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.NewMapProvider(map[string]string{})))
+	assert.Equal(t, conf.RetryPolicy{Attempts: 3, BaseDelay: 100 * time.Millisecond, MaxDelay: 5 * time.Second, Jitter: 0.2}, cfg.Retry)
+}
+
+func TestRetryPolicyInvalid(t *testing.T) {
+	type config struct {
+		Retry conf.RetryPolicy `envPrefix:"RETRY_"`
+	}
+
+	for name, tc := range map[string]struct {
+		env map[string]string
+		err string
+	}{
+		"no attempts": {
+			env: map[string]string{"RETRY_ATTEMPTS": "0"},
+			err: `env: validation failed on field "Retry": retry attempts 0 is less than 1`,
+		},
+		"negative base delay": {
+			env: map[string]string{"RETRY_BASE_DELAY": "-1s"},
+			err: `env: validation failed on field "Retry": retry base delay -1s is negative`,
+		},
+		"base exceeds max": {
+			env: map[string]string{"RETRY_BASE_DELAY": "10s", "RETRY_MAX_DELAY": "1s"},
+			err: `env: validation failed on field "Retry": retry base delay 10s exceeds max delay 1s`,
+		},
+		"base exceeds default max": {
+			env: map[string]string{"RETRY_BASE_DELAY": "6s"},
+			err: `env: validation failed on field "Retry": retry base delay 6s exceeds max delay 5s`,
+		},
+		"jitter": {
+			env: map[string]string{"RETRY_JITTER": "1.5"},
+			err: `env: validation failed on field "Retry": retry jitter 1.5 is not between 0 and 1`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := config{}
+			assert.EqualError(t, conf.ParseWithOptions(&cfg, []conf.Option{conf.WithValidators()}, conf.NewMapProvider(tc.env)), tc.err)
+		})
+	}
+}
+
+func TestRetryPolicyEqualDelays(t *testing.T) {
+	type config struct {
+		Retry conf.RetryPolicy `envPrefix:"RETRY_"`
+	}
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.NewMapProvider(map[string]string{
+		"RETRY_BASE_DELAY": "5s",
+		"RETRY_JITTER":     "0",
+	})))
+	assert.Equal(t, 5*time.Second, cfg.Retry.BaseDelay)
+	assert.Equal(t, float64(0), cfg.Retry.Jitter)
+}
@@ -0,0 +1,25 @@
+package conf
+
+import "reflect"
+
+// SchemaValidator validates the JSON value of a field against the schema
+// given by its `envSchema` tag, a path to a schema file or the schema itself.
+// Schema libraries are not a dependency of conf, see the schema package for
+// a SchemaValidator for JSON Schema.
+type SchemaValidator func(schema string, value []byte) error
+
+// checkSchema validates the JSON value of sf against the schema of its
+// `envSchema` tag, if it has one.
+func (p *parser) checkSchema(sf reflect.StructField, value []byte) error {
+	schema := sf.Tag.Get("envSchema")
+	if schema == "" {
+		return nil
+	}
+	if p.opts.schemaValidator == nil {
+		return newError(`field "%s" has envSchema but no schema validator is set`, sf.Name)
+	}
+	if err := p.opts.schemaValidator(schema, value); err != nil {
+		return newParseError(sf, err)
+	}
+	return nil
+}
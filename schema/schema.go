@@ -0,0 +1,74 @@
+// Package schema provides a conf schema validator for JSON Schema, which
+// checks the JSON objects of fields tagged with `envSchema` before they are
+// decoded.
+//
+// It is a separate module so that the JSON Schema library is only required by
+// programs which validate their config against a schema. The tag holds either
+// the schema itself or the path to a schema file:
+//
+//	type config struct {
+//		Retry  Retry  `env:"RETRY" envSchema:"{\"required\": [\"attempts\"]}"`
+//		Routes Routes `env:"ROUTES" envSchema:"schemas/routes.json"`
+//	}
+//
+//	var cfg config
+//	err := conf.ParseWithOptions(&cfg, []conf.Option{conf.WithSchemaValidator(schema.New())}, conf.EnvProvider)
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/steinfletcher/conf"
+)
+
+// New returns a conf.SchemaValidator for JSON Schema. A schema which starts
+// with "{" is the schema itself, otherwise it is the path to a schema file.
+// Schemas are compiled the first time they are used and then reused.
+func New() conf.SchemaValidator {
+	var mu sync.Mutex
+	compiled := map[string]*jsonschema.Schema{}
+
+	return func(schema string, value []byte) error {
+		mu.Lock()
+		s, ok := compiled[schema]
+		if !ok {
+			var err error
+			if s, err = compile(schema); err != nil {
+				mu.Unlock()
+				return err
+			}
+			compiled[schema] = s
+		}
+		mu.Unlock()
+
+		dec := json.NewDecoder(bytes.NewReader(value))
+		dec.UseNumber()
+		var doc interface{}
+		if err := dec.Decode(&doc); err != nil {
+			return fmt.Errorf("unable to decode JSON: %v", err)
+		}
+		if err := s.Validate(doc); err != nil {
+			return fmt.Errorf("does not match schema: %v", err)
+		}
+		return nil
+	}
+}
+
+func compile(schema string) (*jsonschema.Schema, error) {
+	var s *jsonschema.Schema
+	var err error
+	if strings.HasPrefix(strings.TrimSpace(schema), "{") {
+		s, err = jsonschema.CompileString("inline.json", schema)
+	} else {
+		s, err = jsonschema.Compile(schema)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %v", err)
+	}
+	return s, nil
+}
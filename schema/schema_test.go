@@ -0,0 +1,59 @@
+package schema_test
+
+import (
+	"testing"
+
+	"github.com/steinfletcher/conf"
+	"github.com/steinfletcher/conf/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type retry struct {
+	Attempts int    `json:"attempts"`
+	Backoff  string `json:"backoff"`
+}
+
+type config struct {
+	File   retry `env:"RETRY" envSchema:"testdata/retry.json"`
+	Inline retry `env:"RETRY" envSchema:"{\"required\": [\"backoff\"]}"`
+}
+
+func parse(value string) (config, error) {
+	var cfg config
+	err := conf.ParseWithOptions(&cfg, []conf.Option{conf.WithSchemaValidator(schema.New())}, conf.NewMapProvider(map[string]string{
+		"RETRY": value,
+	}))
+	return cfg, err
+}
+
+func TestConformingValue(t *testing.T) {
+	cfg, err := parse(`{"attempts": 3, "backoff": "1s"}`)
+	require.NoError(t, err)
+	assert.Equal(t, retry{Attempts: 3, Backoff: "1s"}, cfg.File)
+	assert.Equal(t, retry{Attempts: 3, Backoff: "1s"}, cfg.Inline)
+}
+
+func TestNonConformingValue(t *testing.T) {
+	_, err := parse(`{"attempts": 0, "backoff": "1s"}`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `env: parse error on field "File" of type "schema_test.retry": does not match schema: jsonschema: '/attempts' does not validate`)
+	assert.Contains(t, err.Error(), "must be >= 1 but found 0")
+
+	_, err = parse(`{"attempts": 3, "retries": 2}`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "additionalProperties 'retries' not allowed")
+}
+
+func TestInvalidSchema(t *testing.T) {
+	type config struct {
+		Retry retry `env:"RETRY" envSchema:"testdata/missing.json"`
+	}
+
+	var cfg config
+	err := conf.ParseWithOptions(&cfg, []conf.Option{conf.WithSchemaValidator(schema.New())}, conf.NewMapProvider(map[string]string{
+		"RETRY": `{"attempts": 3}`,
+	}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `env: parse error on field "Retry" of type "schema_test.retry": invalid schema: `)
+}
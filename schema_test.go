@@ -0,0 +1,79 @@
+package conf_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// requiredKeys is a SchemaValidator whose schema is a comma separated list of
+// the keys the object must have.
+func requiredKeys(schema string, value []byte) error {
+	var object map[string]interface{}
+	if err := json.Unmarshal(value, &object); err != nil {
+		return err
+	}
+	if _, ok := object[schema]; !ok {
+		return fmt.Errorf("missing key %q", schema)
+	}
+	return nil
+}
+
+type retryPolicy struct {
+	Attempts int    `json:"attempts"`
+	Backoff  string `json:"backoff"`
+}
+
+func TestSchema(t *testing.T) {
+	type config struct {
+		Retry  retryPolicy  `env:"RETRY" envSchema:"attempts"`
+		Nested *retryPolicy `env:"NESTED" envSchema:"attempts" envNested:"true"`
+	}
+
+	cfg := config{}
+	require.NoError(t, conf.ParseWithOptions(&cfg, []conf.Option{conf.WithSchemaValidator(requiredKeys)}, conf.NewMapProvider(map[string]string{
+		"RETRY":  `{"attempts": 3, "backoff": "1s"}`,
+		"NESTED": `{"attempts": 5}`,
+	})))
+	assert.Equal(t, retryPolicy{Attempts: 3, Backoff: "1s"}, cfg.Retry)
+}
+
+func TestSchemaInvalid(t *testing.T) {
+	type config struct {
+		Retry retryPolicy `env:"RETRY" envSchema:"attempts"`
+	}
+
+	cfg := config{}
+	err := conf.ParseWithOptions(&cfg, []conf.Option{conf.WithSchemaValidator(requiredKeys)}, conf.NewMapProvider(map[string]string{
+		"RETRY": `{"backoff": "1s"}`,
+	}))
+	assert.EqualError(t, err, `env: parse error on field "Retry" of type "conf_test.retryPolicy": missing key "attempts"`)
+}
+
+func TestSchemaNested(t *testing.T) {
+	type config struct {
+		Retry retryPolicy `env:"RETRY" envSchema:"attempts" envNested:"true"`
+	}
+
+	cfg := config{}
+	err := conf.ParseWithOptions(&cfg, []conf.Option{conf.WithSchemaValidator(requiredKeys)}, conf.NewMapProvider(map[string]string{
+		"RETRY": `{"backoff": "1s"}`,
+	}))
+	assert.EqualError(t, err, `env: parse error on field "Retry" of type "conf_test.retryPolicy": missing key "attempts"`)
+}
+
+func TestSchemaWithoutValidator(t *testing.T) {
+	type config struct {
+		Retry retryPolicy `env:"RETRY" envSchema:"attempts"`
+	}
+
+	cfg := config{}
+	err := conf.Parse(&cfg, conf.NewMapProvider(map[string]string{
+		"RETRY": `{"attempts": 3}`,
+	}))
+	assert.EqualError(t, err, `env: field "Retry" has envSchema but no schema validator is set`)
+}
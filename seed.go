@@ -0,0 +1,38 @@
+package conf
+
+import (
+	"reflect"
+	"strings"
+)
+
+// isSeededMap reports whether a map field has keys to seed with
+// `envMapKeys`, which it has even when its variable is not set.
+func isSeededMap(field reflect.Value, sf reflect.StructField) bool {
+	return reflect.Map == field.Kind() && sf.Tag.Get("envMapKeys") != ""
+}
+
+// seedMap adds the keys of the `envMapKeys` tag of sf to m, with the value of
+// its `envMapDefault` tag or the zero value, before the configured pairs are
+// added over them.
+func seedMap(m reflect.Value, sf reflect.StructField, keyParser, valueParser func(string) (reflect.Value, error)) error {
+	keys := sf.Tag.Get("envMapKeys")
+	if keys == "" {
+		return nil
+	}
+	def, hasDefault := sf.Tag.Lookup("envMapDefault")
+	for _, k := range strings.Split(keys, ",") {
+		key, err := keyParser(strings.TrimSpace(k))
+		if err != nil {
+			return newError(`field "%s" has invalid envMapKeys key %q: %v`, sf.Name, k, err)
+		}
+		// the default is parsed for each key, so pointers are not shared
+		val := reflect.Zero(sf.Type.Elem())
+		if hasDefault {
+			if val, err = valueParser(def); err != nil {
+				return newError(`field "%s" has invalid envMapDefault %q: %v`, sf.Name, def, err)
+			}
+		}
+		m.SetMapIndex(key, val)
+	}
+	return nil
+}
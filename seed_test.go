@@ -0,0 +1,68 @@
+package conf_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapKeys(t *testing.T) {
+	type config struct {
+		Timeouts map[string]time.Duration `env:"TIMEOUTS" envMapKeys:"read,write,idle" envMapDefault:"30s"`
+		Limits   map[string]int           `env:"LIMITS" envMapKeys:"cpu,memory"`
+		Retries  map[string]*int          `env:"RETRIES" envMapKeys:"a,b" envMapDefault:"3"`
+		Labels   map[string]string        `envMapPrefix:"LABEL_" envMapKeys:"team" envMapDefault:"unknown"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("TIMEOUTS", "write=5s,connect=1s")
+	os.Setenv("LABEL_TIER", "web")
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, map[string]time.Duration{
+		"read":    30 * time.Second,
+		"write":   5 * time.Second,
+		"idle":    30 * time.Second,
+		"connect": time.Second,
+	}, cfg.Timeouts)
+	assert.Equal(t, map[string]int{"cpu": 0, "memory": 0}, cfg.Limits)
+	require.Len(t, cfg.Retries, 2)
+	assert.Equal(t, 3, *cfg.Retries["a"])
+	assert.True(t, cfg.Retries["a"] != cfg.Retries["b"])
+	assert.Equal(t, map[string]string{"team": "unknown", "tier": "web"}, cfg.Labels)
+}
+
+func TestMapKeysProviders(t *testing.T) {
+	type config struct {
+		Timeouts map[string]time.Duration `env:"TIMEOUTS" envMapKeys:"read,write" envMapDefault:"30s"`
+	}
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg,
+		conf.NewMapProvider(map[string]string{"TIMEOUTS": "read=1s"}),
+		conf.NewMapProvider(map[string]string{})))
+	assert.Equal(t, map[string]time.Duration{"read": time.Second, "write": 30 * time.Second}, cfg.Timeouts)
+}
+
+func TestMapKeysErrors(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		type config struct {
+			Timeouts map[string]time.Duration `env:"TIMEOUTS" envMapKeys:"read" envMapDefault:"soon"`
+		}
+		cfg := config{}
+		assert.EqualError(t, conf.Parse(&cfg, conf.NewMapProvider(map[string]string{})), `env: field "Timeouts" has invalid envMapDefault "soon": unable to parser duration: time: invalid duration "soon"`)
+	})
+
+	t.Run("key", func(t *testing.T) {
+		type config struct {
+			Ports map[int]bool `env:"PORTS" envMapKeys:"80,https"`
+		}
+		cfg := config{}
+		assert.EqualError(t, conf.Parse(&cfg, conf.NewMapProvider(map[string]string{"PORTS": "80=true"})), `env: field "Ports" has invalid envMapKeys key "https": strconv.ParseInt: parsing "https": invalid syntax`)
+	})
+}
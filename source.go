@@ -0,0 +1,77 @@
+package conf
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// RegisterProvider registers provider under name, so it can be selected for a
+// single field with the `source` tag, e.g. `env:"DB_PASS" source:"vault"`, or
+// for a struct with the `confProvider` tag. The `env` and `secret` providers
+// are registered by default, and registering a name again replaces its
+// provider.
+func RegisterProvider(name string, provider Provider) {
+	namedProvidersMu.Lock()
+	defer namedProvidersMu.Unlock()
+	namedProviders[name] = provider
+}
+
+func lookupProvider(name string) (Provider, bool) {
+	namedProvidersMu.RLock()
+	defer namedProvidersMu.RUnlock()
+	provider, ok := namedProviders[name]
+	return provider, ok
+}
+
+// withSource returns the parser which reads the field sf with the provider
+// named by its `source` tag. The named provider replaces the providers
+// passed to Parse, so it reads the field in the first pass only and skip
+// reports the other passes. A field whose provider is not registered falls
+// back to the providers passed to Parse, with a warning.
+func (p *parser) withSource(sf reflect.StructField, name string) (sourced *parser, skip bool) {
+	if _, ok := p.provider.(defaultsProvider); ok {
+		return p, false
+	}
+	provider, ok := lookupProvider(name)
+	if !ok {
+		if p.subtrees {
+			p.opts.warn(errorPrefix(p.opts.errorPrefix) + ": " +
+				fmt.Sprintf(`field "%s" has unknown source %q, it is read from the providers passed to Parse`, sf.Name, name))
+		}
+		return p, false
+	}
+	if !p.subtrees {
+		return nil, true
+	}
+	if c, ok := provider.(configurableProvider); ok {
+		provider = c.withOptions(p.opts)
+	}
+	if e, ok := provider.(envProvider); ok && e.tag != EnvProvider.tag {
+		provider = sourcedEnvProvider{e}
+	}
+	copied := *p
+	copied.provider = provider
+	return &copied, false
+}
+
+// sourcedEnvProvider reads a field selected by its `source` tag with an
+// envProvider of another tag, such as SecretEnvProvider, at the key of its
+// `env` tag when it has none of the provider's tag, so
+// `env:"DB_PASS,required" source:"secret"` reads and requires DB_PASS.
+type sourcedEnvProvider struct {
+	envProvider
+}
+
+func (o sourcedEnvProvider) Provide(field reflect.StructField) (string, error) {
+	result, err := o.ProvideResult(field)
+	return result.Value, err
+}
+
+func (o sourcedEnvProvider) ProvideResult(field reflect.StructField) (Result, error) {
+	if _, ok := field.Tag.Lookup(o.tag); !ok {
+		if key, ok := field.Tag.Lookup(EnvProvider.tag); ok {
+			field.Tag = formatTag(append(parseTag(field.Tag), structTag{name: o.tag, value: key}))
+		}
+	}
+	return o.envProvider.ProvideResult(field)
+}
@@ -0,0 +1,118 @@
+package conf_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	conf.RegisterProvider("keystore", conf.NewMapProvider(map[string]string{
+		"DB_PASS": "s3cret",
+	}))
+}
+
+type sourcedConfig struct {
+	Host   string `env:"HOST"`
+	DBPass string `env:"DB_PASS" source:"keystore"`
+	Token  string `secret:"TOKEN" source:"secret"`
+	Region string `env:"REGION" source:"keystore" envDefault:"eu-west-1"`
+}
+
+func TestSource(t *testing.T) {
+	defer os.Clearenv()
+	os.Setenv("TOKEN", "t0ken")
+
+	cfg := sourcedConfig{}
+	require.NoError(t, conf.Parse(&cfg, conf.NewMapProvider(map[string]string{
+		"HOST":    "localhost",
+		"DB_PASS": "from-env",
+		"REGION":  "us-east-1",
+	})))
+	assert.Equal(t, sourcedConfig{Host: "localhost", DBPass: "s3cret", Token: "t0ken", Region: "eu-west-1"}, cfg)
+}
+
+func TestSourceOverridesEveryProvider(t *testing.T) {
+	cfg := sourcedConfig{}
+	require.NoError(t, conf.Parse(&cfg,
+		conf.NewMapProvider(map[string]string{"HOST": "localhost"}),
+		conf.NewMapProvider(map[string]string{"DB_PASS": "from-env"}),
+	))
+	assert.Equal(t, "localhost", cfg.Host)
+	assert.Equal(t, "s3cret", cfg.DBPass)
+}
+
+func TestSourceRequired(t *testing.T) {
+	type config struct {
+		APIKey string `env:"API_KEY,required" source:"keystore"`
+	}
+
+	cfg := config{}
+	err := conf.Parse(&cfg, conf.NewMapProvider(map[string]string{"API_KEY": "from-env"}))
+	assert.EqualError(t, err, `env: required environment variable "API_KEY" is not set`)
+}
+
+func TestSourceEnvKey(t *testing.T) {
+	type config struct {
+		DBPass string `env:"DB_PASS" source:"secret"`
+	}
+	defer os.Clearenv()
+	os.Setenv("DB_PASS", "s3cret")
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.NewMapProvider(map[string]string{"DB_PASS": "from-map"})))
+	assert.Equal(t, "s3cret", cfg.DBPass)
+}
+
+func TestSourceEnvKeyRequired(t *testing.T) {
+	type config struct {
+		DBPass string `env:"DB_PASS,required" source:"secret"`
+		Token  string `env:"TOKEN,notEmpty" source:"secret"`
+	}
+	defer os.Clearenv()
+
+	cfg := config{}
+	err := conf.Parse(&cfg, conf.NewMapProvider(map[string]string{"DB_PASS": "from-map"}))
+	assert.EqualError(t, err, `env: required environment variable "DB_PASS" is not set`)
+
+	os.Setenv("DB_PASS", "s3cret")
+	os.Setenv("TOKEN", "")
+	err = conf.Parse(&cfg, conf.EnvProvider)
+	assert.EqualError(t, err, `env: environment variable "TOKEN" should not be empty`)
+}
+
+func TestSourceUnknown(t *testing.T) {
+	type config struct {
+		DBPass string `env:"DB_PASS" source:"missing"`
+	}
+
+	cfg := config{}
+	warnings, err := conf.ParseWithWarnings(&cfg, conf.NewMapProvider(map[string]string{"DB_PASS": "from-env"}))
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", cfg.DBPass)
+	assert.Equal(t, []string{
+		`env: field "DBPass" has unknown source "missing", it is read from the providers passed to Parse`,
+	}, warnings)
+}
+
+func TestSourceConfProvider(t *testing.T) {
+	type credentials struct {
+		Password string `env:"DB_PASS"`
+	}
+	type config struct {
+		Credentials credentials `confProvider:"keystore"`
+	}
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.NewMapProvider(map[string]string{})))
+	assert.Equal(t, "s3cret", cfg.Credentials.Password)
+}
+
+func TestSourceResetToDefaults(t *testing.T) {
+	cfg := sourcedConfig{DBPass: "s3cret"}
+	require.NoError(t, conf.ResetToDefaults(&cfg))
+	assert.Equal(t, sourcedConfig{Region: "eu-west-1"}, cfg)
+}
@@ -0,0 +1,29 @@
+package conf
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+)
+
+// ParseTextTemplate compiles a `text/template`, such as `Hello {{.Name}}`.
+// Register it with `RegisterType(ParseTextTemplate)` to compile fields of
+// type *template.Template once, when config is parsed.
+func ParseTextTemplate(v string) (*texttemplate.Template, error) {
+	t, err := texttemplate.New("").Parse(v)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse template: %v", err)
+	}
+	return t, nil
+}
+
+// ParseHTMLTemplate compiles an `html/template`, which escapes the values it
+// is executed with. Register it with `RegisterType(ParseHTMLTemplate)`, as for
+// ParseTextTemplate.
+func ParseHTMLTemplate(v string) (*htmltemplate.Template, error) {
+	t, err := htmltemplate.New("").Parse(v)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse template: %v", err)
+	}
+	return t, nil
+}
@@ -0,0 +1,58 @@
+package conf_test
+
+import (
+	htmltemplate "html/template"
+	"strings"
+	"testing"
+	texttemplate "text/template"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	conf.RegisterType(conf.ParseTextTemplate)
+	conf.RegisterType(conf.ParseHTMLTemplate)
+}
+
+type notificationConfig struct {
+	Greeting *texttemplate.Template `env:"GREETING"`
+	Body     *htmltemplate.Template `env:"BODY"`
+}
+
+func TestTemplate(t *testing.T) {
+	cfg := notificationConfig{}
+	require.NoError(t, conf.Parse(&cfg, conf.NewMapProvider(map[string]string{
+		"GREETING": "Hello {{.Name}}",
+		"BODY":     "<p>{{.Name}}</p>",
+	})))
+
+	data := struct{ Name string }{Name: "<Ada>"}
+	var greeting, body strings.Builder
+	require.NoError(t, cfg.Greeting.Execute(&greeting, data))
+	require.NoError(t, cfg.Body.Execute(&body, data))
+	assert.Equal(t, "Hello <Ada>", greeting.String())
+	assert.Equal(t, "<p>&lt;Ada&gt;</p>", body.String())
+}
+
+func TestTemplateInvalid(t *testing.T) {
+	for name, tc := range map[string]struct {
+		env map[string]string
+		err string
+	}{
+		"text": {
+			env: map[string]string{"GREETING": "Hello {{.Name"},
+			err: `env: parse error on field "Greeting" of type "*template.Template": unable to parse template: template: :1: unclosed action`,
+		},
+		"html": {
+			env: map[string]string{"BODY": "<p>{{end}}</p>"},
+			err: `env: parse error on field "Body" of type "*template.Template": unable to parse template: template: :1: unexpected {{end}}`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := notificationConfig{}
+			assert.EqualError(t, conf.Parse(&cfg, conf.NewMapProvider(tc.env)), tc.err)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package conf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// TransformFunc transforms a value before it is parsed. arg is the text after
+// the colon of a parameterized step such as `stripPrefix:v`, and is empty for
+// steps without one.
+type TransformFunc func(value, arg string) (string, error)
+
+// nolint: gochecknoglobals
+var (
+	transformsMu sync.RWMutex
+	transforms   = map[string]TransformFunc{
+		"trim": func(v, _ string) (string, error) {
+			return strings.TrimSpace(v), nil
+		},
+		"lower": func(v, _ string) (string, error) {
+			return strings.ToLower(v), nil
+		},
+		"upper": func(v, _ string) (string, error) {
+			return strings.ToUpper(v), nil
+		},
+		"stripPrefix": func(v, prefix string) (string, error) {
+			if prefix == "" {
+				return "", fmt.Errorf("stripPrefix expects a prefix, e.g. stripPrefix:v")
+			}
+			return strings.TrimPrefix(v, prefix), nil
+		},
+		"stripSuffix": func(v, suffix string) (string, error) {
+			if suffix == "" {
+				return "", fmt.Errorf("stripSuffix expects a suffix, e.g. stripSuffix:/")
+			}
+			return strings.TrimSuffix(v, suffix), nil
+		},
+	}
+)
+
+// RegisterTransform registers a step which can be used in `envTransform`
+// pipelines under name, replacing any step of the same name.
+func RegisterTransform(name string, transform TransformFunc) {
+	transformsMu.Lock()
+	defer transformsMu.Unlock()
+	transforms[name] = transform
+}
+
+// transform applies the `envTransform` pipeline of sf to v. The steps are
+// separated by "|" and applied left to right, e.g. "trim|lower|stripPrefix:v".
+func transform(sf reflect.StructField, v string) (string, error) {
+	pipeline := sf.Tag.Get("envTransform")
+	if pipeline == "" {
+		return v, nil
+	}
+	for _, step := range strings.Split(pipeline, "|") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(step), ":")
+		transformsMu.RLock()
+		transformFunc, ok := transforms[name]
+		transformsMu.RUnlock()
+		if !ok {
+			return "", newError(`field "%s" has unknown envTransform step %q`, sf.Name, name)
+		}
+		var err error
+		if v, err = transformFunc(v, arg); err != nil {
+			return "", newParseError(sf, err)
+		}
+	}
+	return v, nil
+}
+
+// transformResult applies the `envTransform` pipeline of sf to the value of
+// result and to each of its values.
+func transformResult(sf reflect.StructField, result Result) (Result, error) {
+	if sf.Tag.Get("envTransform") == "" {
+		return result, nil
+	}
+	var err error
+	if result.Value, err = transform(sf, result.Value); err != nil {
+		return result, err
+	}
+	if result.Values != nil {
+		values := make([]string, len(result.Values))
+		for i, v := range result.Values {
+			if values[i], err = transform(sf, v); err != nil {
+				return result, err
+			}
+		}
+		result.Values = values
+	}
+	return result, nil
+}
@@ -0,0 +1,88 @@
+package conf_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	conf.RegisterTransform("mask", func(v, arg string) (string, error) {
+		if arg == "" {
+			return "", errors.New("mask expects a character")
+		}
+		if len(v) <= 4 {
+			return v, nil
+		}
+		out := []byte(v)
+		for i := 0; i < len(out)-4; i++ {
+			out[i] = arg[0]
+		}
+		return string(out), nil
+	})
+}
+
+func TestTransform(t *testing.T) {
+	type config struct {
+		Version string   `env:"VERSION" envTransform:"trim|lower|stripPrefix:v"`
+		Modes   []string `env:"MODES" envTransform:"trim | upper"`
+		Port    int      `env:"PORT" envTransform:"stripPrefix::"`
+		Base    string   `env:"BASE" envTransform:"stripSuffix:/"`
+		Level   string   `env:"LEVEL" envTransform:"lower" envDefault:"INFO"`
+		Card    string   `env:"CARD" envTransform:"trim|mask:*"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("VERSION", "  V1.2.3 ")
+	os.Setenv("MODES", " fast,safe ")
+	os.Setenv("PORT", ":8080")
+	os.Setenv("BASE", "/api/")
+	os.Setenv("CARD", " 4111111111111111")
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, "1.2.3", cfg.Version)
+	assert.Equal(t, []string{"FAST", "SAFE"}, cfg.Modes)
+	assert.Equal(t, 8080, cfg.Port)
+	assert.Equal(t, "/api", cfg.Base)
+	assert.Equal(t, "info", cfg.Level)
+	assert.Equal(t, "************1111", cfg.Card)
+}
+
+func TestTransformValues(t *testing.T) {
+	type config struct {
+		Peers []string `env:"PEER" envNumbered:"true" envTransform:"trim|lower"`
+	}
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.NewMapProvider(map[string]string{
+		"PEER_1": " A ",
+		"PEER_2": "B",
+	})))
+	assert.Equal(t, []string{"a", "b"}, cfg.Peers)
+}
+
+func TestTransformErrors(t *testing.T) {
+	defer os.Clearenv()
+	os.Setenv("VALUE", "v1")
+
+	t.Run("unknown step", func(t *testing.T) {
+		type config struct {
+			Value string `env:"VALUE" envTransform:"trim|reverse"`
+		}
+		cfg := config{}
+		assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: field "Value" has unknown envTransform step "reverse"`)
+	})
+
+	t.Run("missing argument", func(t *testing.T) {
+		type config struct {
+			Value string `env:"VALUE" envTransform:"stripPrefix"`
+		}
+		cfg := config{}
+		assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: parse error on field "Value" of type "string": stripPrefix expects a prefix, e.g. stripPrefix:v`)
+	})
+}
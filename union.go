@@ -0,0 +1,101 @@
+package conf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// nolint: gochecknoglobals
+var (
+	unionsMu sync.RWMutex
+	unions   = map[reflect.Type]jsonUnion{}
+)
+
+type jsonUnion struct {
+	typeField string
+	variants  map[string]reflect.Type
+}
+
+// RegisterJSONUnion registers the concrete types of an interface type, so
+// fields of that type can be read from a JSON object whose typeField member
+// names its type, e.g. `{"type": "s3", "bucket": "logs"}`. The object is
+// decoded into the variant like a struct field read from JSON. A variant may
+// be registered as a type which implements the interface or as a type whose
+// pointer does. RegisterJSONUnion panics if ifaceType is not an interface
+// type or a variant does not implement it.
+func RegisterJSONUnion(ifaceType reflect.Type, typeField string, variants map[string]reflect.Type) {
+	if ifaceType.Kind() != reflect.Interface {
+		panic(fmt.Sprintf("conf: RegisterJSONUnion called with non interface type %s", ifaceType))
+	}
+	copied := make(map[string]reflect.Type, len(variants))
+	for name, t := range variants {
+		if !t.Implements(ifaceType) && !reflect.PtrTo(t).Implements(ifaceType) {
+			panic(fmt.Sprintf("conf: RegisterJSONUnion called with type %s which does not implement %s", t, ifaceType))
+		}
+		copied[name] = t
+	}
+
+	unionsMu.Lock()
+	defer unionsMu.Unlock()
+	unions[ifaceType] = jsonUnion{typeField: typeField, variants: copied}
+}
+
+func lookupUnion(t reflect.Type) (jsonUnion, bool) {
+	if t.Kind() != reflect.Interface {
+		return jsonUnion{}, false
+	}
+	unionsMu.RLock()
+	defer unionsMu.RUnlock()
+	u, ok := unions[t]
+	return u, ok
+}
+
+// decodeJSONUnion sets the interface v to the variant of u named by the
+// discriminator of the JSON object data.
+func (p *parser) decodeJSONUnion(data []byte, v reflect.Value, u jsonUnion) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+	raw, ok := members[u.typeField]
+	if !ok {
+		return fmt.Errorf("missing %q member", u.typeField)
+	}
+	var name string
+	if err := json.Unmarshal(raw, &name); err != nil {
+		return fmt.Errorf("%q member should be a string", u.typeField)
+	}
+	t, ok := u.variants[name]
+	if !ok {
+		return fmt.Errorf("unknown %s %q, expected one of %s", u.typeField, name, strings.Join(u.names(), ", "))
+	}
+
+	variant := reflect.New(t)
+	if err := p.decodeJSON(data, variant.Elem()); err != nil {
+		return err
+	}
+	if t.Implements(v.Type()) {
+		v.Set(variant.Elem())
+	} else {
+		v.Set(variant)
+	}
+	return nil
+}
+
+func (u jsonUnion) names() []string {
+	names := make([]string, 0, len(u.variants))
+	for name := range u.variants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
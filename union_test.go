@@ -0,0 +1,93 @@
+package conf_test
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+)
+
+type storage interface {
+	Kind() string
+}
+
+type s3Storage struct {
+	Bucket  string        `json:"bucket"`
+	Timeout time.Duration `json:"timeout"`
+}
+
+func (s3Storage) Kind() string { return "s3" }
+
+type localStorage struct {
+	Path string `json:"path"`
+}
+
+func (*localStorage) Kind() string { return "local" }
+
+func init() {
+	conf.RegisterJSONUnion(reflect.TypeOf((*storage)(nil)).Elem(), "type", map[string]reflect.Type{
+		"s3":    reflect.TypeOf(s3Storage{}),
+		"local": reflect.TypeOf(localStorage{}),
+	})
+}
+
+func TestParsesJSONUnion(t *testing.T) {
+	type archive struct {
+		Stores []storage `json:"stores"`
+	}
+	type config struct {
+		Primary storage `env:"PRIMARY"`
+		Backup  storage `env:"BACKUP"`
+		Archive archive `env:"ARCHIVE"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("PRIMARY", `{"type": "s3", "bucket": "logs", "timeout": "5s"}`)
+	os.Setenv("BACKUP", `{"type": "local", "path": "/var/backup"}`)
+	os.Setenv("ARCHIVE", `{"stores": [{"type": "local", "path": "/a"}, {"type": "s3", "bucket": "b"}]}`)
+
+	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, s3Storage{Bucket: "logs", Timeout: 5 * time.Second}, cfg.Primary)
+	assert.Equal(t, &localStorage{Path: "/var/backup"}, cfg.Backup)
+	assert.Equal(t, []storage{&localStorage{Path: "/a"}, s3Storage{Bucket: "b"}}, cfg.Archive.Stores)
+}
+
+func TestJSONUnionErrors(t *testing.T) {
+	tests := []struct {
+		value string
+		err   string
+	}{
+		{`{"type": "gcs"}`, `unknown type "gcs", expected one of local, s3`},
+		{`{"bucket": "logs"}`, `missing "type" member`},
+		{`{"type": 1}`, `"type" member should be a string`},
+		{`{"type": "s3", "timeout": "soon"}`, `json field "timeout": unable to parser duration: time: invalid duration "soon"`},
+		{`s3`, `invalid character 's' looking for beginning of value`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			type config struct {
+				Primary storage `env:"PRIMARY"`
+			}
+			defer os.Clearenv()
+			os.Setenv("PRIMARY", tt.value)
+
+			cfg := config{}
+			assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: parse error on field "Primary" of type "conf_test.storage": `+tt.err)
+		})
+	}
+}
+
+func TestRegisterJSONUnionPanics(t *testing.T) {
+	assert.Panics(t, func() {
+		conf.RegisterJSONUnion(reflect.TypeOf(s3Storage{}), "type", nil)
+	})
+	assert.Panics(t, func() {
+		conf.RegisterJSONUnion(reflect.TypeOf((*storage)(nil)).Elem(), "type", map[string]reflect.Type{
+			"string": reflect.TypeOf(""),
+		})
+	})
+}
@@ -0,0 +1,84 @@
+package conf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// dedupe removes the duplicate elements of a slice field tagged with
+// `envUnique:"true"`, keeping the first of each. Pointer elements are compared
+// by the values they point to.
+func dedupe(field reflect.Value, sf reflect.StructField) error {
+	if strings.ToLower(sf.Tag.Get("envUnique")) != "true" {
+		return nil
+	}
+	slice, elemType, err := comparableSlice(field, sf, "envUnique")
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[interface{}]bool, slice.Len())
+	unique := reflect.MakeSlice(slice.Type(), 0, slice.Len())
+	for i := 0; i < slice.Len(); i++ {
+		elem := slice.Index(i)
+		key := elemKey(elem, elemType)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = reflect.Append(unique, elem)
+	}
+	slice.Set(unique)
+	return nil
+}
+
+// checkNoDuplicates fails on the first repeated element of a slice field
+// tagged with `envNoDuplicates:"true"`, for lists where a repeat is a mistake.
+func checkNoDuplicates(field reflect.Value, sf reflect.StructField) error {
+	if strings.ToLower(sf.Tag.Get("envNoDuplicates")) != "true" {
+		return nil
+	}
+	slice, elemType, err := comparableSlice(field, sf, "envNoDuplicates")
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[interface{}]bool, slice.Len())
+	for i := 0; i < slice.Len(); i++ {
+		key := elemKey(slice.Index(i), elemType)
+		if seen[key] {
+			return newParseError(sf, fmt.Errorf("duplicate element %q", fmt.Sprint(key)))
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// comparableSlice returns the slice of a field with tag and the type its
+// elements are compared by, the type they point to for pointer elements.
+func comparableSlice(field reflect.Value, sf reflect.StructField, tag string) (reflect.Value, reflect.Type, error) {
+	slice := indirect(field)
+	if slice.Kind() != reflect.Slice {
+		return slice, nil, newError(`field "%s" has %s but is not a slice`, sf.Name, tag)
+	}
+	elemType := slice.Type().Elem()
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	if !elemType.Comparable() {
+		return slice, nil, newError(`field "%s" has %s but its elements of type "%s" are not comparable`, sf.Name, tag, elemType)
+	}
+	return slice, elemType, nil
+}
+
+// elemKey returns the value elem is compared by, the value it points to for
+// a pointer and the zero value of elemType for a nil pointer.
+func elemKey(elem reflect.Value, elemType reflect.Type) interface{} {
+	if elem.Kind() == reflect.Ptr {
+		if elem = elem.Elem(); !elem.IsValid() {
+			elem = reflect.Zero(elemType)
+		}
+	}
+	return elem.Interface()
+}
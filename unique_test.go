@@ -0,0 +1,113 @@
+package conf_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnique(t *testing.T) {
+	type config struct {
+		Hosts     []string `env:"HOSTS" envUnique:"true"`
+		Ports     []int    `env:"PORTS" envUnique:"true"`
+		PortPtrs  []*int   `env:"PORTS" envUnique:"true"`
+		AllPorts  []int    `env:"PORTS"`
+		Defaulted []string `env:"DEFAULTED" envUnique:"true" envDefault:"a,a"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("HOSTS", "b,a,b,c,a")
+	os.Setenv("PORTS", "443,80,443,443")
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, []string{"b", "a", "c"}, cfg.Hosts)
+	assert.Equal(t, []int{443, 80}, cfg.Ports)
+	require.Len(t, cfg.PortPtrs, 2)
+	assert.Equal(t, 443, *cfg.PortPtrs[0])
+	assert.Equal(t, 80, *cfg.PortPtrs[1])
+	assert.Equal(t, []int{443, 80, 443, 443}, cfg.AllPorts)
+	assert.Equal(t, []string{"a"}, cfg.Defaulted)
+}
+
+func TestUniqueWithItems(t *testing.T) {
+	type config struct {
+		Hosts []string `env:"HOSTS" envUnique:"true" envMinItems:"2"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("HOSTS", "a,a")
+
+	cfg := config{}
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: field "Hosts" should have at least 2 items, got 1`)
+}
+
+func TestUniqueErrors(t *testing.T) {
+	defer os.Clearenv()
+	os.Setenv("VALUE", `[{"a": "1"}, {"a": "1"}]`)
+
+	t.Run("not comparable", func(t *testing.T) {
+		type config struct {
+			Values []map[string]string `env:"VALUE" envUnique:"true"`
+		}
+		cfg := config{}
+		assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: field "Values" has envUnique but its elements of type "map[string]string" are not comparable`)
+	})
+
+	t.Run("not a slice", func(t *testing.T) {
+		type config struct {
+			Value string `env:"VALUE" envUnique:"true"`
+		}
+		cfg := config{}
+		assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: field "Value" has envUnique but is not a slice`)
+	})
+}
+
+func TestNoDuplicates(t *testing.T) {
+	type config struct {
+		Hosts    []string `env:"HOSTS" envNoDuplicates:"true"`
+		PortPtrs []*int   `env:"PORTS" envNoDuplicates:"true"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("HOSTS", "b,a,c")
+	os.Setenv("PORTS", "443,80")
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, []string{"b", "a", "c"}, cfg.Hosts)
+	require.Len(t, cfg.PortPtrs, 2)
+}
+
+func TestNoDuplicatesErrors(t *testing.T) {
+	defer os.Clearenv()
+
+	t.Run("duplicate", func(t *testing.T) {
+		type config struct {
+			Hosts []string `env:"HOSTS" envNoDuplicates:"true"`
+		}
+		os.Setenv("HOSTS", "a.example.com,b.example.com,a.example.com")
+		cfg := config{}
+		assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: parse error on field "Hosts" of type "[]string": duplicate element "a.example.com"`)
+	})
+
+	t.Run("duplicate pointer", func(t *testing.T) {
+		type config struct {
+			Ports []*int `env:"PORTS" envNoDuplicates:"true"`
+		}
+		os.Setenv("PORTS", "80,443,80")
+		cfg := config{}
+		assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: parse error on field "Ports" of type "[]*int": duplicate element "80"`)
+	})
+
+	t.Run("not a slice", func(t *testing.T) {
+		type config struct {
+			Host string `env:"HOSTS" envNoDuplicates:"true"`
+		}
+		cfg := config{}
+		assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: field "Host" has envNoDuplicates but is not a slice`)
+	})
+}
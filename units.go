@@ -0,0 +1,115 @@
+package conf
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// nolint: gochecknoglobals
+var (
+	unitsMu sync.RWMutex
+	units   = map[reflect.Type]map[string]func(float64) float64{}
+
+	celsiusType      = reflect.TypeOf(Celsius(0))
+	temperatureUnits = map[string]func(float64) float64{
+		"":   func(c float64) float64 { return c },
+		"C":  func(c float64) float64 { return c },
+		"°C": func(c float64) float64 { return c },
+		"F":  func(f float64) float64 { return (f - 32) * 5 / 9 },
+		"°F": func(f float64) float64 { return (f - 32) * 5 / 9 },
+		"K":  func(k float64) float64 { return k - 273.15 },
+	}
+)
+
+// Celsius is a temperature in degrees Celsius. It is parsed from a number
+// with a unit of C, F or K, e.g. `THRESHOLD=72F`, which is converted to
+// Celsius. A number without a unit is in Celsius.
+type Celsius float64
+
+// RegisterUnits registers the unit suffixes of a float type and the functions
+// which convert a number in each unit to the value of the type, so fields of
+// that type can be configured with a unit, e.g. `THRESHOLD=72F`. Units are
+// matched exactly, after the number and any spaces, and parsing fails for
+// units which are not registered. Register the empty unit to accept numbers
+// without a unit. RegisterUnits panics if t is not a float type.
+func RegisterUnits(t reflect.Type, conversions map[string]func(float64) float64) {
+	if t.Kind() != reflect.Float32 && t.Kind() != reflect.Float64 {
+		panic(fmt.Sprintf("conf: RegisterUnits called with non float type %s", t))
+	}
+	copied := make(map[string]func(float64) float64, len(conversions))
+	for unit, convert := range conversions {
+		copied[unit] = convert
+	}
+
+	unitsMu.Lock()
+	defer unitsMu.Unlock()
+	units[t] = copied
+}
+
+func unitsParser(t reflect.Type) (ParserFunc, bool) {
+	unitsMu.RLock()
+	conversions, ok := units[t]
+	unitsMu.RUnlock()
+	if !ok && t == celsiusType {
+		conversions, ok = temperatureUnits, true
+	}
+	if !ok {
+		return nil, false
+	}
+
+	return func(v string) (interface{}, error) {
+		f, err := parseUnits(v, conversions)
+		if err != nil {
+			return nil, err
+		}
+		return reflect.ValueOf(f).Convert(t).Interface(), nil
+	}, true
+}
+
+// parseUnits parses a number followed by a unit and converts it with the
+// conversion of the unit. The longest unit matching the end of v is used, so
+// units may be suffixes of each other.
+func parseUnits(v string, conversions map[string]func(float64) float64) (float64, error) {
+	v = strings.TrimSpace(v)
+	names := unitNames(conversions)
+	sort.SliceStable(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+	if _, ok := conversions[""]; ok {
+		names = append(names, "")
+	}
+	for _, unit := range names {
+		if !strings.HasSuffix(v, unit) {
+			continue
+		}
+		number := strings.TrimSpace(strings.TrimSuffix(v, unit))
+		f, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			if unit == "" {
+				break
+			}
+			return 0, fmt.Errorf("invalid number %q", number)
+		}
+		return conversions[unit](f), nil
+	}
+
+	unit := strings.TrimSpace(v[strings.LastIndexAny(v, "0123456789.")+1:])
+	if unit == "" {
+		return 0, fmt.Errorf("missing unit in %q, expected one of %s", v, strings.Join(unitNames(conversions), ", "))
+	}
+	return 0, fmt.Errorf("unknown unit %q, expected one of %s", unit, strings.Join(unitNames(conversions), ", "))
+}
+
+// unitNames returns the non empty units in order.
+func unitNames(conversions map[string]func(float64) float64) []string {
+	names := make([]string, 0, len(conversions))
+	for unit := range conversions {
+		if unit != "" {
+			names = append(names, unit)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
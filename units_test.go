@@ -0,0 +1,114 @@
+package conf_test
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Meters float32
+
+func init() {
+	conf.RegisterUnits(reflect.TypeOf(Meters(0)), map[string]func(float64) float64{
+		"m":  func(m float64) float64 { return m },
+		"cm": func(cm float64) float64 { return cm / 100 },
+		"km": func(km float64) float64 { return km * 1000 },
+		"ft": func(ft float64) float64 { return ft * 0.3048 },
+	})
+}
+
+func TestCelsius(t *testing.T) {
+	type config struct {
+		Fahrenheit conf.Celsius   `env:"FAHRENHEIT"`
+		Degrees    conf.Celsius   `env:"DEGREES"`
+		Kelvin     conf.Celsius   `env:"KELVIN"`
+		Plain      conf.Celsius   `env:"PLAIN"`
+		Ptr        *conf.Celsius  `env:"FAHRENHEIT"`
+		Range      []conf.Celsius `env:"RANGE"`
+		Default    conf.Celsius   `env:"DEFAULT" envDefault:"-40F"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("FAHRENHEIT", "72F")
+	os.Setenv("DEGREES", "212 °F")
+	os.Setenv("KELVIN", "0K")
+	os.Setenv("PLAIN", "21.5")
+	os.Setenv("RANGE", "18C,25°C,300K")
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.InDelta(t, 22.222, float64(cfg.Fahrenheit), 0.001)
+	assert.InDelta(t, 100, float64(cfg.Degrees), 0.001)
+	assert.InDelta(t, -273.15, float64(cfg.Kelvin), 0.001)
+	assert.Equal(t, conf.Celsius(21.5), cfg.Plain)
+	assert.Equal(t, cfg.Fahrenheit, *cfg.Ptr)
+	require.Len(t, cfg.Range, 3)
+	assert.InDelta(t, 18, float64(cfg.Range[0]), 0.001)
+	assert.InDelta(t, 25, float64(cfg.Range[1]), 0.001)
+	assert.InDelta(t, 26.85, float64(cfg.Range[2]), 0.001)
+	assert.InDelta(t, -40, float64(cfg.Default), 0.001)
+}
+
+func TestRegisteredUnits(t *testing.T) {
+	type config struct {
+		Meters      Meters `env:"METERS"`
+		Centimeters Meters `env:"CENTIMETERS"`
+		Kilometers  Meters `env:"KILOMETERS"`
+		Feet        Meters `env:"FEET"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("METERS", "1.5m")
+	os.Setenv("CENTIMETERS", "250cm")
+	os.Setenv("KILOMETERS", "2 km")
+	os.Setenv("FEET", "10ft")
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, Meters(1.5), cfg.Meters)
+	assert.Equal(t, Meters(2.5), cfg.Centimeters)
+	assert.Equal(t, Meters(2000), cfg.Kilometers)
+	assert.InDelta(t, 3.048, float64(cfg.Feet), 0.001)
+}
+
+func TestUnitsInvalid(t *testing.T) {
+	tests := []struct {
+		value string
+		err   string
+	}{
+		{"72R", `env: parse error on field "Temp" of type "conf.Celsius": unknown unit "R", expected one of C, F, K, °C, °F`},
+		{"warmF", `env: parse error on field "Temp" of type "conf.Celsius": invalid number "warm"`},
+		{"warm", `env: parse error on field "Temp" of type "conf.Celsius": unknown unit "warm", expected one of C, F, K, °C, °F`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			type config struct {
+				Temp conf.Celsius `env:"TEMP"`
+			}
+			defer os.Clearenv()
+			os.Setenv("TEMP", tt.value)
+
+			assert.EqualError(t, conf.Parse(&config{}, conf.EnvProvider), tt.err)
+		})
+	}
+}
+
+func TestUnitsMissingUnit(t *testing.T) {
+	type config struct {
+		Distance Meters `env:"DISTANCE"`
+	}
+	defer os.Clearenv()
+	os.Setenv("DISTANCE", "12")
+
+	assert.EqualError(t, conf.Parse(&config{}, conf.EnvProvider), `env: parse error on field "Distance" of type "conf_test.Meters": missing unit in "12", expected one of cm, ft, km, m`)
+}
+
+func TestRegisterUnitsNonFloat(t *testing.T) {
+	assert.PanicsWithValue(t, "conf: RegisterUnits called with non float type int", func() {
+		conf.RegisterUnits(reflect.TypeOf(0), map[string]func(float64) float64{})
+	})
+}
@@ -0,0 +1,68 @@
+package conf
+
+import "reflect"
+
+// EnvUnmarshaler is implemented by types which read themselves from a
+// provider, e.g. to derive several fields from one variable. A struct field
+// whose type implements it, directly or through its pointer, is not parsed
+// field by field and its own tags are ignored: UnmarshalEnv is called instead,
+// once for each provider. Nil pointer fields are skipped.
+//
+// The provider applies the `envPrefix` of the enclosing struct fields to the
+// keys of the fields passed to it.
+type EnvUnmarshaler interface {
+	UnmarshalEnv(provider Provider) error
+}
+
+// nolint: gochecknoglobals
+var envUnmarshalerType = reflect.TypeOf((*EnvUnmarshaler)(nil)).Elem()
+
+// asEnvUnmarshaler returns field as an EnvUnmarshaler if its type or its
+// pointer implements it.
+func asEnvUnmarshaler(field reflect.Value) (EnvUnmarshaler, bool) {
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() || !field.Type().Implements(envUnmarshalerType) {
+			return nil, false
+		}
+		return field.Interface().(EnvUnmarshaler), true
+	}
+	if field.CanAddr() && field.Addr().Type().Implements(envUnmarshalerType) {
+		return field.Addr().Interface().(EnvUnmarshaler), true
+	}
+	return nil, false
+}
+
+// unmarshalEnv calls the UnmarshalEnv method of the field sf with the
+// provider of the pass.
+func (p *parser) unmarshalEnv(u EnvUnmarshaler, sf reflect.StructField) error {
+	var provider Provider = prefixedProvider{provider: p.provider, prefix: p.prefix}
+	if p.prefix == "" {
+		provider = p.provider
+	}
+	err := u.UnmarshalEnv(provider)
+	switch err.(type) {
+	case nil, parseError, prefixedError:
+		// errors of the provider are returned as they are
+		return err
+	}
+	return newParseError(sf, err)
+}
+
+// prefixedProvider adds prefix to the keys of the fields passed to provider.
+type prefixedProvider struct {
+	provider Provider
+	prefix   string
+}
+
+func (p prefixedProvider) Provide(field reflect.StructField) (string, error) {
+	return p.provider.Provide(withKeyPrefix(field, p.prefix))
+}
+
+func (p prefixedProvider) ProvideResult(field reflect.StructField) (Result, error) {
+	field = withKeyPrefix(field, p.prefix)
+	if rp, ok := p.provider.(ResultProvider); ok {
+		return rp.ProvideResult(field)
+	}
+	value, err := p.provider.Provide(field)
+	return Result{Value: value}, err
+}
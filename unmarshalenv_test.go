@@ -0,0 +1,87 @@
+package conf_test
+
+import (
+	"errors"
+	"net"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listener derives its host and port from a single ADDR variable and reads
+// its TLS setting from another.
+type listener struct {
+	Host string
+	Port string
+	TLS  bool `env:"TLS"`
+}
+
+func (l *listener) UnmarshalEnv(provider conf.Provider) error {
+	addr, err := provider.Provide(reflect.StructField{Name: "Addr", Tag: `env:"ADDR,required"`})
+	if err != nil {
+		return err
+	}
+	if addr == "" {
+		return nil
+	}
+	if l.Host, l.Port, err = net.SplitHostPort(addr); err != nil {
+		return err
+	}
+	tls, err := provider.Provide(reflect.StructField{Name: "TLS", Tag: `env:"TLS" envDefault:"false"`})
+	l.TLS = tls == "true"
+	return err
+}
+
+func TestUnmarshalEnv(t *testing.T) {
+	type config struct {
+		Public   listener  `envPrefix:"PUBLIC_"`
+		Admin    *listener `envPrefix:"ADMIN_"`
+		Internal *listener `envPrefix:"INTERNAL_"`
+		Name     string    `env:"NAME"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("PUBLIC_ADDR", "0.0.0.0:443")
+	os.Setenv("PUBLIC_TLS", "true")
+	os.Setenv("ADMIN_ADDR", "127.0.0.1:8081")
+	os.Setenv("NAME", "api")
+
+	cfg := config{Admin: &listener{}}
+	require.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, listener{Host: "0.0.0.0", Port: "443", TLS: true}, cfg.Public)
+	assert.Equal(t, &listener{Host: "127.0.0.1", Port: "8081"}, cfg.Admin)
+	assert.Nil(t, cfg.Internal)
+	assert.Equal(t, "api", cfg.Name)
+}
+
+func TestUnmarshalEnvErrors(t *testing.T) {
+	type config struct {
+		Public listener `envPrefix:"PUBLIC_"`
+	}
+	defer os.Clearenv()
+
+	cfg := config{}
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: required environment variable "PUBLIC_ADDR" is not set`)
+
+	os.Setenv("PUBLIC_ADDR", "nope")
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: parse error on field "Public" of type "conf_test.listener": address nope: missing port in address`)
+}
+
+type failingUnmarshaler struct{}
+
+func (failingUnmarshaler) UnmarshalEnv(conf.Provider) error {
+	return errors.New("boom")
+}
+
+func TestUnmarshalEnvValueReceiver(t *testing.T) {
+	type config struct {
+		Value failingUnmarshaler `env:"VALUE"`
+	}
+
+	cfg := config{}
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: parse error on field "Value" of type "conf_test.failingUnmarshaler": boom`)
+}
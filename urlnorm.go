@@ -0,0 +1,48 @@
+package conf
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// nolint: gochecknoglobals
+var urlType = reflect.TypeOf(url.URL{})
+
+// normalizeURL applies the envURLScheme and envURLTrailingSlash tags to a
+// parsed url.URL field. Without the tags the URL is left as parsed.
+func normalizeURL(field reflect.Value, sf reflect.StructField, value string) error {
+	if field.Type() != urlType {
+		return nil
+	}
+	u := field.Addr().Interface().(*url.URL)
+
+	// a value without a scheme, such as "example.com/api", parses as a path
+	// and "localhost:8080" parses with "localhost" as its scheme, so neither
+	// has a host
+	if scheme := sf.Tag.Get("envURLScheme"); scheme != "" && u.Host == "" {
+		parsed, err := url.Parse(scheme + "://" + value)
+		if err != nil {
+			return newParseError(sf, err)
+		}
+		*u = *parsed
+	}
+
+	switch mode := sf.Tag.Get("envURLTrailingSlash"); mode {
+	case "":
+	case "strip":
+		u.Path = strings.TrimRight(u.Path, "/")
+		u.RawPath = strings.TrimRight(u.RawPath, "/")
+	case "ensure":
+		if !strings.HasSuffix(u.Path, "/") {
+			u.Path += "/"
+			if u.RawPath != "" {
+				u.RawPath += "/"
+			}
+		}
+	default:
+		return newParseError(sf, fmt.Errorf(`envURLTrailingSlash %q not supported, expected "strip" or "ensure"`, mode))
+	}
+	return nil
+}
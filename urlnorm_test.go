@@ -0,0 +1,79 @@
+package conf_test
+
+import (
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestURLScheme(t *testing.T) {
+	type config struct {
+		API     url.URL  `env:"API" envURLScheme:"https"`
+		Proxy   *url.URL `env:"PROXY" envURLScheme:"http"`
+		Backend url.URL  `env:"BACKEND" envURLScheme:"https"`
+		Plain   url.URL  `env:"PLAIN"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("API", "example.com/v1")
+	os.Setenv("PROXY", "localhost:3128")
+	os.Setenv("BACKEND", "http://backend.internal")
+	os.Setenv("PLAIN", "example.com/v1")
+
+	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, "https://example.com/v1", cfg.API.String())
+	assert.Equal(t, "http://localhost:3128", cfg.Proxy.String())
+	assert.Equal(t, "http://backend.internal", cfg.Backend.String())
+	assert.Equal(t, "example.com/v1", cfg.Plain.String())
+}
+
+func TestURLTrailingSlash(t *testing.T) {
+	type config struct {
+		Strip     url.URL `env:"STRIP" envURLTrailingSlash:"strip"`
+		Ensure    url.URL `env:"ENSURE" envURLTrailingSlash:"ensure"`
+		Present   url.URL `env:"PRESENT" envURLTrailingSlash:"ensure"`
+		Untouched url.URL `env:"UNTOUCHED"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("STRIP", "https://example.com/api//")
+	os.Setenv("ENSURE", "https://example.com/api")
+	os.Setenv("PRESENT", "https://example.com/api/")
+	os.Setenv("UNTOUCHED", "https://example.com/api/")
+
+	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, "https://example.com/api", cfg.Strip.String())
+	assert.Equal(t, "https://example.com/api/", cfg.Ensure.String())
+	assert.Equal(t, "https://example.com/api/", cfg.Present.String())
+	assert.Equal(t, "https://example.com/api/", cfg.Untouched.String())
+}
+
+func TestURLSchemeAndTrailingSlash(t *testing.T) {
+	type config struct {
+		API url.URL `env:"API" envURLScheme:"https" envURLTrailingSlash:"ensure"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("API", "example.com")
+
+	cfg := config{}
+	assert.NoError(t, conf.Parse(&cfg, conf.EnvProvider))
+	assert.Equal(t, "https://example.com/", cfg.API.String())
+}
+
+func TestURLTrailingSlashUnsupported(t *testing.T) {
+	type config struct {
+		API url.URL `env:"API" envURLTrailingSlash:"keep"`
+	}
+	defer os.Clearenv()
+
+	os.Setenv("API", "https://example.com")
+
+	cfg := config{}
+	assert.EqualError(t, conf.Parse(&cfg, conf.EnvProvider), `env: parse error on field "API" of type "url.URL": envURLTrailingSlash "keep" not supported, expected "strip" or "ensure"`)
+}
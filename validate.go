@@ -0,0 +1,71 @@
+package conf
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Validator is implemented by config structs which check their own
+// invariants, such as one setting not exceeding another. With WithValidators,
+// Validate is called once every provider has been applied, for the struct
+// passed to ParseWithOptions and for every struct nested in it, innermost
+// first.
+type Validator interface {
+	Validate() error
+}
+
+// nolint: gochecknoglobals
+var validatorType = reflect.TypeOf((*Validator)(nil)).Elem()
+
+// validationError is the error returned by the Validate method of a struct,
+// with the field holding the struct, or none for the struct passed to Parse.
+type validationError struct {
+	field  string
+	err    error
+	prefix string
+	// redacted hides err, which may quote values, with WithNeverEchoValues.
+	redacted bool
+}
+
+func (e validationError) Error() string {
+	msg := errorPrefix(e.prefix) + ": validation failed"
+	if e.field != "" {
+		msg += fmt.Sprintf(` on field "%s"`, e.field)
+	}
+	if e.redacted {
+		return msg
+	}
+	return msg + ": " + e.err.Error()
+}
+
+func (e validationError) Unwrap() error {
+	return e.err
+}
+
+// checkValidators calls the Validate method of the structs nested in ref and
+// then of ref itself. A struct with a field rejected by the field filter is
+// not validated.
+func checkValidators(ref reflect.Value, opts options) error {
+	return walkFields(ref, opts, func(s walkedStruct) error {
+		validator, ok := asValidator(s.ref)
+		if !ok || s.filtered {
+			return nil
+		}
+		if err := validator.Validate(); err != nil {
+			return validationError{field: s.field, err: err, redacted: opts.neverEcho}
+		}
+		return nil
+	})
+}
+
+// asValidator returns ref as a Validator, whether Validate has a value or a
+// pointer receiver.
+func asValidator(ref reflect.Value) (Validator, bool) {
+	if ref.CanAddr() && ref.Addr().Type().Implements(validatorType) {
+		return ref.Addr().Interface().(Validator), true
+	}
+	if ref.Type().Implements(validatorType) {
+		return ref.Interface().(Validator), true
+	}
+	return nil, false
+}
@@ -0,0 +1,91 @@
+package conf_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errNoTLS = errors.New("tls is required outside development")
+
+type tlsListener struct {
+	Dev bool   `env:"DEV"`
+	TLS string `env:"TLS_CERT"`
+}
+
+func (l *tlsListener) Validate() error {
+	if !l.Dev && l.TLS == "" {
+		return errNoTLS
+	}
+	return nil
+}
+
+type validatedServer struct {
+	Listener tlsListener
+	Name     string `env:"NAME"`
+}
+
+func (s validatedServer) Validate() error {
+	if s.Name == "" {
+		return errors.New("name must be set")
+	}
+	return nil
+}
+
+func TestValidate(t *testing.T) {
+	cfg := validatedServer{}
+	require.NoError(t, conf.ParseWithOptions(&cfg, []conf.Option{conf.WithValidators()}, conf.NewMapProvider(map[string]string{
+		"NAME":     "api",
+		"TLS_CERT": "cert.pem",
+	})))
+}
+
+func TestValidateNested(t *testing.T) {
+	cfg := validatedServer{}
+	err := conf.ParseWithOptions(&cfg, []conf.Option{conf.WithValidators(), conf.WithErrorPrefix("config")}, conf.NewMapProvider(map[string]string{
+		"NAME": "api",
+	}))
+	assert.EqualError(t, err, `config: validation failed on field "Listener": tls is required outside development`)
+	assert.True(t, errors.Is(err, errNoTLS))
+}
+
+func TestValidateRoot(t *testing.T) {
+	cfg := validatedServer{}
+	err := conf.ParseWithOptions(&cfg, []conf.Option{conf.WithValidators()}, conf.NewMapProvider(map[string]string{
+		"DEV": "true",
+	}))
+	assert.EqualError(t, err, `env: validation failed: name must be set`)
+}
+
+func TestValidateNeverEcho(t *testing.T) {
+	cfg := validatedServer{}
+	err := conf.ParseWithOptions(&cfg, []conf.Option{conf.WithValidators(), conf.WithNeverEchoValues()}, conf.NewMapProvider(map[string]string{
+		"NAME": "api",
+	}))
+	assert.EqualError(t, err, `env: validation failed on field "Listener"`)
+	assert.True(t, errors.Is(err, errNoTLS))
+}
+
+func TestValidateOptIn(t *testing.T) {
+	cfg := validatedServer{}
+	require.NoError(t, conf.Parse(&cfg, conf.NewMapProvider(map[string]string{})))
+}
+
+func TestValidateFieldFilter(t *testing.T) {
+	onlyListener := conf.WithFieldFilter(func(sf reflect.StructField) bool {
+		return sf.Name != "Name"
+	})
+	cfg := validatedServer{}
+	err := conf.ParseWithOptions(&cfg, []conf.Option{conf.WithValidators(), onlyListener}, conf.NewMapProvider(map[string]string{
+		"TLS_CERT": "cert.pem",
+	}))
+	assert.NoError(t, err)
+
+	cfg = validatedServer{}
+	err = conf.ParseWithOptions(&cfg, []conf.Option{conf.WithValidators(), onlyListener}, conf.NewMapProvider(map[string]string{}))
+	assert.EqualError(t, err, `env: validation failed on field "Listener": tls is required outside development`)
+}
@@ -0,0 +1,65 @@
+package conf
+
+import "reflect"
+
+// walkedStruct is a struct visited by walkFields.
+type walkedStruct struct {
+	ref reflect.Value
+	// field is the name of the field holding the struct, or empty for the
+	// struct passed to Parse.
+	field string
+	// prefix is the `envPrefix` of the struct fields the struct is nested in,
+	// which Parse prepends to the keys of its fields.
+	prefix string
+	// fields are the indexes of the settable fields of the struct which the
+	// field filter accepts, and filtered reports whether it rejected any.
+	fields   []int
+	filtered bool
+}
+
+// key returns the key the field at index i is read from, with the prefix,
+// for use in error messages.
+func (s walkedStruct) key(i int) string {
+	return fieldKey(withKeyPrefix(s.ref.Type().Field(i), s.prefix))
+}
+
+// walkFields calls fn for ref and every struct nested in it, through struct
+// fields and non-nil pointers to structs, innermost first. It is shared by
+// the checks which run once every provider has been applied, so they agree
+// on which fields are checked and the keys they report. The fields rejected
+// by the field filter are skipped along with the structs they hold.
+func walkFields(ref reflect.Value, opts options, fn func(s walkedStruct) error) error {
+	return walkStruct(walkedStruct{ref: ref}, opts, fn)
+}
+
+func walkStruct(s walkedStruct, opts options, fn func(s walkedStruct) error) error {
+	var refType = s.ref.Type()
+
+	for i := 0; i < refType.NumField(); i++ {
+		refField := s.ref.Field(i)
+		refTypeField := refType.Field(i)
+		if !refField.CanSet() {
+			continue
+		}
+		if opts.fieldFilter != nil && !opts.fieldFilter(refTypeField) {
+			s.filtered = true
+			continue
+		}
+		s.fields = append(s.fields, i)
+
+		if reflect.Ptr == refField.Kind() && !refField.IsNil() {
+			refField = refField.Elem()
+		}
+		if reflect.Struct == refField.Kind() {
+			nested := walkedStruct{
+				ref:    refField,
+				field:  refTypeField.Name,
+				prefix: s.prefix + refTypeField.Tag.Get("envPrefix"),
+			}
+			if err := walkStruct(nested, opts, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return fn(s)
+}
@@ -0,0 +1,31 @@
+package conf
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// splitWidth splits value into the chunks of the `envWidth` tag of sf, for
+// fixed-width records such as "ab12cd34" with a width of 4. The width counts
+// characters rather than bytes, and ok is false for fields without the tag.
+func splitWidth(value string, sf reflect.StructField) (parts []string, ok bool, err error) {
+	tag, ok := sf.Tag.Lookup("envWidth")
+	if !ok {
+		return nil, false, nil
+	}
+	width, err := strconv.Atoi(tag)
+	if err != nil || width < 1 {
+		return nil, true, newError(`field "%s" has invalid envWidth %q`, sf.Name, tag)
+	}
+
+	runes := []rune(value)
+	if len(runes)%width != 0 {
+		return nil, true, newParseError(sf, fmt.Errorf("length %d is not a multiple of width %d", len(runes), width))
+	}
+	parts = make([]string, 0, len(runes)/width)
+	for i := 0; i < len(runes); i += width {
+		parts = append(parts, string(runes[i:i+width]))
+	}
+	return parts, true, nil
+}
@@ -0,0 +1,56 @@
+package conf_test
+
+import (
+	"testing"
+
+	"github.com/steinfletcher/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fixedWidthConfig struct {
+	Records []string `env:"RECORDS" envWidth:"4"`
+	Codes   []int    `env:"CODES" envWidth:"2"`
+}
+
+func TestFixedWidth(t *testing.T) {
+	cfg := fixedWidthConfig{}
+	require.NoError(t, conf.Parse(&cfg, conf.NewMapProvider(map[string]string{
+		"RECORDS": "ab12cd34",
+		"CODES":   "010203",
+	})))
+	assert.Equal(t, []string{"ab12", "cd34"}, cfg.Records)
+	assert.Equal(t, []int{1, 2, 3}, cfg.Codes)
+}
+
+func TestFixedWidthCharacters(t *testing.T) {
+	type config struct {
+		Records []string `env:"RECORDS" envWidth:"2"`
+	}
+
+	cfg := config{}
+	require.NoError(t, conf.Parse(&cfg, conf.NewMapProvider(map[string]string{"RECORDS": "äböü"})))
+	assert.Equal(t, []string{"äb", "öü"}, cfg.Records)
+}
+
+func TestFixedWidthNotMultiple(t *testing.T) {
+	cfg := fixedWidthConfig{}
+	err := conf.Parse(&cfg, conf.NewMapProvider(map[string]string{"RECORDS": "ab12cd3"}))
+	assert.EqualError(t, err, `env: parse error on field "Records" of type "[]string": length 7 is not a multiple of width 4`)
+}
+
+func TestFixedWidthInvalidTag(t *testing.T) {
+	type config struct {
+		Records []string `env:"RECORDS" envWidth:"0"`
+	}
+
+	cfg := config{}
+	err := conf.Parse(&cfg, conf.NewMapProvider(map[string]string{"RECORDS": "ab12"}))
+	assert.EqualError(t, err, `env: field "Records" has invalid envWidth "0"`)
+}
+
+func TestFixedWidthMarshal(t *testing.T) {
+	out, err := conf.MarshalEnv(fixedWidthConfig{Records: []string{"ab12", "cd34"}, Codes: []int{10, 20}})
+	require.NoError(t, err)
+	assert.Equal(t, "RECORDS=ab12cd34\nCODES=1020\n", string(out))
+}